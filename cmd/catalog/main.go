@@ -0,0 +1,204 @@
+// Command catalog imports and exports the product catalog from the command line.
+//
+//	go run ./cmd/catalog import [-format csv|ndjson] [-dry-run] <file>
+//	go run ./cmd/catalog export [-format csv|ndjson] [-o <file>]
+//	go run ./cmd/catalog rates <file>
+//	go run ./cmd/catalog related
+//
+// related updates the "frequently bought together" counts with the orders
+// placed since its last run; schedule it to keep recommendations fresh.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/catalog"
+	"github.com/Hiroki111/go-backend-example/internal/database"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.NewPostgresDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo := repository.NewRepository(db)
+	if code, ok := os.LookupEnv("BASE_CURRENCY"); ok {
+		if err := repo.SetBaseCurrency(code); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := repo.Migrate(); err != nil {
+		log.Fatal(err)
+	}
+
+	switch os.Args[1] {
+	case "import":
+		err = runImport(repo, os.Args[2:])
+	case "export":
+		err = runExport(repo, os.Args[2:])
+	case "rates":
+		err = runRates(repo, os.Args[2:])
+	case "related":
+		err = runRelated(repo, os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	log.Fatal("usage: catalog import [-format csv|ndjson] [-dry-run] <file>\n" +
+		"       catalog export [-format csv|ndjson] [-o <file>]\n" +
+		"       catalog rates <file>\n" +
+		"       catalog related")
+}
+
+func runImport(repo *repository.Repository, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	formatValue := flags.String("format", "", "csv or ndjson (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate without saving")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		usage()
+	}
+	path := flags.Arg(0)
+
+	if *formatValue == "" {
+		*formatValue = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	format, err := catalog.ParseFormat(*formatValue)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, recordErrors, err := catalog.Decode(format, file)
+	if err != nil {
+		return err
+	}
+
+	result, err := repo.ImportProducts(records, *dryRun || len(recordErrors) > 0, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, recordErr := range append(recordErrors, result.Errors...) {
+		fmt.Fprintln(os.Stderr, recordErr)
+	}
+	fmt.Printf("created: %d, updated: %d\n", result.Created, result.Updated)
+
+	switch {
+	case len(recordErrors) > 0 || len(result.Errors) > 0:
+		return errors.New("import rejected, nothing was saved")
+	case *dryRun:
+		fmt.Println("dry run, nothing was saved")
+	}
+	return nil
+}
+
+func runExport(repo *repository.Repository, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatValue := flags.String("format", "csv", "csv or ndjson")
+	output := flags.String("o", "", "output file (default: stdout)")
+	flags.Parse(args)
+
+	format, err := catalog.ParseFormat(*formatValue)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	buffered := bufio.NewWriter(out)
+	encoder, err := catalog.NewEncoder(format, buffered)
+	if err != nil {
+		return err
+	}
+	err = repo.EachProduct(func(product domain.Product) error {
+		return encoder.Encode(product)
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// runRates loads exchange rates from a CSV file with a currency,rate header.
+func runRates(repo *repository.Repository, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rates, err := money.DecodeRates(file)
+	if err != nil {
+		return err
+	}
+	if err := repo.SetExchangeRates(rates); err != nil {
+		return err
+	}
+
+	fmt.Printf("saved %d exchange rates against %s\n", len(rates), repo.BaseCurrency().Code)
+	return nil
+}
+
+func runRelated(repo *repository.Repository, args []string) error {
+	if len(args) != 0 {
+		usage()
+	}
+
+	counted, err := repo.UpdateProductAffinities(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("counted %d new orders\n", counted)
+	return nil
+}
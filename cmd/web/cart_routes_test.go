@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func getCart(t *testing.T, app http.Handler, token string) handler.CartResponse {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/cart", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	return decodeJSON[handler.CartResponse](t, rec)
+}
+
+func cartWarnings(cart handler.CartResponse) []string {
+	codes := make([]string, len(cart.Warnings))
+	for i, warning := range cart.Warnings {
+		codes[i] = warning.Code
+	}
+	return codes
+}
+
+func TestCartItems(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+	})
+	token := registerAndLogin(t, app, "customer")
+
+	if cart := getCart(t, app, token); len(cart.Items) != 0 || cart.TotalCents != 0 {
+		t.Fatalf("expected an empty cart, got %+v", cart)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 2})
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 2, Quantity: 1})
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 1})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	cart := decodeJSON[handler.CartResponse](t, rec)
+	if len(cart.Items) != 2 || cart.Items[0].Quantity != 3 || cart.TotalCents != 550 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/cart/items/2", token, handler.CartItemQuantityRequest{Quantity: 4})
+	cart = decodeJSON[handler.CartResponse](t, rec)
+	if cart.Items[1].Quantity != 4 || cart.TotalCents != 1300 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/cart/items/1", token, nil)
+	cart = decodeJSON[handler.CartResponse](t, rec)
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != 2 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	// Another user's cart is separate.
+	other := registerAndLogin(t, app, "other")
+	if cart := getCart(t, app, other); len(cart.Items) != 0 {
+		t.Fatalf("expected an empty cart, got %+v", cart)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         any
+		expectedCode int
+	}{
+		{name: "unknown product", method: http.MethodPost, path: "/cart/items", body: handler.CartItemRequest{ProductID: 9, Quantity: 1}, expectedCode: http.StatusNotFound},
+		{name: "zero quantity", method: http.MethodPost, path: "/cart/items", body: handler.CartItemRequest{ProductID: 1, Quantity: 0}, expectedCode: http.StatusBadRequest},
+		{name: "update item not in cart", method: http.MethodPut, path: "/cart/items/1", body: handler.CartItemQuantityRequest{Quantity: 1}, expectedCode: http.StatusNotFound},
+		{name: "remove item not in cart", method: http.MethodDelete, path: "/cart/items/1", expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, test.method, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+
+	rec = executeRequest(t, app, http.MethodPost, "/cart/checkout", nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestCartWarnings(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+		{Name: "cherry", PriceCents: 300},
+	})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	token := registerAndLogin(t, app, "customer")
+
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/3/stock/1", admin, handler.StockRequest{OnHand: 1})
+	for _, id := range []uint{1, 2, 3} {
+		executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: id, Quantity: 2})
+	}
+	if cart := getCart(t, app, token); len(cart.Warnings) != 1 || cart.Warnings[0].Code != handler.CartWarningInsufficientStock {
+		t.Fatalf("expected an insufficient stock warning, got %v", cartWarnings(cart))
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1", admin, handler.ProductRequest{Name: "apple", PriceCents: 120})
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/2", admin, nil)
+
+	cart := getCart(t, app, token)
+	codes := cartWarnings(cart)
+	expected := []string{handler.CartWarningPriceChanged, handler.CartWarningUnavailable, handler.CartWarningInsufficientStock}
+	if len(codes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, codes)
+	}
+	for i := range expected {
+		if codes[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, codes)
+		}
+	}
+
+	apple := cart.Items[0]
+	if apple.UnitPriceCents != 120 || apple.PreviousUnitPriceCents == nil || *apple.PreviousUnitPriceCents != 100 {
+		t.Fatalf("unexpected apple line: %+v", apple)
+	}
+	if banana := cart.Items[1]; banana.Available || banana.ProductName != "banana" {
+		t.Fatalf("unexpected banana line: %+v", banana)
+	}
+	// Unavailable items are left out of the total.
+	if cart.TotalCents != 2*120+2*300 {
+		t.Fatalf("expected total %d, got %d", 2*120+2*300, cart.TotalCents)
+	}
+
+	// Changing the quantity accepts the new price.
+	rec := executeRequestWithToken(t, app, http.MethodPut, "/cart/items/1", token, handler.CartItemQuantityRequest{Quantity: 1})
+	cart = decodeJSON[handler.CartResponse](t, rec)
+	if cart.Items[0].PreviousUnitPriceCents != nil {
+		t.Fatalf("expected the price change to be accepted, got %+v", cart.Items[0])
+	}
+}
+
+func TestCheckoutCart(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+	})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	token := registerAndLogin(t, app, "customer")
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an empty cart, got %d", rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/2/stock/1", admin, handler.StockRequest{OnHand: 1})
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 3})
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 2, Quantity: 2})
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when out of stock, got %d", rec.Code)
+	}
+	if cart := getCart(t, app, token); len(cart.Items) != 2 {
+		t.Fatalf("expected the cart to be left as it was, got %+v", cart)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/cart/items/2", token, handler.CartItemQuantityRequest{Quantity: 1})
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	order := decodeJSON[handler.OrderResponse](t, rec)
+	if len(order.Items) != 2 || order.TotalCents != 3*100+250 || order.Status != "pending" {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+
+	if cart := getCart(t, app, token); len(cart.Items) != 0 {
+		t.Fatalf("expected an empty cart, got %+v", cart)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders", token, nil)
+	if orders := decodeJSON[map[string][]handler.OrderResponse](t, rec); len(orders["items"]) != 1 {
+		t.Fatalf("expected 1 order, got %d", len(orders["items"]))
+	}
+}
+
+// executeCartRequest sends a request as a guest with the given cart token,
+// or as the user with the given bearer token when it's not empty.
+func executeCartRequest(t *testing.T, app http.Handler, method, path, token, cartToken string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cartToken != "" {
+		req.Header.Set(handler.CartTokenHeader, cartToken)
+	}
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGuestCart(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+	})
+
+	rec := executeCartRequest(t, app, http.MethodGet, "/cart", "", "", nil)
+	if cart := decodeJSON[handler.CartResponse](t, rec); rec.Code != http.StatusOK || len(cart.Items) != 0 || cart.CartToken != "" {
+		t.Fatalf("expected an empty cart without a token, got %d %+v", rec.Code, cart)
+	}
+
+	rec = executeCartRequest(t, app, http.MethodPost, "/cart/items", "", "", handler.CartItemRequest{ProductID: 1, Quantity: 2})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	cartToken := rec.Header().Get(handler.CartTokenHeader)
+	if cart := decodeJSON[handler.CartResponse](t, rec); cartToken == "" || cart.CartToken != cartToken {
+		t.Fatalf("expected the cart token in the header and body, got %q and %q", cartToken, cart.CartToken)
+	}
+
+	executeCartRequest(t, app, http.MethodPost, "/cart/items", "", cartToken, handler.CartItemRequest{ProductID: 2, Quantity: 1})
+	executeCartRequest(t, app, http.MethodPut, "/cart/items/2", "", cartToken, handler.CartItemQuantityRequest{Quantity: 3})
+	rec = executeCartRequest(t, app, http.MethodGet, "/cart", "", cartToken, nil)
+	cart := decodeJSON[handler.CartResponse](t, rec)
+	if len(cart.Items) != 2 || cart.TotalCents != 2*100+3*250 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	rec = executeCartRequest(t, app, http.MethodDelete, "/cart/items/2", "", cartToken, nil)
+	if cart := decodeJSON[handler.CartResponse](t, rec); len(cart.Items) != 1 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		cartToken    string
+		body         any
+		expectedCode int
+	}{
+		{name: "get unknown cart", method: http.MethodGet, path: "/cart", cartToken: "unknown", expectedCode: http.StatusNotFound},
+		{name: "add to unknown cart", method: http.MethodPost, path: "/cart/items", cartToken: "unknown", body: handler.CartItemRequest{ProductID: 1, Quantity: 1}, expectedCode: http.StatusNotFound},
+		{name: "update without a cart", method: http.MethodPut, path: "/cart/items/1", body: handler.CartItemQuantityRequest{Quantity: 1}, expectedCode: http.StatusNotFound},
+		{name: "remove without a cart", method: http.MethodDelete, path: "/cart/items/1", expectedCode: http.StatusNotFound},
+		{name: "check out as a guest", method: http.MethodPost, path: "/cart/checkout", cartToken: cartToken, expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeCartRequest(t, app, test.method, test.path, "", test.cartToken, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestMergeGuestCartOnLogin(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+		{Name: "cherry", PriceCents: 300},
+		{Name: "durian", PriceCents: 900},
+		{Name: "elderberry", PriceCents: 400},
+	})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/2/stock/1", admin, handler.StockRequest{OnHand: 5})
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/4/stock/1", admin, handler.StockRequest{OnHand: 0})
+
+	token := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 1})
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 2, Quantity: 3})
+
+	rec := executeCartRequest(t, app, http.MethodPost, "/cart/items", "", "", handler.CartItemRequest{ProductID: 1, Quantity: 2})
+	cartToken := rec.Header().Get(handler.CartTokenHeader)
+	for _, item := range []handler.CartItemRequest{
+		{ProductID: 2, Quantity: 4},
+		{ProductID: 3, Quantity: 1},
+		{ProductID: 4, Quantity: 1},
+		{ProductID: 5, Quantity: 1},
+	} {
+		executeCartRequest(t, app, http.MethodPost, "/cart/items", "", cartToken, item)
+	}
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/5", admin, nil)
+
+	rec = executeCartRequest(t, app, http.MethodPost, "/login-user", "", cartToken, handler.LoginUserRequest{UserName: "customer", Password: "password"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	token = decodeJSON[handler.LoginUserResponse](t, rec).AccessToken
+
+	cart := getCart(t, app, token)
+	quantities := make(map[uint]int)
+	for _, item := range cart.Items {
+		quantities[item.ProductID] = item.Quantity
+	}
+	// Quantities are added together, banana is capped at its stock, and the
+	// out of stock durian and deleted elderberry are dropped.
+	expected := map[uint]int{1: 3, 2: 5, 3: 1}
+	if len(quantities) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, quantities)
+	}
+	for id, quantity := range expected {
+		if quantities[id] != quantity {
+			t.Fatalf("expected %v, got %v", expected, quantities)
+		}
+	}
+
+	// The guest cart is gone once merged, and logging in with it again
+	// changes nothing.
+	rec = executeCartRequest(t, app, http.MethodGet, "/cart", "", cartToken, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	rec = executeCartRequest(t, app, http.MethodPost, "/login-user", "", cartToken, handler.LoginUserRequest{UserName: "customer", Password: "password"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if cart := getCart(t, app, token); len(cart.Items) != 3 {
+		t.Fatalf("expected 3 items, got %+v", cart.Items)
+	}
+}
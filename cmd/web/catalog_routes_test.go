@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func skuPtr(sku string) *string {
+	return &sku
+}
+
+func TestImportProducts(t *testing.T) {
+	existing := []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", SKU: skuPtr("BAN-1"), PriceCents: 200},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		expectedCode   int
+		expectedLines  []int
+		expectedPrices map[string]int64
+	}{
+		{
+			name:         "csv upsert by name and sku",
+			path:         "/admin/products/import",
+			contentType:  "text/csv",
+			body:         "sku,name,price_cents\n,apple,150\nBAN-1,banana split,250\nCHE-1,cherry,300\n",
+			expectedCode: http.StatusOK,
+			expectedPrices: map[string]int64{
+				"apple":        150,
+				"banana split": 250,
+				"cherry":       300,
+			},
+		},
+		{
+			name:         "ndjson selected by query parameter",
+			path:         "/admin/products/import?format=ndjson",
+			contentType:  "application/octet-stream",
+			body:         `{"name":"cherry","price_cents":300}` + "\n" + `{"sku":"BAN-1","name":"banana","price_cents":210}` + "\n",
+			expectedCode: http.StatusOK,
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 210,
+				"cherry": 300,
+			},
+		},
+		{
+			name:         "dry run does not write",
+			path:         "/admin/products/import?dryRun=true",
+			contentType:  "text/csv",
+			body:         "name,price_cents\napple,999\ncherry,300\n",
+			expectedCode: http.StatusOK,
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 200,
+			},
+		},
+		{
+			name:          "row errors reject the whole file",
+			path:          "/admin/products/import",
+			contentType:   "text/csv",
+			body:          "sku,name,price_cents\n,cherry,300\n,,100\n,apple,abc\nBAN-1,apple,100\n,cherry,400\n",
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedLines: []int{3, 4, 5, 6},
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 200,
+			},
+		},
+		{
+			name:          "two rows for the same product",
+			path:          "/admin/products/import",
+			contentType:   "text/csv",
+			body:          "sku,name,price_cents\nBAN-1,plantain,250\n,banana,300\n",
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedLines: []int{3},
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 200,
+			},
+		},
+		{
+			name:         "unsupported format",
+			path:         "/admin/products/import",
+			contentType:  "application/xml",
+			body:         "<products/>",
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 200,
+			},
+		},
+		{
+			name:         "missing csv column",
+			path:         "/admin/products/import",
+			contentType:  "text/csv",
+			body:         "name\napple\n",
+			expectedCode: http.StatusBadRequest,
+			expectedPrices: map[string]int64{
+				"apple":  100,
+				"banana": 200,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, existing)
+			token := loginAdmin(t, app)
+
+			rec := executeRawRequest(t, app, http.MethodPost, test.path, token, test.contentType, strings.NewReader(test.body))
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+
+			if test.expectedLines != nil {
+				resp := decodeJSON[handler.ImportProductsResponse](t, rec)
+				lines := make([]int, 0, len(resp.Errors))
+				for _, e := range resp.Errors {
+					lines = append(lines, e.Line)
+				}
+				if !reflect.DeepEqual(test.expectedLines, lines) {
+					t.Fatalf("expected errors on lines %v, got %v", test.expectedLines, resp.Errors)
+				}
+			}
+
+			var products []domain.Product
+			if err := db.Find(&products).Error; err != nil {
+				t.Fatal(err)
+			}
+			prices := make(map[string]int64, len(products))
+			for _, product := range products {
+				prices[product.Name] = product.PriceCents
+			}
+			if !reflect.DeepEqual(test.expectedPrices, prices) {
+				t.Fatalf("expected %v, got %v", test.expectedPrices, prices)
+			}
+		})
+	}
+}
+
+func TestImportProducts_RequiresAdmin(t *testing.T) {
+	app, _ := setupTestApp(t)
+	body := "name,price_cents\napple,100\n"
+
+	rec := executeRawRequest(t, app, http.MethodPost, "/admin/products/import", "", "text/csv", strings.NewReader(body))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	token := registerAndLogin(t, app, "customer")
+	rec = executeRawRequest(t, app, http.MethodPost, "/admin/products/import", token, "text/csv", strings.NewReader(body))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	products := []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana, ripe", SKU: skuPtr("BAN-1"), PriceCents: 200},
+	}
+
+	tests := []struct {
+		format        string
+		expectedType  string
+		expectedLines []string
+	}{
+		{
+			format:        "csv",
+			expectedType:  "text/csv",
+			expectedLines: []string{"sku,name,price_cents,category", ",apple,100,", `BAN-1,"banana, ripe",200,`},
+		},
+		{
+			format:       "ndjson",
+			expectedType: "application/x-ndjson",
+			expectedLines: []string{
+				`{"sku":"","name":"apple","category":"","price_cents":100}`,
+				`{"sku":"BAN-1","name":"banana, ripe","category":"","price_cents":200}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, products)
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/export?format="+test.format, token, nil)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != test.expectedType {
+				t.Fatalf("expected content type %s, got %s", test.expectedType, contentType)
+			}
+
+			var lines []string
+			scanner := bufio.NewScanner(rec.Body)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			if !reflect.DeepEqual(test.expectedLines, lines) {
+				t.Fatalf("expected %q, got %q", test.expectedLines, lines)
+			}
+		})
+	}
+}
+
+func TestExportProducts_RoundTrip(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", SKU: skuPtr("BAN-1"), PriceCents: 200},
+	})
+	token := loginAdmin(t, app)
+
+	export := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/export", token, nil)
+	rec := executeRawRequest(t, app, http.MethodPost, "/admin/products/import?dryRun=true", token, "text/csv", export.Body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	resp := decodeJSON[handler.ImportProductsResponse](t, rec)
+	if resp.Created != 0 || resp.Updated != 2 {
+		t.Fatalf("expected 0 created and 2 updated, got %+v", resp)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func createCoupon(t *testing.T, app http.Handler, admin string, coupon handler.CouponRequest) handler.CouponResponse {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/coupons", admin, coupon)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return decodeJSON[handler.CouponResponse](t, rec)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestCoupons(t *testing.T) {
+	app, _ := setupTestApp(t)
+	admin := loginAdmin(t, app)
+
+	coupon := createCoupon(t, app, admin, handler.CouponRequest{Code: " spring10 ", Kind: "percentage", PercentOff: 10})
+	if coupon.Code != "SPRING10" || coupon.TimesUsed != 0 {
+		t.Fatalf("unexpected coupon: %+v", coupon)
+	}
+
+	startsAt := time.Now().Add(time.Hour)
+	endsAt := startsAt.Add(-time.Minute)
+	tests := []struct {
+		name         string
+		body         handler.CouponRequest
+		expectedCode int
+	}{
+		{name: "duplicate code", body: handler.CouponRequest{Code: "Spring10", Kind: "free_shipping"}, expectedCode: http.StatusConflict},
+		{name: "no code", body: handler.CouponRequest{Kind: "free_shipping"}, expectedCode: http.StatusBadRequest},
+		{name: "unknown kind", body: handler.CouponRequest{Code: "X", Kind: "bogus"}, expectedCode: http.StatusBadRequest},
+		{name: "percent over 100", body: handler.CouponRequest{Code: "X", Kind: "percentage", PercentOff: 101}, expectedCode: http.StatusBadRequest},
+		{name: "no amount", body: handler.CouponRequest{Code: "X", Kind: "fixed_amount"}, expectedCode: http.StatusBadRequest},
+		{name: "buy nothing", body: handler.CouponRequest{Code: "X", Kind: "buy_x_get_y", GetQuantity: 1}, expectedCode: http.StatusBadRequest},
+		{name: "ends before it starts", body: handler.CouponRequest{Code: "X", Kind: "free_shipping", StartsAt: &startsAt, EndsAt: &endsAt}, expectedCode: http.StatusBadRequest},
+		{name: "zero max uses", body: handler.CouponRequest{Code: "X", Kind: "free_shipping", MaxUses: intPtr(0)}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/coupons", admin, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodPut, "/admin/coupons/1", admin, handler.CouponRequest{Code: "SPRING15", Kind: "percentage", PercentOff: 15})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if updated := decodeJSON[handler.CouponResponse](t, rec); updated.Code != "SPRING15" || updated.PercentOff != 15 {
+		t.Fatalf("unexpected coupon: %+v", updated)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/coupons", admin, nil)
+	if items := decodeJSON[map[string][]handler.CouponResponse](t, rec)["items"]; len(items) != 1 {
+		t.Fatalf("expected 1 coupon, got %+v", items)
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/coupons/1", admin, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/coupons/1", admin, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	token := registerAndLogin(t, app, "customer")
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/coupons", token, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestApplyCartCoupon(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+	})
+	admin := loginAdmin(t, app)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	bananaID := uint(2)
+	coupons := []handler.CouponRequest{
+		{Code: "TENOFF", Kind: "percentage", PercentOff: 10},
+		{Code: "BIGSPENDER", Kind: "fixed_amount", AmountOffCents: 300, MinSubtotalCents: 2000},
+		{Code: "FIFTY", Kind: "fixed_amount", AmountOffCents: 5000},
+		{Code: "BANANAS", Kind: "percentage", PercentOff: 50, ProductID: &bananaID},
+		{Code: "APPLES", Kind: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1},
+		{Code: "EXPIRED", Kind: "percentage", PercentOff: 10, EndsAt: &past},
+		{Code: "SOON", Kind: "percentage", PercentOff: 10, StartsAt: &future},
+	}
+	for _, coupon := range coupons {
+		createCoupon(t, app, admin, coupon)
+	}
+
+	// 7 apples and 2 bananas: 700 + 500.
+	token := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 7})
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 2, Quantity: 2})
+
+	tests := []struct {
+		code          string
+		expectedCode  int
+		discountCents int64
+	}{
+		{code: "tenoff", expectedCode: http.StatusOK, discountCents: 120},
+		{code: "BIGSPENDER", expectedCode: http.StatusConflict},
+		{code: "FIFTY", expectedCode: http.StatusOK, discountCents: 1200},
+		{code: "BANANAS", expectedCode: http.StatusOK, discountCents: 250},
+		{code: "APPLES", expectedCode: http.StatusOK, discountCents: 200},
+		{code: "EXPIRED", expectedCode: http.StatusConflict},
+		{code: "SOON", expectedCode: http.StatusConflict},
+		{code: "NOPE", expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPut, "/cart/coupon", token, handler.CartCouponRequest{Code: test.code})
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+			cart := decodeJSON[handler.CartResponse](t, rec)
+			if cart.SubtotalCents != 1200 || cart.DiscountCents != test.discountCents || cart.TotalCents != 1200-test.discountCents {
+				t.Fatalf("unexpected cart: %+v", cart)
+			}
+		})
+	}
+
+	// Taking the apples below 3 leaves APPLES applied but giving nothing.
+	executeRequestWithToken(t, app, http.MethodPut, "/cart/items/1", token, handler.CartItemQuantityRequest{Quantity: 2})
+	cart := getCart(t, app, token)
+	if cart.CouponCode == nil || *cart.CouponCode != "APPLES" || cart.DiscountCents != 0 || cart.TotalCents != 700 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+	if warnings := cartWarnings(cart); len(warnings) != 1 || warnings[0] != handler.CartWarningCouponNotApplicable {
+		t.Fatalf("expected a coupon warning, got %v", warnings)
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/cart/coupon", token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if cart := getCart(t, app, token); cart.CouponCode != nil || len(cart.Warnings) != 0 {
+		t.Fatalf("unexpected cart: %+v", cart)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/cart/coupon", token, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestCheckoutCart_WithCoupon(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	admin := loginAdmin(t, app)
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "TENOFF", Kind: "percentage", PercentOff: 10})
+
+	token := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 5})
+	executeRequestWithToken(t, app, http.MethodPut, "/cart/coupon", token, handler.CartCouponRequest{Code: "TENOFF"})
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	order := decodeJSON[handler.OrderResponse](t, rec)
+	if order.SubtotalCents != 500 || order.DiscountCents != 50 || order.TotalCents != 450 || len(order.Discounts) != 1 {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	if discount := order.Discounts[0]; discount.Code != "TENOFF" || discount.Description != "10% off the order" {
+		t.Fatalf("unexpected discount: %+v", discount)
+	}
+	if cart := getCart(t, app, token); cart.CouponCode != nil {
+		t.Fatalf("expected the coupon to be used up with the cart, got %+v", cart)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/coupons/1", admin, nil)
+	if coupon := decodeJSON[handler.CouponResponse](t, rec); coupon.TimesUsed != 1 {
+		t.Fatalf("expected the coupon to be used once, got %d", coupon.TimesUsed)
+	}
+}
+
+func TestCreateOrder_CouponLimits(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	admin := loginAdmin(t, app)
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "ONCE", Kind: "fixed_amount", AmountOffCents: 30, MaxUses: intPtr(2), MaxUsesPerUser: intPtr(1)})
+
+	alice := registerAndLogin(t, app, "alice")
+	bob := registerAndLogin(t, app, "bob")
+	carol := registerAndLogin(t, app, "carol")
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}, CouponCode: "once"}
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", alice, order)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if placed := decodeJSON[handler.OrderResponse](t, rec); placed.TotalCents != 70 {
+		t.Fatalf("expected a total of 70, got %d", placed.TotalCents)
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", alice, order); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a second use, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", bob, order); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", carol, order); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 once the coupon is used up, got %d", rec.Code)
+	}
+
+	// Cancelling an order gives its use back.
+	rec = executeRequestWithToken(t, app, http.MethodPost, fmt.Sprintf("/orders/%d/cancel", 1), alice, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", carol, order); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+
+	order.CouponCode = "NOPE"
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", carol, order); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown coupon, got %d", rec.Code)
+	}
+}
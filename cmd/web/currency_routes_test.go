@@ -0,0 +1,155 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func setExchangeRates(t *testing.T, app http.Handler, token string, rates map[string]string) {
+	t.Helper()
+
+	for currency, rate := range rates {
+		rec := executeRequestWithToken(t, app, http.MethodPut, "/admin/exchange-rates/"+currency, token, handler.ExchangeRateRequest{Rate: rate})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("failed to set %s rate: %d %s", currency, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestGetProducts_InCurrency(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 1000}, {Name: "banana", PriceCents: 1050}})
+	setExchangeRates(t, app, loginAdmin(t, app), map[string]string{"EUR": "0.9", "JPY": "151.0", "KWD": "0.30745"})
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptCurrency   string
+		expectedCode     int
+		expected         map[string]int64
+		expectedCurrency string
+	}{
+		{name: "base currency", path: "/products", expectedCode: http.StatusOK, expectedCurrency: "USD", expected: map[string]int64{"apple": 1000, "banana": 1050}},
+		{name: "two minor units", path: "/products?currency=eur", expectedCode: http.StatusOK, expectedCurrency: "EUR", expected: map[string]int64{"apple": 900, "banana": 945}},
+		// 10.50 USD is 1585.5 JPY, and halves round away from zero.
+		{name: "no minor units", path: "/products?currency=JPY", expectedCode: http.StatusOK, expectedCurrency: "JPY", expected: map[string]int64{"apple": 1510, "banana": 1586}},
+		{name: "three minor units", path: "/products?currency=KWD", expectedCode: http.StatusOK, expectedCurrency: "KWD", expected: map[string]int64{"apple": 3075, "banana": 3228}},
+		{name: "accept-currency header", path: "/products", acceptCurrency: "GBP, EUR;q=0.8", expectedCode: http.StatusOK, expectedCurrency: "EUR", expected: map[string]int64{"apple": 900, "banana": 945}},
+		{name: "header without known rates", path: "/products", acceptCurrency: "GBP", expectedCode: http.StatusOK, expectedCurrency: "USD", expected: map[string]int64{"apple": 1000, "banana": 1050}},
+		{name: "query parameter wins", path: "/products?currency=USD", acceptCurrency: "EUR", expectedCode: http.StatusOK, expectedCurrency: "USD", expected: map[string]int64{"apple": 1000, "banana": 1050}},
+		{name: "no rate", path: "/products?currency=GBP", expectedCode: http.StatusBadRequest},
+		{name: "unknown currency", path: "/products?currency=XYZ", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptCurrency != "" {
+				req.Header.Set("Accept-Currency", test.acceptCurrency)
+			}
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			for _, item := range decodeJSON[handler.GetProductsResponse](t, rec).Items {
+				if item.Currency != test.expectedCurrency || item.PriceCents != test.expected[item.Name] {
+					t.Fatalf("expected %s %d for %s, got %s %d", test.expectedCurrency, test.expected[item.Name], item.Name, item.Currency, item.PriceCents)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateOrder_InCurrency(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 333}})
+	setExchangeRates(t, app, loginAdmin(t, app), map[string]string{"EUR": "0.9"})
+	token := registerAndLogin(t, app, "customer")
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/orders?currency=EUR", token, handler.CreateOrderRequest{
+		Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	// The unit price is rounded first, so the total is a whole number of
+	// unit prices: 3.33 USD is 3.00 EUR (2.997 rounded).
+	order := decodeJSON[handler.OrderResponse](t, rec)
+	if order.Currency != "EUR" || order.Items[0].UnitPriceCents != 300 || order.TotalCents != 900 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	// Changing the rate later doesn't reprice the order.
+	setExchangeRates(t, app, loginAdmin(t, app), map[string]string{"EUR": "0.5"})
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/1", token, nil)
+	if order := decodeJSON[handler.OrderResponse](t, rec); order.Currency != "EUR" || order.TotalCents != 900 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders?currency=GBP", token, handler.CreateOrderRequest{
+		Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestExchangeRates(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "invalid rate", body: "currency,rate\nEUR,0.9\nJPY,abc\n", expectedCode: http.StatusBadRequest},
+		{name: "unknown currency", body: "currency,rate\nXYZ,1.2\n", expectedCode: http.StatusBadRequest},
+		{name: "base currency", body: "currency,rate\nUSD,1\n", expectedCode: http.StatusBadRequest},
+		{name: "missing header", body: "EUR,0.9\n", expectedCode: http.StatusBadRequest},
+		{name: "success", body: "currency,rate\neur,0.9200\nJPY,151.5\n", expectedCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRawRequest(t, app, http.MethodPost, "/admin/exchange-rates/import", token, "text/csv", strings.NewReader(test.body))
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := executeRequest(t, app, http.MethodGet, "/exchange-rates", nil)
+	rates := decodeJSON[handler.GetExchangeRatesResponse](t, rec)
+	if rates.BaseCurrency != "USD" || len(rates.Items) != 2 ||
+		rates.Items[0].Currency != "EUR" || rates.Items[0].Rate != "0.92" ||
+		rates.Items[1].Currency != "JPY" || rates.Items[1].Rate != "151.5" {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/exchange-rates/eur", token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/exchange-rates/EUR", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/admin/exchange-rates/GBP", token, handler.ExchangeRateRequest{Rate: "-1"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+)
+
+func executeIdempotentRequest(t *testing.T, app http.Handler, method, path, token, key string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set(handler.IdempotencyKeyHeader, key)
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreateOrder_IdempotencyKey(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+	})
+	token := registerAndLogin(t, app, "customer")
+	other := registerAndLogin(t, app, "other")
+
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}}
+	first := executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-1", order)
+	if first.Code != http.StatusCreated || first.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected 201, got %d", first.Code)
+	}
+
+	retry := executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-1", order)
+	if retry.Code != http.StatusCreated || retry.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected a replayed 201, got %d", retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected the first response, got %s", retry.Body.String())
+	}
+
+	changed := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 2}}}
+	if rec := executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-1", changed); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	if rec := executeIdempotentRequest(t, app, http.MethodPost, "/orders", other, "order-1", order); rec.Code != http.StatusCreated || rec.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected another user's key to be separate, got %d", rec.Code)
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders", token, nil)
+	if orders := decodeJSON[map[string][]handler.OrderResponse](t, rec)["items"]; len(orders) != 1 {
+		t.Fatalf("expected one order, got %d", len(orders))
+	}
+
+	// Errors other than server errors are replayed too.
+	missing := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 9, Quantity: 1}}}
+	executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-2", missing)
+	rec = executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-2", missing)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected the replayed 400, got %d", rec.Code)
+	}
+
+	if err := db.Model(&domain.IdempotencyKey{}).Where("key = ?", "order-1").Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("failed to expire key: %v", err)
+	}
+	rec = executeIdempotentRequest(t, app, http.MethodPost, "/orders", token, "order-1", changed)
+	if rec.Code != http.StatusCreated || rec.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected an expired key to be reusable, got %d", rec.Code)
+	}
+}
+
+func TestRegisterUser_IdempotencyKey(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	user := handler.RegisterUserRequest{UserName: "customer", Password: "password"}
+	for range 2 {
+		if rec := executeIdempotentRequest(t, app, http.MethodPost, "/register-user", "", "register-1", user); rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d", rec.Code)
+		}
+	}
+	if rec := executeRequest(t, app, http.MethodPost, "/register-user", user); rec.Code != http.StatusConflict {
+		t.Fatalf("expected a retry without a key to conflict, got %d", rec.Code)
+	}
+}
+
+func TestCheckoutCart_IdempotencyKey(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	token := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 2})
+
+	checkout := handler.CheckoutRequest{}
+	first := executeIdempotentRequest(t, app, http.MethodPost, "/cart/checkout", token, "checkout-1", checkout)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", first.Code)
+	}
+
+	// The cart is empty now, so only a replay can succeed.
+	retry := executeIdempotentRequest(t, app, http.MethodPost, "/cart/checkout", token, "checkout-1", checkout)
+	if retry.Code != http.StatusCreated || retry.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected a replayed 201, got %d", retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the first response, got %s", retry.Body.String())
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders", token, nil)
+	if orders := decodeJSON[map[string][]handler.OrderResponse](t, rec)["items"]; len(orders) != 1 {
+		t.Fatalf("expected one order, got %d", len(orders))
+	}
+}
+
+func TestPayOrder_IdempotencyKey(t *testing.T) {
+	app, token := setupPaymentTest(t)
+
+	card := handler.PaymentRequest{CardNumber: payment.CardSucceeds}
+	first := executeIdempotentRequest(t, app, http.MethodPost, "/orders/1/payments", token, "payment-1", card)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", first.Code)
+	}
+
+	// Without the key, the retry would be refused while the payment is in
+	// progress.
+	retry := executeIdempotentRequest(t, app, http.MethodPost, "/orders/1/payments", token, "payment-1", card)
+	if retry.Code != http.StatusCreated || retry.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected a replayed 201, got %d", retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the first response, got %s", retry.Body.String())
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/payments", token, nil)
+	if payments := decodeJSON[map[string][]handler.PaymentResponse](t, rec)["items"]; len(payments) != 1 {
+		t.Fatalf("expected one payment, got %d", len(payments))
+	}
+}
+
+func TestRegisterUser_IdempotencyKeyIsNotShared(t *testing.T) {
+	app, db := setupTestApp(t)
+
+	// Two anonymous callers that happen to pick the same key each get
+	// their own response.
+	for _, name := range []string{"alice", "bob"} {
+		user := handler.RegisterUserRequest{UserName: name, Password: "password"}
+		rec := executeIdempotentRequest(t, app, http.MethodPost, "/register-user", "", "register-1", user)
+		if rec.Code != http.StatusCreated || rec.Header().Get(handler.IdempotentReplayedHeader) != "" {
+			t.Fatalf("expected %s to be registered, got %d", name, rec.Code)
+		}
+	}
+
+	var count int64
+	db.Model(&domain.User{}).Where("user_name IN ?", []string{"alice", "bob"}).Count(&count)
+	if count != 2 {
+		t.Fatalf("expected both users to be registered, got %d", count)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestSetStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.StockRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/admin/products/1/stock/1", body: handler.StockRequest{OnHand: 5}, expectedCode: http.StatusOK},
+		{name: "negative", path: "/admin/products/1/stock/1", body: handler.StockRequest{OnHand: -1}, expectedCode: http.StatusBadRequest},
+		{name: "unknown product", path: "/admin/products/9/stock/1", body: handler.StockRequest{OnHand: 5}, expectedCode: http.StatusNotFound},
+		{name: "unknown warehouse", path: "/admin/products/1/stock/9", body: handler.StockRequest{OnHand: 5}, expectedCode: http.StatusNotFound},
+		{name: "invalid warehouse id", path: "/admin/products/1/stock/abc", body: handler.StockRequest{OnHand: 5}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPut, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrders_ReserveAndReleaseStock(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+
+	rec := executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.AvailableQuantity != nil {
+		t.Fatalf("expected untracked stock, got %d", *product.AvailableQuantity)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/stock", admin, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d for untracked stock, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 5})
+
+	// Banana isn't tracked, so it never runs out.
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 100}}}
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.AvailableQuantity == nil || *product.AvailableQuantity != 2 {
+		t.Fatalf("expected 2 available, got %v", product.AvailableQuantity)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when out of stock, got %d", http.StatusConflict, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 2})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when going below the reserved stock, got %d", http.StatusConflict, rec.Code)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/stock/1/adjust", admin, handler.AdjustStockRequest{Delta: -3})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when going below the reserved stock, got %d", http.StatusConflict, rec.Code)
+	}
+
+	other := registerAndLogin(t, app, "other")
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", other, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d for another user's order, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", customer, nil)
+	if order := decodeJSON[handler.OrderResponse](t, rec); rec.Code != http.StatusOK || order.Status != "cancelled" {
+		t.Fatalf("expected the order to be cancelled, got %d %+v", rec.Code, order)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", customer, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when cancelling twice, got %d", http.StatusConflict, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/stock/1/adjust", admin, handler.AdjustStockRequest{Delta: 2})
+	stock := decodeJSON[handler.StockResponse](t, rec)
+	if stock.OnHand != 7 || stock.Reserved != 0 || stock.Available != 7 {
+		t.Fatalf("expected the cancelled reservation to be released, got %+v", stock)
+	}
+}
+
+func TestGetStock_BreakdownPerWarehouse(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "north", Priority: 2}, {Name: "south", Priority: 1}})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 4})
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/2", admin, handler.StockRequest{OnHand: 3})
+
+	// South has priority, so it is emptied first.
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 5}}}
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/stock", admin, nil)
+	stock := decodeJSON[handler.ProductStockResponse](t, rec)
+	if stock.OnHand != 7 || stock.Reserved != 5 || stock.Available != 2 {
+		t.Fatalf("expected totals of 7 on hand and 5 reserved, got %+v", stock)
+	}
+	if len(stock.Warehouses) != 2 {
+		t.Fatalf("expected 2 warehouses, got %+v", stock.Warehouses)
+	}
+	south, north := stock.Warehouses[0], stock.Warehouses[1]
+	if south.WarehouseName != "south" || south.Reserved != 3 || south.Available != 0 {
+		t.Fatalf("expected south to be fully reserved, got %+v", south)
+	}
+	if north.WarehouseName != "north" || north.Reserved != 2 || north.Available != 2 {
+		t.Fatalf("expected 2 reserved in north, got %+v", north)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.AvailableQuantity == nil || *product.AvailableQuantity != 2 {
+		t.Fatalf("expected 2 available across warehouses, got %v", product.AvailableQuantity)
+	}
+}
+
+func TestCreateOrder_ShipTo(t *testing.T) {
+	tests := []struct {
+		name         string
+		shipTo       *handler.LocationRequest
+		expectedCode int
+	}{
+		{name: "without destination", expectedCode: http.StatusCreated},
+		{name: "with destination", shipTo: &handler.LocationRequest{Latitude: 55.68, Longitude: 12.57}, expectedCode: http.StatusCreated},
+		{name: "latitude out of range", shipTo: &handler.LocationRequest{Latitude: 91}, expectedCode: http.StatusBadRequest},
+		{name: "longitude out of range", shipTo: &handler.LocationRequest{Longitude: -181}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			customer := registerAndLogin(t, app, "customer")
+
+			order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}, ShipTo: test.shipTo}
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
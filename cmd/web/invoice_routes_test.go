@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestGetOrderInvoice(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 1000},
+	})
+	admin := loginAdmin(t, app)
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/tax-rates", admin, handler.TaxRateRequest{Country: "CA", Name: "GST", Rate: "0.05"})
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones", admin, handler.ShippingZoneRequest{Name: "Canada", Locations: []handler.ShippingLocationRequest{{Country: "CA"}}})
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones/1/methods", admin, handler.ShippingMethodRequest{Name: "Standard", Basis: "weight", Rates: []handler.ShippingRateRequest{{CostCents: 500}}})
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "TENOFF", Kind: "percentage", PercentOff: 10})
+
+	token := registerAndLogin(t, app, "customer")
+	address := createAddress(t, app, token, handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{
+		Name: "Ann Smith", Street: "1 Bay St", City: "Toronto", Region: "ON", PostalCode: "M5J 2N8", Country: "CA",
+	}})
+	items := []handler.OrderItemRequest{{ProductID: 1, Quantity: 2}}
+	orders := []handler.CreateOrderRequest{
+		{Items: items, AddressID: &address.ID, ShippingMethodID: uintPtr(1), CouponCode: "TENOFF"},
+		{Items: items, AddressID: &address.ID, ShippingMethodID: uintPtr(1)},
+		{Items: items, AddressID: &address.ID, ShippingMethodID: uintPtr(1)},
+	}
+	for _, order := range orders {
+		if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order); rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create order: %d", rec.Code)
+		}
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/invoice.pdf", token, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before the order is paid, got %d", rec.Code)
+	}
+
+	// Invoices are numbered in the order the orders are paid.
+	for _, id := range []uint{2, 1} {
+		for _, status := range []string{"awaiting_payment", "paid"} {
+			if code := transitionOrder(t, app, admin, id, status); code != http.StatusOK {
+				t.Fatalf("failed to move order %d to %s: %d", id, status, code)
+			}
+		}
+	}
+	year := time.Now().UTC().Year()
+	for id, number := range map[uint]string{1: fmt.Sprintf("%d-000002", year), 2: fmt.Sprintf("%d-000001", year)} {
+		rec := executeRequestWithToken(t, app, http.MethodGet, fmt.Sprintf("/orders/%d", id), token, nil)
+		if order := decodeJSON[handler.OrderResponse](t, rec); order.InvoiceNumber == nil || *order.InvoiceNumber != number {
+			t.Fatalf("expected order %d to have invoice %s, got %v", id, number, order.InvoiceNumber)
+		}
+	}
+
+	first := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/invoice.pdf", token, nil)
+	if first.Code != http.StatusOK || first.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("expected a PDF, got %d", first.Code)
+	}
+	pdf := first.Body.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF: %q", pdf[:min(len(pdf), 20)])
+	}
+	for _, text := range []string{
+		fmt.Sprintf("(%d-000002)", year),
+		"(apple)",
+		"(20.00 USD)",
+		"(10% off the order \\(TENOFF\\))",
+		"(Shipping: Standard)",
+		"(GST 5%)",
+		"(Ann Smith)",
+		"(M5J 2N8 Toronto ON)",
+	} {
+		if !bytes.Contains(pdf, []byte(text)) {
+			t.Errorf("expected the invoice to contain %s", text)
+		}
+	}
+
+	again := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/invoice.pdf", token, nil)
+	if !bytes.Equal(again.Body.Bytes(), pdf) {
+		t.Fatalf("expected the same invoice when it is rendered again")
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/3/invoice.pdf", token, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an unpaid order, got %d", rec.Code)
+	}
+	other := registerAndLogin(t, app, "other")
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/invoice.pdf", other, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's order, got %d", rec.Code)
+	}
+}
@@ -5,12 +5,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Hiroki111/go-backend-example/internal/database"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
 	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/notification"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
 	"github.com/Hiroki111/go-backend-example/internal/repository"
 	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 const portNumber = ":8080"
@@ -22,12 +27,40 @@ func main() {
 	}
 
 	fmt.Println("Connecting to database")
-	db, err := newPostgresDB()
+	db, err := database.NewPostgresDB()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	repo := repository.NewRepository(db)
+	if code, ok := os.LookupEnv("BASE_CURRENCY"); ok {
+		if err := repo.SetBaseCurrency(code); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if name, ok := os.LookupEnv("ALLOCATION_STRATEGY"); ok {
+		if err := repo.SetAllocationStrategy(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if value, ok := os.LookupEnv("TAX_PRICES_INCLUDE_TAX"); ok {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("invalid TAX_PRICES_INCLUDE_TAX: %v", err)
+		}
+		repo.SetPricesIncludeTax(include)
+	}
+	if name, ok := os.LookupEnv("TAX_ROUNDING"); ok {
+		if err := repo.SetTaxRounding(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+	repo.SetSeller(domain.Seller{
+		Name:    os.Getenv("SELLER_NAME"),
+		Address: strings.FieldsFunc(os.Getenv("SELLER_ADDRESS"), func(r rune) bool { return r == '\n' }),
+		TaxID:   os.Getenv("SELLER_TAX_ID"),
+		Email:   os.Getenv("SELLER_EMAIL"),
+	})
 	if err := repo.Migrate(); err != nil {
 		log.Fatal(err)
 	}
@@ -35,7 +68,27 @@ func main() {
 		log.Fatal(err)
 	}
 
+	var channel notification.Channel = notification.NewOutbox(db)
+	if path, ok := os.LookupEnv("NOTIFICATION_FILE"); ok {
+		channel = notification.NewFile(path)
+	}
+
+	go releaseExpiredReservations(repo)
+	go evaluateStockNotifications(repo, channel)
+	go deleteExpiredIdempotencyKeys(repo)
+
 	handler := handler.NewHandler(repo)
+	if value, ok := os.LookupEnv("IDEMPOTENCY_KEY_TTL"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("invalid IDEMPOTENCY_KEY_TTL: %q", value)
+		}
+		handler.SetIdempotencyKeyTTL(ttl)
+	}
+	handler.SetPaymentGateway(payment.NewMock(
+		os.Getenv("PAYMENT_WEBHOOK_URL"),
+		[]byte(os.Getenv("PAYMENT_WEBHOOK_SECRET")),
+	))
 	server := &http.Server{
 		Addr:    portNumber,
 		Handler: routes(handler),
@@ -46,23 +99,39 @@ func main() {
 	log.Fatal(err)
 }
 
-func newPostgresDB() (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		getEnv("DB_HOST"),
-		getEnv("DB_USER"),
-		getEnv("DB_PASSWORD"),
-		getEnv("DB_NAME"),
-		getEnv("DB_PORT"),
-		getEnv("DB_SSLMODE"),
-		getEnv("DB_TIMEZONE"),
-	)
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+// releaseExpiredReservations returns stock held by abandoned checkouts.
+func releaseExpiredReservations(repo *repository.Repository) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if _, err := repo.ReleaseExpiredReservations(now); err != nil {
+			log.Printf("failed to release expired reservations: %v", err)
+		}
+	}
 }
 
-func getEnv(key string) string {
-	if v, ok := os.LookupEnv(key); ok {
-		return v
+// evaluateStockNotifications sends low-stock alerts and back-in-stock
+// notices.
+func evaluateStockNotifications(repo *repository.Repository, channel notification.Channel) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := repo.EvaluateStockNotifications(channel); err != nil {
+			log.Printf("failed to evaluate stock notifications: %v", err)
+		}
+	}
+}
+
+// deleteExpiredIdempotencyKeys forgets idempotency keys past their TTL.
+func deleteExpiredIdempotencyKeys(repo *repository.Repository) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if _, err := repo.DeleteExpiredIdempotencyKeys(now); err != nil {
+			log.Printf("failed to delete expired idempotency keys: %v", err)
+		}
 	}
-	panic(fmt.Sprintf("Env variable %s not found", key))
 }
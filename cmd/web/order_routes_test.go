@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestCreateOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          handler.CreateOrderRequest
+		expectedCode  int
+		expectedTotal int64
+	}{
+		{
+			name: "success",
+			body: handler.CreateOrderRequest{Items: []handler.OrderItemRequest{
+				{ProductID: 1, Quantity: 2},
+				{ProductID: 2, Quantity: 1},
+				{ProductID: 1, Quantity: 1},
+			}},
+			expectedCode:  http.StatusCreated,
+			expectedTotal: 3*100 + 200,
+		},
+		{name: "no items", body: handler.CreateOrderRequest{}, expectedCode: http.StatusBadRequest},
+		{
+			name:         "invalid quantity",
+			body:         handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 0}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown product",
+			body:         handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}, {ProductID: 9, Quantity: 1}}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+			token := registerAndLogin(t, app, "customer")
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+
+			if test.expectedCode == http.StatusCreated {
+				order := decodeJSON[handler.OrderResponse](t, rec)
+				if order.TotalCents != test.expectedTotal || len(order.Items) != 2 || order.Status != "pending" {
+					t.Fatalf("unexpected order %+v", order)
+				}
+				if order.Items[0].Quantity != 3 || order.Items[0].LineTotalCents != 300 {
+					t.Fatalf("expected apple lines to be merged, got %+v", order.Items[0])
+				}
+			} else {
+				var count int64
+				db.Model(&domain.Order{}).Count(&count)
+				if count != 0 {
+					t.Fatalf("expected no order to be saved, got %d", count)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateOrder_RequiresAuth(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	rec := executeRequest(t, app, http.MethodPost, "/orders", handler.CreateOrderRequest{})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestGetOrders_OnlyOwnOrders(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	alice := registerAndLogin(t, app, "alice")
+	bob := registerAndLogin(t, app, "bob")
+
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", alice, order)
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", alice, order)
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", bob, order)
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders", alice, nil)
+	if items := decodeJSON[map[string][]handler.OrderResponse](t, rec)["items"]; len(items) != 2 {
+		t.Fatalf("expected 2 orders for alice, got %d", len(items))
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/1", alice, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/3", alice, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d for another user's order, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func transitionOrder(t *testing.T, app http.Handler, token string, orderID uint, status string) int {
+	t.Helper()
+
+	path := fmt.Sprintf("/admin/orders/%d/transitions", orderID)
+	rec := executeRequestWithToken(t, app, http.MethodPost, path, token, handler.OrderTransitionRequest{Status: status})
+	return rec.Code
+}
+
+func TestTransitionOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         []string
+		status       string
+		orderID      uint
+		expectedCode int
+	}{
+		{name: "pending to awaiting payment", status: "awaiting_payment", orderID: 1, expectedCode: http.StatusOK},
+		{name: "pending to shipped", status: "shipped", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "paid to cancelled", path: []string{"awaiting_payment", "paid"}, status: "cancelled", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "shipped to refunded", path: []string{"awaiting_payment", "paid", "fulfilled", "shipped"}, status: "refunded", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "paid to refunded", path: []string{"awaiting_payment", "paid"}, status: "refunded", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "delivered to refunded", path: []string{"awaiting_payment", "paid", "fulfilled", "shipped", "delivered"}, status: "refunded", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "out of cancelled", path: []string{"cancelled"}, status: "pending", orderID: 1, expectedCode: http.StatusConflict},
+		{name: "unknown status", status: "lost", orderID: 1, expectedCode: http.StatusBadRequest},
+		{name: "unknown order", status: "awaiting_payment", orderID: 9, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			admin := loginAdmin(t, app)
+			customer := registerAndLogin(t, app, "customer")
+			order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}}
+			executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+
+			for _, status := range test.path {
+				if code := transitionOrder(t, app, admin, 1, status); code != http.StatusOK {
+					t.Fatalf("failed to move the order to %s: %d", status, code)
+				}
+			}
+
+			if code := transitionOrder(t, app, admin, test.orderID, test.status); code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 10})
+
+	stock := func() handler.ProductStockResponse {
+		t.Helper()
+
+		rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/stock", admin, nil)
+		return decodeJSON[handler.ProductStockResponse](t, rec)
+	}
+
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/orders/1/transitions", customer, handler.OrderTransitionRequest{Status: "awaiting_payment"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected %d for customers, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	// Paying commits the reservation.
+	for _, status := range []string{"awaiting_payment", "paid"} {
+		if code := transitionOrder(t, app, admin, 1, status); code != http.StatusOK {
+			t.Fatalf("failed to move the order to %s: %d", status, code)
+		}
+	}
+	if s := stock(); s.OnHand != 7 || s.Reserved != 3 {
+		t.Fatalf("expected the paid order's stock to leave the warehouse, got %+v", s)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", customer, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when cancelling a paid order, got %d", http.StatusConflict, rec.Code)
+	}
+
+	// Cancelling releases the reservation.
+	transitionOrder(t, app, admin, 2, "awaiting_payment")
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders/2/cancel", customer, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if s := stock(); s.OnHand != 7 || s.Reserved != 0 {
+		t.Fatalf("expected the cancelled order's stock to be released, got %+v", s)
+	}
+
+	for _, status := range []string{"fulfilled", "shipped", "delivered"} {
+		if code := transitionOrder(t, app, admin, 1, status); code != http.StatusOK {
+			t.Fatalf("failed to move the order to %s: %d", status, code)
+		}
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/1", customer, nil)
+	if order := decodeJSON[handler.OrderResponse](t, rec); order.Status != "delivered" {
+		t.Fatalf("expected the order to be delivered, got %s", order.Status)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/orders/1/transitions", admin, nil)
+	transitions := decodeJSON[map[string][]handler.OrderTransitionResponse](t, rec)["items"]
+	expected := [][2]string{
+		{"pending", "awaiting_payment"},
+		{"awaiting_payment", "paid"},
+		{"paid", "fulfilled"},
+		{"fulfilled", "shipped"},
+		{"shipped", "delivered"},
+	}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected %d transitions, got %+v", len(expected), transitions)
+	}
+	for i, transition := range transitions {
+		if transition.From != expected[i][0] || transition.To != expected[i][1] {
+			t.Fatalf("expected %v at %d, got %+v", expected[i], i, transition)
+		}
+		if transition.ActorID == nil || *transition.ActorID != 1 {
+			t.Fatalf("expected the admin as the actor, got %v", transition.ActorID)
+		}
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/orders/2/transitions", admin, nil)
+	transitions = decodeJSON[map[string][]handler.OrderTransitionResponse](t, rec)["items"]
+	if last := transitions[len(transitions)-1]; last.To != "cancelled" || last.ActorID == nil || *last.ActorID != 2 {
+		t.Fatalf("expected the customer's cancellation to be recorded, got %+v", last)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+// sendPaymentWebhook posts the event as the payment provider would, with a
+// signature made at signedAt.
+func sendPaymentWebhook(t *testing.T, app http.Handler, event payment.Event, signedAt time.Time) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payment.SignatureHeader, payment.Sign([]byte(testWebhookSecret), signedAt, body))
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec
+}
+
+func payOrder(t *testing.T, app http.Handler, token string, orderID uint, card string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	path := fmt.Sprintf("/orders/%d/payments", orderID)
+	return executeRequestWithToken(t, app, http.MethodPost, path, token, handler.PaymentRequest{CardNumber: card})
+}
+
+func getOrderStatus(t *testing.T, app http.Handler, token string, orderID uint) string {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, fmt.Sprintf("/orders/%d", orderID), token, nil)
+	return decodeJSON[handler.OrderResponse](t, rec).Status
+}
+
+func setupPaymentTest(t *testing.T) (http.Handler, string) {
+	t.Helper()
+
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	token := registerAndLogin(t, app, "customer")
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order)
+	return app, token
+}
+
+func TestPayOrder(t *testing.T) {
+	app, token := setupPaymentTest(t)
+
+	rec := payOrder(t, app, token, 1, payment.CardSucceeds)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	p := decodeJSON[handler.PaymentResponse](t, rec)
+	if p.Status != "processing" || p.AmountCents != 300 || p.Reference == nil {
+		t.Fatalf("unexpected payment: %+v", p)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "awaiting_payment" {
+		t.Fatalf("expected awaiting_payment, got %s", status)
+	}
+
+	// The order can't be cancelled or paid again while the payment is in
+	// flight.
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", token, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when cancelling, got %d", rec.Code)
+	}
+	if rec := payOrder(t, app, token, 1, payment.CardSucceeds); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when paying again, got %d", rec.Code)
+	}
+
+	event := payment.Event{ID: "evt_1", Type: payment.EventPaymentSucceeded, Reference: *p.Reference}
+	rec = sendPaymentWebhook(t, app, event, time.Now())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "paid" {
+		t.Fatalf("expected paid, got %s", status)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/1/payments", token, nil)
+	payments := decodeJSON[map[string][]handler.PaymentResponse](t, rec)["items"]
+	if len(payments) != 1 || payments[0].Status != "succeeded" {
+		t.Fatalf("unexpected payments: %+v", payments)
+	}
+
+	if rec := payOrder(t, app, token, 1, payment.CardSucceeds); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when paying a paid order, got %d", rec.Code)
+	}
+}
+
+func TestPayOrderDeclined(t *testing.T) {
+	tests := []struct {
+		name string
+		card string
+	}{
+		{name: "declined", card: payment.CardDeclined},
+		{name: "insufficient funds", card: payment.CardInsufficientFunds},
+		{name: "unknown card", card: "1234"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, token := setupPaymentTest(t)
+
+			if rec := payOrder(t, app, token, 1, test.card); rec.Code != http.StatusPaymentRequired {
+				t.Fatalf("expected 402, got %d", rec.Code)
+			}
+			if status := getOrderStatus(t, app, token, 1); status != "payment_failed" {
+				t.Fatalf("expected payment_failed, got %s", status)
+			}
+
+			// The order can be paid with another card.
+			if rec := payOrder(t, app, token, 1, payment.CardSucceeds); rec.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d", rec.Code)
+			}
+			rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/payments", token, nil)
+			payments := decodeJSON[map[string][]handler.PaymentResponse](t, rec)["items"]
+			if len(payments) != 2 || payments[0].Status != "failed" || payments[0].FailureReason == "" {
+				t.Fatalf("unexpected payments: %+v", payments)
+			}
+		})
+	}
+}
+
+func TestPaymentFailedWebhook(t *testing.T) {
+	app, token := setupPaymentTest(t)
+
+	rec := payOrder(t, app, token, 1, payment.CardFailsOnCapture)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	p := decodeJSON[handler.PaymentResponse](t, rec)
+
+	event := payment.Event{ID: "evt_1", Type: payment.EventPaymentFailed, Reference: *p.Reference, Reason: "capture failed"}
+	if rec := sendPaymentWebhook(t, app, event, time.Now()); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "payment_failed" {
+		t.Fatalf("expected payment_failed, got %s", status)
+	}
+
+	// A late success for a payment that already failed changes nothing.
+	event = payment.Event{ID: "evt_2", Type: payment.EventPaymentSucceeded, Reference: *p.Reference}
+	if rec := sendPaymentWebhook(t, app, event, time.Now()); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "payment_failed" {
+		t.Fatalf("expected payment_failed, got %s", status)
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders/1/cancel", token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 when cancelling, got %d", rec.Code)
+	}
+}
+
+func TestPaymentWebhookVerification(t *testing.T) {
+	app, token := setupPaymentTest(t)
+	rec := payOrder(t, app, token, 1, payment.CardSucceeds)
+	p := decodeJSON[handler.PaymentResponse](t, rec)
+	event := payment.Event{ID: "evt_1", Type: payment.EventPaymentSucceeded, Reference: *p.Reference}
+
+	tests := []struct {
+		name         string
+		event        payment.Event
+		signedAt     time.Time
+		expectedCode int
+	}{
+		{name: "stale signature", event: event, signedAt: time.Now().Add(-payment.SignatureTolerance - time.Minute), expectedCode: http.StatusBadRequest},
+		{name: "unknown payment", event: payment.Event{ID: "evt_0", Type: payment.EventPaymentSucceeded, Reference: "mock_unknown"}, signedAt: time.Now(), expectedCode: http.StatusNotFound},
+		{name: "first delivery", event: event, signedAt: time.Now(), expectedCode: http.StatusOK},
+		{name: "replayed delivery", event: event, signedAt: time.Now(), expectedCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := sendPaymentWebhook(t, app, test.event, test.signedAt)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+
+	rec = sendPaymentWebhook(t, app, event, time.Now())
+	if resp := decodeJSON[handler.PaymentWebhookResponse](t, rec); resp.Status != "duplicate" {
+		t.Fatalf("expected the replay to be ignored, got %q", resp.Status)
+	}
+
+	body, _ := json.Marshal(payment.Event{ID: "evt_2", Type: payment.EventPaymentSucceeded, Reference: *p.Reference})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set(payment.SignatureHeader, payment.Sign([]byte("wrong-secret"), time.Now(), body))
+	forged := httptest.NewRecorder()
+	app.ServeHTTP(forged, req)
+	if forged.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a forged signature, got %d", forged.Code)
+	}
+}
+
+func TestPayOrderNotFound(t *testing.T) {
+	app, _ := setupPaymentTest(t)
+	other := registerAndLogin(t, app, "other")
+
+	if rec := payOrder(t, app, other, 1, payment.CardSucceeds); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1/payments", other, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestPayOrder_NothingToPay(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	admin := loginAdmin(t, app)
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "FREE", Kind: "percentage", PercentOff: 100})
+	token := registerAndLogin(t, app, "customer")
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}, CouponCode: "FREE"}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order)
+
+	rec := payOrder(t, app, token, 1, payment.CardDeclined)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if p := decodeJSON[handler.PaymentResponse](t, rec); p.Status != "succeeded" || p.AmountCents != 0 || p.Reference != nil {
+		t.Fatalf("expected a payment of nothing without the card, got %+v", p)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "paid" {
+		t.Fatalf("expected the order to be paid, got %s", status)
+	}
+}
+
+func TestPaymentWebhook_StockRanOut(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 3})
+	token := registerAndLogin(t, app, "customer")
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order)
+	p := decodeJSON[handler.PaymentResponse](t, payOrder(t, app, token, 1, payment.CardSucceeds))
+
+	// The reservation lapses before the provider confirms the capture, and
+	// another order takes the stock.
+	if _, err := repository.NewRepository(db).ReleaseExpiredReservations(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", registerAndLogin(t, app, "other"), order)
+
+	event := payment.Event{ID: "evt_1", Type: payment.EventPaymentSucceeded, Reference: *p.Reference}
+	if rec := sendPaymentWebhook(t, app, event, time.Now()); rec.Code != http.StatusOK {
+		t.Fatalf("expected the event to be acknowledged, got %d", rec.Code)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "on_hold" {
+		t.Fatalf("expected the order to be on hold, got %s", status)
+	}
+
+	// Once there is stock again, the order can go ahead.
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 6})
+	if code := transitionOrder(t, app, admin, 1, "paid"); code != http.StatusOK {
+		t.Fatalf("expected the order to be paid, got %d", code)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestGetPriceHistory(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "apple", PriceCents: 1000})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	for _, price := range []int64{900, 900, 1100} {
+		executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "apple", PriceCents: price})
+	}
+	// Scheduled changes only show up once they take effect.
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/prices", token, handler.ScheduledPriceRequest{
+		Kind:       "sale",
+		PriceCents: 500,
+		StartsAt:   timePtr(time.Now().Add(time.Hour)),
+	})
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1/price-history", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var prices []int64
+	for _, change := range decodeJSON[map[string][]handler.PriceChangeResponse](t, rec)["items"] {
+		prices = append(prices, change.PriceCents)
+	}
+	expected := []int64{1000, 900, 1100}
+	if len(prices) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, prices)
+	}
+	for i := range expected {
+		if prices[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, prices)
+		}
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/9/price-history", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestLowestPrice30d(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 1100}, {Name: "banana", PriceCents: 200}})
+	token := loginAdmin(t, app)
+
+	now := time.Now().UTC()
+	day := 24 * time.Hour
+	history := []domain.PriceChange{
+		{ProductID: 1, PriceCents: 600, RegularPriceCents: 600, EffectiveFrom: now.Add(-40 * day)},
+		{ProductID: 1, PriceCents: 1000, RegularPriceCents: 1000, EffectiveFrom: now.Add(-35 * day)},
+		{ProductID: 1, PriceCents: 900, RegularPriceCents: 900, EffectiveFrom: now.Add(-20 * day)},
+		{ProductID: 1, PriceCents: 1100, RegularPriceCents: 1100, EffectiveFrom: now.Add(-10 * day)},
+	}
+	if err := db.Create(&history).Error; err != nil {
+		t.Fatalf("failed to seed price history: %v", err)
+	}
+
+	rec := executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.LowestPrice30dCents != nil {
+		t.Fatalf("expected no reference price before the sale, got %d", *product.LowestPrice30dCents)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/prices", token, handler.ScheduledPriceRequest{
+		Kind:       "sale",
+		PriceCents: 700,
+	})
+
+	// The 600 price ended more than 30 days ago; the 1000 price was still
+	// in effect at the start of the window.
+	rec = executeRequest(t, app, http.MethodGet, "/products?orderBy=name", nil)
+	items := decodeJSON[handler.GetProductsResponse](t, rec).Items
+	apple, banana := items[0], items[1]
+	if apple.LowestPrice30dCents == nil || *apple.LowestPrice30dCents != 900 {
+		t.Fatalf("expected a reference price of 900, got %+v", apple)
+	}
+	if banana.LowestPrice30dCents != nil {
+		t.Fatalf("expected no reference price for a product that is not on sale, got %+v", banana)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1/price-history", nil)
+	changes := decodeJSON[map[string][]handler.PriceChangeResponse](t, rec)["items"]
+	if last := changes[len(changes)-1]; last.PriceCents != 700 || last.OriginalPriceCents != 1100 {
+		t.Fatalf("expected the sale to be recorded, got %+v", last)
+	}
+}
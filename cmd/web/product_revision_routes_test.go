@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestProductRevisions_RecordsEachChange(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "apple", PriceCents: 100})
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "apple", PriceCents: 150})
+	// An update that changes nothing is not a new revision.
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "apple", PriceCents: 150})
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/trash/1/restore", token, nil)
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/revisions", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	items := decodeJSON[map[string][]handler.ProductRevisionResponse](t, rec)["items"]
+
+	var actions []string
+	for _, item := range items {
+		actions = append(actions, item.Action)
+		if item.ActorID == nil || *item.ActorID != 1 {
+			t.Fatalf("expected revision %d to be made by the admin, got %v", item.Version, item.ActorID)
+		}
+	}
+	expected := []string{"restore", "delete", "update", "create"}
+	if !reflect.DeepEqual(expected, actions) {
+		t.Fatalf("expected %v, got %v", expected, actions)
+	}
+	if items[2].Snapshot.PriceCents != 150 || items[3].Snapshot.PriceCents != 100 {
+		t.Fatalf("expected snapshots to hold the price at each revision, got %+v", items)
+	}
+}
+
+func TestProductRevisionDiff(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "apple", PriceCents: 100})
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "green apple", PriceCents: 100})
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "green apple", PriceCents: 150})
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/revisions/diff?from=1&to=3", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	diff := decodeJSON[handler.ProductRevisionDiffResponse](t, rec)
+
+	expected := []handler.FieldChangeResponse{
+		{Field: "name", From: "apple", To: "green apple"},
+		{Field: "price_cents", From: float64(100), To: float64(150)},
+	}
+	if !reflect.DeepEqual(expected, diff.Changes) {
+		t.Fatalf("expected %v, got %v", expected, diff.Changes)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/revisions/diff?from=1&to=9", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestRollbackProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		takeOldName  bool
+		expectedCode int
+		expectedName string
+	}{
+		{name: "success", path: "/admin/products/1/revisions/1/rollback", expectedCode: http.StatusOK, expectedName: "apple"},
+		{name: "old name taken", path: "/admin/products/1/revisions/1/rollback", takeOldName: true, expectedCode: http.StatusConflict, expectedName: "green apple"},
+		{name: "unknown revision", path: "/admin/products/1/revisions/9/rollback", expectedCode: http.StatusNotFound, expectedName: "green apple"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+			token := loginAdmin(t, app)
+
+			executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "apple", PriceCents: 100})
+			executeRequestWithToken(t, app, http.MethodPut, "/products/1", token, handler.ProductRequest{Name: "green apple", PriceCents: 150})
+			if test.takeOldName {
+				executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "apple", PriceCents: 300})
+			}
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, test.path, token, nil)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+
+			rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+			if product := decodeJSON[handler.ProductResponse](t, rec); product.Name != test.expectedName {
+				t.Fatalf("expected %s, got %s", test.expectedName, product.Name)
+			}
+
+			if test.expectedCode == http.StatusOK {
+				rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/revisions", token, nil)
+				items := decodeJSON[map[string][]handler.ProductRevisionResponse](t, rec)["items"]
+				if len(items) != 3 || items[0].Action != "rollback" || items[0].Snapshot.PriceCents != 100 {
+					t.Fatalf("expected the rollback to be recorded as a new revision, got %+v", items)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestCreateProduct_GeneratesSlug(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedSlug string
+	}{
+		{name: "Crème Brûlée (Large)", expectedSlug: "creme-brulee-large"},
+		{name: "  Kid's  T-Shirt ", expectedSlug: "kids-t-shirt"},
+		{name: "Straße & Søren", expectedSlug: "strasse-soren"},
+		{name: "Чай с лимоном", expectedSlug: "chai-s-limonom"},
+		{name: "ＡＢＣ 123", expectedSlug: "abc-123"},
+		{name: "茶", expectedSlug: "product"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expectedSlug, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: test.name, PriceCents: 100})
+			if product := decodeJSON[handler.ProductResponse](t, rec); product.Slug != test.expectedSlug {
+				t.Fatalf("expected slug %q, got %q", test.expectedSlug, product.Slug)
+			}
+		})
+	}
+}
+
+func TestGetProductBySlug(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	createProduct := func(name string) handler.ProductResponse {
+		t.Helper()
+
+		rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: name, PriceCents: 100})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create %s: %d", name, rec.Code)
+		}
+		return decodeJSON[handler.ProductResponse](t, rec)
+	}
+	renameProduct := func(path, name string) handler.ProductResponse {
+		t.Helper()
+
+		rec := executeRequestWithToken(t, app, http.MethodPut, path, token, handler.ProductRequest{Name: name, PriceCents: 100})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("failed to rename to %s: %d", name, rec.Code)
+		}
+		return decodeJSON[handler.ProductResponse](t, rec)
+	}
+
+	createProduct("Crème Brûlée")
+	if product := createProduct("Creme brulee!"); product.Slug != "creme-brulee-2" {
+		t.Fatalf("expected a collision suffix, got %q", product.Slug)
+	}
+
+	rec := executeRequest(t, app, http.MethodGet, "/products/by-slug/creme-brulee", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); rec.Code != http.StatusOK || product.ID != 1 {
+		t.Fatalf("expected product 1, got %d %+v", rec.Code, product)
+	}
+
+	// Changing only the case keeps the slug, collision suffix included.
+	if product := renameProduct("/products/2", "CREME BRULEE"); product.Slug != "creme-brulee-2" {
+		t.Fatalf("expected the slug to be kept, got %q", product.Slug)
+	}
+
+	if product := renameProduct("/products/1", "Crème Brûlée Deluxe"); product.Slug != "creme-brulee-deluxe" {
+		t.Fatalf("expected a new slug, got %q", product.Slug)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/by-slug/creme-brulee?locale=fr", nil)
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected %d for an old slug, got %d", http.StatusMovedPermanently, rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != "/products/by-slug/creme-brulee-deluxe?locale=fr" {
+		t.Fatalf("unexpected redirect to %q", location)
+	}
+	if redirect := decodeJSON[handler.SlugRedirectResponse](t, rec); redirect.Slug != "creme-brulee-deluxe" {
+		t.Fatalf("expected the canonical slug, got %q", redirect.Slug)
+	}
+
+	// Old slugs stay reserved for their redirects.
+	if product := createProduct("Crème brûlée"); product.Slug != "creme-brulee-3" {
+		t.Fatalf("expected the old slug to stay reserved, got %q", product.Slug)
+	}
+
+	// Renaming back takes the old slug back.
+	renameProduct("/products/3", "Crème brûlée 3")
+	if product := renameProduct("/products/1", "Crème Brûlée"); product.Slug != "creme-brulee" {
+		t.Fatalf("expected the old slug back, got %q", product.Slug)
+	}
+	rec = executeRequest(t, app, http.MethodGet, "/products/by-slug/creme-brulee", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	rec = executeRequest(t, app, http.MethodGet, "/products/by-slug/creme-brulee-deluxe", nil)
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected %d, got %d", http.StatusMovedPermanently, rec.Code)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/by-slug/unknown", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+	rec = executeRequest(t, app, http.MethodGet, "/products/by-slug/creme-brulee", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d for a deleted product, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func seedTranslations(t *testing.T, app http.Handler, token string) {
+	t.Helper()
+
+	for locale, translation := range map[string]handler.ProductTranslationRequest{
+		"de":    {Name: "Aprikose", Description: "Süß"},
+		"de-at": {Name: "Marille"},
+		"fr":    {Name: "Abricot", Description: "Sucré"},
+	} {
+		rec := executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/translations/"+locale, token, translation)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("failed to save %s translation: %d %s", locale, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestGetProduct_Localized(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "Apricot", Description: "Sweet", PriceCents: 100}})
+	seedTranslations(t, app, loginAdmin(t, app))
+
+	tests := []struct {
+		name                string
+		path                string
+		acceptLanguage      string
+		expectedCode        int
+		expectedName        string
+		expectedDescription string
+	}{
+		{name: "default", path: "/products/1", expectedCode: http.StatusOK, expectedName: "Apricot", expectedDescription: "Sweet"},
+		{name: "region falls back per field", path: "/products/1?locale=de-AT", expectedCode: http.StatusOK, expectedName: "Marille", expectedDescription: "Süß"},
+		{name: "region without translation", path: "/products/1?locale=de-CH", expectedCode: http.StatusOK, expectedName: "Aprikose", expectedDescription: "Süß"},
+		{name: "accept-language", path: "/products/1", acceptLanguage: "es, fr-CA;q=0.8, de;q=0.5", expectedCode: http.StatusOK, expectedName: "Abricot", expectedDescription: "Sucré"},
+		{name: "locale parameter wins", path: "/products/1?locale=de", acceptLanguage: "fr", expectedCode: http.StatusOK, expectedName: "Aprikose", expectedDescription: "Süß"},
+		{name: "no translation", path: "/products/1", acceptLanguage: "es", expectedCode: http.StatusOK, expectedName: "Apricot", expectedDescription: "Sweet"},
+		{name: "invalid locale", path: "/products/1?locale=not_a_locale!", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			product := decodeJSON[handler.ProductResponse](t, rec)
+			if product.Name != test.expectedName || product.Description != test.expectedDescription {
+				t.Fatalf("expected %q / %q, got %q / %q", test.expectedName, test.expectedDescription, product.Name, product.Description)
+			}
+		})
+	}
+}
+
+func TestGetProducts_SearchLocalizedName(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "Apricot", PriceCents: 100}, {Name: "Banana", PriceCents: 100}})
+	seedTranslations(t, app, loginAdmin(t, app))
+
+	tests := []struct {
+		path          string
+		expectedCount int
+	}{
+		{path: "/products?name=apricot", expectedCount: 1},
+		{path: "/products?name=aprikose", expectedCount: 0},
+		{path: "/products?name=aprikose&locale=de", expectedCount: 1},
+		// The Austrian name replaces the German one.
+		{path: "/products?name=aprikose&locale=de-AT", expectedCount: 0},
+		{path: "/products?name=MARILLE&locale=de-AT", expectedCount: 1},
+		// Untranslated products keep their default name.
+		{path: "/products?name=banana&locale=de-AT", expectedCount: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			rec := executeRequest(t, app, http.MethodGet, test.path, nil)
+			if items := decodeJSON[handler.GetProductsResponse](t, rec).Items; len(items) != test.expectedCount {
+				t.Fatalf("expected %d products, got %d", test.expectedCount, len(items))
+			}
+		})
+	}
+}
+
+func TestProductTranslations(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "Apricot", PriceCents: 100}})
+	token := loginAdmin(t, app)
+
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.ProductTranslationRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/admin/products/1/translations/de-at", body: handler.ProductTranslationRequest{Name: "Marille"}, expectedCode: http.StatusOK},
+		{name: "invalid locale", path: "/admin/products/1/translations/xx-!!", body: handler.ProductTranslationRequest{Name: "Marille"}, expectedCode: http.StatusBadRequest},
+		{name: "empty translation", path: "/admin/products/1/translations/de", body: handler.ProductTranslationRequest{Name: "  "}, expectedCode: http.StatusBadRequest},
+		{name: "unknown product", path: "/admin/products/9/translations/de", body: handler.ProductTranslationRequest{Name: "Aprikose"}, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPut, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Saving the same locale again replaces the translation.
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/translations/de-AT", token, handler.ProductTranslationRequest{Name: "Marillen"})
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/translations", token, nil)
+	items := decodeJSON[map[string][]handler.ProductTranslationResponse](t, rec)["items"]
+	if len(items) != 1 || items[0].Locale != "de-AT" || items[0].Name != "Marillen" {
+		t.Fatalf("unexpected translations %+v", items)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/1/translations/de-AT", token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/1/translations/de-AT", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestDeleteProduct_MovesToTrash(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+	token := loginAdmin(t, app)
+
+	rec := executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products", nil)
+	items := decodeJSON[map[string][]handler.ProductResponse](t, rec)["items"]
+	if len(items) != 1 || items[0].Name != "banana" {
+		t.Fatalf("expected only banana to be listed, got %v", items)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/trash", token, nil)
+	trash := decodeJSON[map[string][]handler.DeletedProductResponse](t, rec)["items"]
+	if len(trash) != 1 || trash[0].Name != "apple" || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("expected apple in the trash, got %v", trash)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d when deleting twice, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestDeleteProduct_NameCanBeReused(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", SKU: skuPtr("APL-1"), PriceCents: 100}})
+	token := loginAdmin(t, app)
+
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+
+	if err := db.Create(&domain.Product{Name: "apple", SKU: skuPtr("APL-1"), PriceCents: 120}).Error; err != nil {
+		t.Fatalf("expected to re-create a deleted product's name and sku, got %v", err)
+	}
+	if err := db.Create(&domain.Product{Name: "apple", PriceCents: 130}).Error; err == nil {
+		t.Fatalf("expected active names to stay unique")
+	}
+}
+
+func TestRestoreProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		recreate     bool
+		productID    uint
+		expectedCode int
+	}{
+		{name: "success", productID: 1, expectedCode: http.StatusOK},
+		{name: "name taken by an active product", productID: 1, recreate: true, expectedCode: http.StatusConflict},
+		{name: "product is not in the trash", productID: 2, expectedCode: http.StatusNotFound},
+		{name: "unknown product", productID: 99, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+			token := loginAdmin(t, app)
+			executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+			if test.recreate {
+				seedProducts(t, db, []domain.Product{{Name: "apple", Slug: "apple-2", PriceCents: 150}})
+			}
+
+			path := fmt.Sprintf("/admin/products/trash/%d/restore", test.productID)
+			rec := executeRequestWithToken(t, app, http.MethodPost, path, token, nil)
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+
+			if test.expectedCode == http.StatusOK {
+				var product domain.Product
+				if err := db.First(&product, 1).Error; err != nil {
+					t.Fatalf("expected product to be active again, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeProduct(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	for _, row := range []any{
+		&domain.StockLevel{ProductID: 1, WarehouseID: 1, OnHand: 5},
+		&domain.ProductSlug{Slug: "old-apple", ProductID: 1},
+		&domain.ProductAffinity{ProductID: 1, RelatedProductID: 2, Count: 3},
+		&domain.ProductAffinity{ProductID: 2, RelatedProductID: 1, Count: 3},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	token := loginAdmin(t, app)
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+
+	rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/trash/2", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected active products not to be purged, got %d", rec.Code)
+	}
+
+	// A coupon for the product keeps it from being purged.
+	coupon := domain.Coupon{Code: "APPLE", Kind: "percentage", PercentOff: 10, ProductID: uintPtr(1)}
+	if err := db.Create(&coupon).Error; err != nil {
+		t.Fatal(err)
+	}
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/trash/1", token, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected a product with a coupon not to be purged, got %d", rec.Code)
+	}
+	db.Delete(&coupon)
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/trash/1", token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	var count int64
+	db.Unscoped().Model(&domain.Product{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected 1 product left in the table, got %d", count)
+	}
+
+	// Nothing may keep pointing at the purged product.
+	for _, model := range []any{&domain.StockLevel{}, &domain.ProductSlug{}, &domain.ProductAffinity{}} {
+		db.Model(model).Count(&count)
+		if count != 0 {
+			t.Fatalf("expected no %T rows left, got %d", model, count)
+		}
+	}
+}
+
+func TestEmptyProductTrash(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 200},
+		{Name: "cherry", PriceCents: 300},
+	})
+	token := loginAdmin(t, app)
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/1", token, nil)
+	executeRequestWithToken(t, app, http.MethodDelete, "/products/3", token, nil)
+
+	rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/trash", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if resp := decodeJSON[handler.PurgeProductsResponse](t, rec); resp.Purged != 2 {
+		t.Fatalf("expected 2 purged products, got %d", resp.Purged)
+	}
+
+	var products []domain.Product
+	db.Unscoped().Find(&products)
+	if len(products) != 1 || products[0].Name != "banana" {
+		t.Fatalf("expected only banana to remain, got %v", products)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+var carType = handler.ProductTypeRequest{
+	Name: "car",
+	Attributes: []handler.AttributeDefinitionRequest{
+		{Name: "fuel", Type: "enum", Required: true, Options: []string{"petrol", "hybrid", "electric"}},
+		{Name: "mileage", Type: "number"},
+		{Name: "automatic", Type: "bool"},
+		{Name: "colour", Type: "string"},
+	},
+}
+
+// seedCars creates the car product type and a few cars through the API.
+func seedCars(t *testing.T, app http.Handler, token string) {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/product-types", token, carType)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("failed to create product type: %d", rec.Code)
+	}
+	typeID := decodeJSON[handler.ProductTypeResponse](t, rec).ID
+
+	cars := []handler.ProductRequest{
+		{Name: "Toyota Prius", PriceCents: 2800000, Attributes: map[string]any{"fuel": "hybrid", "mileage": 30000, "automatic": true}},
+		{Name: "Honda Insight", PriceCents: 2600000, Attributes: map[string]any{"fuel": "hybrid", "mileage": 80000, "automatic": false}},
+		{Name: "Tesla Model 3", PriceCents: 4200000, Attributes: map[string]any{"fuel": "electric", "mileage": 10000, "automatic": true}},
+		{Name: "Ford Focus", PriceCents: 2300000, Attributes: map[string]any{"fuel": "petrol", "mileage": 120000}},
+	}
+	for _, car := range cars {
+		car.ProductTypeID = &typeID
+		rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, car)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create %s: %d %s", car.Name, rec.Code, rec.Body.String())
+		}
+	}
+	executeRequestWithToken(t, app, http.MethodPost, "/products", token, handler.ProductRequest{Name: "Floor Mat", PriceCents: 5000})
+}
+
+func TestCreateProductType(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         handler.ProductTypeRequest
+		expectedCode int
+	}{
+		{name: "success", body: carType, expectedCode: http.StatusCreated},
+		{name: "duplicate name", body: handler.ProductTypeRequest{Name: "car"}, expectedCode: http.StatusConflict},
+		{
+			name:         "attribute name used with another type",
+			body:         handler.ProductTypeRequest{Name: "bike", Attributes: []handler.AttributeDefinitionRequest{{Name: "mileage", Type: "string"}}},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "attribute name shared with the same type",
+			body:         handler.ProductTypeRequest{Name: "bike", Attributes: []handler.AttributeDefinitionRequest{{Name: "mileage", Type: "number"}}},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid attribute name",
+			body:         handler.ProductTypeRequest{Name: "bike", Attributes: []handler.AttributeDefinitionRequest{{Name: "Wheel Size", Type: "number"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid attribute type",
+			body:         handler.ProductTypeRequest{Name: "bike", Attributes: []handler.AttributeDefinitionRequest{{Name: "gears", Type: "integer"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "enum without options",
+			body:         handler.ProductTypeRequest{Name: "bike", Attributes: []handler.AttributeDefinitionRequest{{Name: "frame", Type: "enum"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+			token := loginAdmin(t, app)
+			if test.name != "success" {
+				executeRequestWithToken(t, app, http.MethodPost, "/admin/product-types", token, carType)
+			}
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/product-types", token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateProduct_ValidatesAttributes(t *testing.T) {
+	typeID := uint(1)
+	unknownTypeID := uint(9)
+
+	tests := []struct {
+		name         string
+		body         handler.ProductRequest
+		expectedCode int
+	}{
+		{
+			name:         "valid attributes",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &typeID, Attributes: map[string]any{"fuel": "hybrid", "mileage": 100, "automatic": true, "colour": "red"}},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "missing required attribute",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &typeID, Attributes: map[string]any{"mileage": 100}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "undefined attribute",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &typeID, Attributes: map[string]any{"fuel": "hybrid", "wings": 2}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "wrong value type",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &typeID, Attributes: map[string]any{"fuel": "hybrid", "mileage": "a lot"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "value outside enum options",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &typeID, Attributes: map[string]any{"fuel": "diesel"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "attributes without a product type",
+			body:         handler.ProductRequest{Name: "Prius", Attributes: map[string]any{"fuel": "hybrid"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown product type",
+			body:         handler.ProductRequest{Name: "Prius", ProductTypeID: &unknownTypeID},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+			token := loginAdmin(t, app)
+			executeRequestWithToken(t, app, http.MethodPost, "/admin/product-types", token, carType)
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+
+			if test.expectedCode == http.StatusCreated {
+				rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+				product := decodeJSON[handler.ProductResponse](t, rec)
+				if product.Attributes["fuel"] != "hybrid" || product.Attributes["mileage"] != float64(100) {
+					t.Fatalf("expected attributes to be stored, got %v", product.Attributes)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProducts_WithAttributeFilters(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedNames []string
+	}{
+		{name: "enum equality", query: "attr.fuel=hybrid", expectedCode: http.StatusOK, expectedNames: []string{"Honda Insight", "Toyota Prius"}},
+		{name: "any of several values", query: "attr.fuel=hybrid&attr.fuel=electric", expectedCode: http.StatusOK, expectedNames: []string{"Honda Insight", "Tesla Model 3", "Toyota Prius"}},
+		{name: "numeric range", query: "attr.mileage.min=20000&attr.mileage.max=100000", expectedCode: http.StatusOK, expectedNames: []string{"Honda Insight", "Toyota Prius"}},
+		{name: "numeric equality", query: "attr.mileage=10000", expectedCode: http.StatusOK, expectedNames: []string{"Tesla Model 3"}},
+		{name: "bool", query: "attr.automatic=true", expectedCode: http.StatusOK, expectedNames: []string{"Tesla Model 3", "Toyota Prius"}},
+		{name: "combined with other filters", query: "attr.fuel=hybrid&maxPrice=2700000", expectedCode: http.StatusOK, expectedNames: []string{"Honda Insight"}},
+		{name: "unknown attribute", query: "attr.wings=2", expectedCode: http.StatusBadRequest},
+		{name: "range on a non-number", query: "attr.fuel.min=1", expectedCode: http.StatusBadRequest},
+		{name: "invalid number", query: "attr.mileage=lots", expectedCode: http.StatusBadRequest},
+		{name: "invalid bound", query: "attr.mileage.avg=1", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+			seedCars(t, app, loginAdmin(t, app))
+
+			rec := executeRequest(t, app, http.MethodGet, "/products?"+test.query, nil)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var names []string
+			for _, item := range decodeJSON[handler.GetProductsResponse](t, rec).Items {
+				names = append(names, item.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(test.expectedNames, names) {
+				t.Fatalf("expected %v, got %v", test.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestGetProducts_WithAttributeFacets(t *testing.T) {
+	app, _ := setupTestApp(t)
+	seedCars(t, app, loginAdmin(t, app))
+
+	rec := executeRequest(t, app, http.MethodGet, "/products?facets=attr.fuel,attr.automatic&attr.fuel=hybrid", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	resp := decodeJSON[handler.GetProductsResponse](t, rec)
+
+	type bucket struct {
+		value string
+		count int64
+	}
+	facet := func(name string) []bucket {
+		var result []bucket
+		for _, b := range resp.Facets[name] {
+			result = append(result, bucket{b.Value, b.Count})
+		}
+		return result
+	}
+
+	// The fuel facet ignores the fuel filter; the automatic facet respects it.
+	if expected := []bucket{{"hybrid", 2}, {"electric", 1}, {"petrol", 1}}; !reflect.DeepEqual(expected, facet("attr.fuel")) {
+		t.Fatalf("expected fuel facet %v, got %v", expected, facet("attr.fuel"))
+	}
+	if expected := []bucket{{"false", 1}, {"true", 1}}; !reflect.DeepEqual(expected, facet("attr.automatic")) {
+		t.Fatalf("expected automatic facet %v, got %v", expected, facet("attr.automatic"))
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products?facets=attr.wings", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d for an unknown attribute facet, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
@@ -18,6 +18,9 @@ func seedProducts(t *testing.T, db *gorm.DB, products []domain.Product) {
 
 	for _, product := range products {
 		p := product
+		if p.Slug == "" {
+			p.Slug = domain.Slugify(p.Name)
+		}
 		if result := db.Create(&p); result.Error != nil {
 			t.Fatal(result.Error)
 		}
@@ -209,3 +212,216 @@ func TestGetProducts_WithFilteringByPrice(t *testing.T) {
 		})
 	}
 }
+
+func TestGetProduct(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+
+	rec := executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.Name != "apple" {
+		t.Fatalf("expected apple, got %s", product.Name)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/2", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestCreateProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         handler.ProductRequest
+		expectedCode int
+	}{
+		{name: "success", body: handler.ProductRequest{Name: "cherry", PriceCents: 300}, expectedCode: http.StatusCreated},
+		{name: "missing name", body: handler.ProductRequest{Name: " ", PriceCents: 300}, expectedCode: http.StatusBadRequest},
+		{name: "negative price", body: handler.ProductRequest{Name: "cherry", PriceCents: -1}, expectedCode: http.StatusBadRequest},
+		{name: "duplicate name", body: handler.ProductRequest{Name: "apple", PriceCents: 300}, expectedCode: http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/products", token, test.body)
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.ProductRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/products/1", body: handler.ProductRequest{Name: "green apple", PriceCents: 120}, expectedCode: http.StatusOK},
+		{name: "name taken", path: "/products/1", body: handler.ProductRequest{Name: "banana", PriceCents: 120}, expectedCode: http.StatusConflict},
+		{name: "unknown product", path: "/products/9", body: handler.ProductRequest{Name: "kiwi", PriceCents: 120}, expectedCode: http.StatusNotFound},
+		{name: "invalid id", path: "/products/abc", body: handler.ProductRequest{Name: "kiwi", PriceCents: 120}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPut, test.path, token, test.body)
+
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+
+			if test.expectedCode == http.StatusOK {
+				var product domain.Product
+				db.First(&product, 1)
+				if product.Name != test.body.Name || product.PriceCents != test.body.PriceCents {
+					t.Fatalf("expected product to be updated, got %+v", product)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProducts_WithFilteringByCategory(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "Toyota Prius", Category: "hybrid", PriceCents: 2800000},
+		{Name: "Tesla Model 3", Category: "electric", PriceCents: 4200000},
+		{Name: "Ford Focus", Category: "petrol", PriceCents: 2300000},
+	})
+
+	rec := executeRequest(t, app, http.MethodGet, "/products?category=hybrid&category=electric&orderBy=name", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	resp := decodeJSON[handler.GetProductsResponse](t, rec)
+	var names []string
+	for _, item := range resp.Items {
+		names = append(names, item.Name)
+	}
+	expected := []string{"Tesla Model 3", "Toyota Prius"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if resp.Facets != nil {
+		t.Fatalf("expected no facets unless requested, got %v", resp.Facets)
+	}
+}
+
+func TestGetProducts_WithFacets(t *testing.T) {
+	products := []domain.Product{
+		{Name: "Toyota Prius", Category: "hybrid", PriceCents: 2800000},
+		{Name: "Honda Insight", Category: "hybrid", PriceCents: 2600000},
+		{Name: "Tesla Model 3", Category: "electric", PriceCents: 4200000},
+		{Name: "Ford Focus", Category: "petrol", PriceCents: 2300000},
+		{Name: "Porsche 911", Category: "petrol", PriceCents: 12500000},
+		{Name: "Spare Tyre", PriceCents: 20000},
+	}
+
+	type bucket struct {
+		key   string
+		count int64
+	}
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedItems    int
+		expectedCategory []bucket
+		expectedPrice    []bucket
+	}{
+		{
+			name:             "no filters",
+			query:            "facets=category,price&priceBuckets=2500000,5000000",
+			expectedItems:    6,
+			expectedCategory: []bucket{{"hybrid", 2}, {"petrol", 2}, {"electric", 1}},
+			expectedPrice:    []bucket{{"0-2500000", 2}, {"2500000-5000000", 3}, {"5000000-", 1}},
+		},
+		{
+			// The category facet ignores the category filter, but the price
+			// facet only counts hybrids.
+			name:             "category filter is left out of its own facet",
+			query:            "facets=category,price&priceBuckets=2500000,5000000&category=hybrid",
+			expectedItems:    2,
+			expectedCategory: []bucket{{"hybrid", 2}, {"petrol", 2}, {"electric", 1}},
+			expectedPrice:    []bucket{{"0-2500000", 0}, {"2500000-5000000", 2}, {"5000000-", 0}},
+		},
+		{
+			name:             "price filter is left out of its own facet",
+			query:            "facets=category,price&priceBuckets=2500000,5000000&minPrice=2500000&maxPrice=4999999",
+			expectedItems:    3,
+			expectedCategory: []bucket{{"hybrid", 2}, {"electric", 1}},
+			expectedPrice:    []bucket{{"0-2500000", 2}, {"2500000-5000000", 3}, {"5000000-", 1}},
+		},
+		{
+			name:             "name filter applies to every facet",
+			query:            "facets=category,price&priceBuckets=2500000,5000000&name=us",
+			expectedItems:    2,
+			expectedCategory: []bucket{{"hybrid", 1}, {"petrol", 1}},
+			expectedPrice:    []bucket{{"0-2500000", 1}, {"2500000-5000000", 1}, {"5000000-", 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, products)
+
+			rec := executeRequest(t, app, http.MethodGet, "/products?"+test.query, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+			}
+			resp := decodeJSON[handler.GetProductsResponse](t, rec)
+
+			if len(resp.Items) != test.expectedItems {
+				t.Fatalf("expected %d items, got %d", test.expectedItems, len(resp.Items))
+			}
+
+			var category []bucket
+			for _, b := range resp.Facets["category"] {
+				category = append(category, bucket{b.Value, b.Count})
+			}
+			if !reflect.DeepEqual(test.expectedCategory, category) {
+				t.Fatalf("expected category facet %v, got %v", test.expectedCategory, category)
+			}
+
+			var price []bucket
+			for _, b := range resp.Facets["price"] {
+				key := fmt.Sprintf("%d-", *b.From)
+				if b.To != nil {
+					key += fmt.Sprint(*b.To)
+				}
+				price = append(price, bucket{key, b.Count})
+			}
+			if !reflect.DeepEqual(test.expectedPrice, price) {
+				t.Fatalf("expected price facet %v, got %v", test.expectedPrice, price)
+			}
+		})
+	}
+}
+
+func TestGetProducts_WithInvalidFacets(t *testing.T) {
+	for _, query := range []string{"facets=colour", "facets=price&priceBuckets=500,100", "facets=price&priceBuckets=abc"} {
+		t.Run(query, func(t *testing.T) {
+			app, _ := setupTestApp(t)
+
+			rec := executeRequest(t, app, http.MethodGet, "/products?"+query, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
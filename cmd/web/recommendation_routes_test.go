@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+// updateAffinities runs the recommendations job as if orders had all had
+// time to settle, and returns how many orders it counted.
+func updateAffinities(t *testing.T, db *gorm.DB) int {
+	t.Helper()
+
+	counted, err := repository.NewRepository(db).UpdateProductAffinities(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to update affinities: %v", err)
+	}
+	return counted
+}
+
+func getRelated(t *testing.T, app http.Handler, path string) []handler.RelatedProductResponse {
+	t.Helper()
+
+	rec := executeRequest(t, app, http.MethodGet, path, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	return decodeJSON[map[string][]handler.RelatedProductResponse](t, rec)["items"]
+}
+
+func TestGetRelatedProducts(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", Category: "fruit", PriceCents: 100},
+		{Name: "banana", Category: "fruit", PriceCents: 200},
+		{Name: "cherry", Category: "fruit", PriceCents: 500},
+		{Name: "bread", Category: "bakery", PriceCents: 300},
+		{Name: "butter", Category: "dairy", PriceCents: 250},
+	})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+
+	placeOrder := func(productIDs ...uint) {
+		t.Helper()
+
+		var items []handler.OrderItemRequest
+		for _, id := range productIDs {
+			items = append(items, handler.OrderItemRequest{ProductID: id, Quantity: 1})
+		}
+		rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, handler.CreateOrderRequest{Items: items})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("failed to place order: %d", rec.Code)
+		}
+	}
+	payOrder := func(orderID uint) {
+		t.Helper()
+
+		for _, status := range []string{"awaiting_payment", "paid"} {
+			if code := transitionOrder(t, app, admin, orderID, status); code != http.StatusOK {
+				t.Fatalf("failed to move order %d to %s: %d", orderID, status, code)
+			}
+		}
+	}
+
+	// Without any orders, only similar products are recommended.
+	related := getRelated(t, app, "/products/1/related")
+	if len(related) != 2 || related[0].Name != "banana" || related[1].Name != "cherry" || related[0].Reason != "similar" {
+		t.Fatalf("expected banana then cherry, got %+v", related)
+	}
+
+	placeOrder(1, 4)
+	placeOrder(1, 4, 5)
+	placeOrder(1, 5)
+	placeOrder(2, 3)
+	placeOrder(2, 3)
+	for _, id := range []uint{1, 2, 3} {
+		payOrder(id)
+	}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders/4/cancel", customer, nil)
+
+	if counted, err := repository.NewRepository(db).UpdateProductAffinities(time.Now()); err != nil || counted != 0 {
+		t.Fatalf("expected recent orders to be left to settle, got %d %v", counted, err)
+	}
+
+	// An order that is still settling holds back the ones after it.
+	db.Model(&domain.Order{}).Where("id = ?", 1).Update("created_at", time.Now().Add(2*time.Hour))
+	if counted := updateAffinities(t, db); counted != 0 {
+		t.Fatalf("expected orders after a settling one to wait, got %d", counted)
+	}
+	db.Model(&domain.Order{}).Where("id = ?", 1).Update("created_at", time.Now())
+
+	if counted := updateAffinities(t, db); counted != 5 {
+		t.Fatalf("expected 5 orders to be counted, got %d", counted)
+	}
+	if counted := updateAffinities(t, db); counted != 0 {
+		t.Fatalf("expected counted orders to be skipped, got %d", counted)
+	}
+
+	related = getRelated(t, app, "/products/1/related?limit=3")
+	expected := []struct{ name, reason string }{
+		{"bread", "bought_together"},
+		{"butter", "bought_together"},
+		{"banana", "similar"},
+	}
+	if len(related) != len(expected) {
+		t.Fatalf("expected %d products, got %+v", len(expected), related)
+	}
+	for i, product := range expected {
+		if related[i].Name != product.name || related[i].Reason != product.reason {
+			t.Fatalf("expected %s (%s) at %d, got %+v", product.name, product.reason, i, related[i])
+		}
+	}
+
+	// The cancelled and unpaid orders don't count.
+	if related := getRelated(t, app, "/products/2/related"); related[0].Name != "apple" || related[0].Reason != "similar" {
+		t.Fatalf("expected only similar products for banana, got %+v", related)
+	}
+
+	placeOrder(1, 5)
+	payOrder(6)
+	if counted := updateAffinities(t, db); counted != 1 {
+		t.Fatalf("expected only the new order to be counted, got %d", counted)
+	}
+	if related := getRelated(t, app, "/products/1/related?limit=1"); len(related) != 1 || related[0].Name != "butter" {
+		t.Fatalf("expected butter to overtake bread, got %+v", related)
+	}
+}
+
+func TestGetRelatedProducts_Validation(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{name: "success", path: "/products/1/related?limit=20", expectedCode: http.StatusOK},
+		{name: "limit too high", path: "/products/1/related?limit=21", expectedCode: http.StatusBadRequest},
+		{name: "invalid limit", path: "/products/1/related?limit=abc", expectedCode: http.StatusBadRequest},
+		{name: "unknown product", path: "/products/9/related", expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+
+			rec := executeRequest(t, app, http.MethodGet, test.path, nil)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+)
+
+// setupPaidOrder places and pays for an order of 3 apples at 100 and 2
+// bananas at 250, with 10 of each in stock beforehand. The coupon, if any,
+// is created and used for the order.
+func setupPaidOrder(t *testing.T, coupon *handler.CouponRequest) (http.Handler, string, string) {
+	t.Helper()
+
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 100},
+		{Name: "banana", PriceCents: 250},
+	})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	token := registerAndLogin(t, app, "customer")
+	for _, id := range []uint{1, 2} {
+		executeRequestWithToken(t, app, http.MethodPut, fmt.Sprintf("/admin/products/%d/stock/1", id), admin, handler.StockRequest{OnHand: 10})
+	}
+
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, Quantity: 2},
+	}}
+	if coupon != nil {
+		order.CouponCode = createCoupon(t, app, admin, *coupon).Code
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order); rec.Code != http.StatusCreated {
+		t.Fatalf("failed to place the order: %d %s", rec.Code, rec.Body.String())
+	}
+	p := decodeJSON[handler.PaymentResponse](t, payOrder(t, app, token, 1, payment.CardSucceeds))
+	event := payment.Event{ID: "evt_1", Type: payment.EventPaymentSucceeded, Reference: *p.Reference}
+	if rec := sendPaymentWebhook(t, app, event, time.Now()); rec.Code != http.StatusOK {
+		t.Fatalf("failed to pay the order: %d", rec.Code)
+	}
+	return app, admin, token
+}
+
+func refundOrder(t *testing.T, app http.Handler, token string, orderID uint, body handler.RefundRequest) int {
+	t.Helper()
+
+	path := fmt.Sprintf("/admin/orders/%d/refunds", orderID)
+	return executeRequestWithToken(t, app, http.MethodPost, path, token, body).Code
+}
+
+func onHand(t *testing.T, app http.Handler, admin string, productID uint) int {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, fmt.Sprintf("/admin/products/%d/stock", productID), admin, nil)
+	return decodeJSON[handler.ProductStockResponse](t, rec).OnHand
+}
+
+func TestRefundOrder(t *testing.T) {
+	app, admin, token := setupPaidOrder(t, nil)
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/orders/1/refunds", admin, handler.RefundRequest{
+		Items:   []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}},
+		Reason:  "bruised",
+		Restock: true,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if refund := decodeJSON[handler.RefundResponse](t, rec); refund.Status != "succeeded" || refund.AmountCents != 100 || !refund.Restocked || len(refund.Items) != 1 {
+		t.Fatalf("unexpected refund: %+v", refund)
+	}
+	if stock := onHand(t, app, admin, 1); stock != 8 {
+		t.Fatalf("expected 8 apples on hand, got %d", stock)
+	}
+
+	tests := []struct {
+		name         string
+		body         handler.RefundRequest
+		expectedCode int
+	}{
+		{name: "more units than are left", body: handler.RefundRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}}}, expectedCode: http.StatusConflict},
+		{name: "product not in the order", body: handler.RefundRequest{Items: []handler.OrderItemRequest{{ProductID: 9, Quantity: 1}}}, expectedCode: http.StatusBadRequest},
+		{name: "more than was captured", body: handler.RefundRequest{AmountCents: 701}, expectedCode: http.StatusConflict},
+		{name: "restock without items", body: handler.RefundRequest{AmountCents: 100, Restock: true}, expectedCode: http.StatusBadRequest},
+		{name: "items and an amount", body: handler.RefundRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}, AmountCents: 100}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := refundOrder(t, app, admin, 1, test.body); code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, code)
+			}
+		})
+	}
+
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{AmountCents: 50, Reason: "goodwill"}); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "paid" {
+		t.Fatalf("expected the order to stay paid, got %s", status)
+	}
+
+	// Refunding the rest restocks the remaining units and refunds the order.
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{Restock: true}); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if stock := onHand(t, app, admin, 1); stock != 10 {
+		t.Fatalf("expected 10 apples on hand, got %d", stock)
+	}
+	if stock := onHand(t, app, admin, 2); stock != 10 {
+		t.Fatalf("expected 10 bananas on hand, got %d", stock)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/orders/1", token, nil)
+	order := decodeJSON[handler.OrderResponse](t, rec)
+	if order.Status != "refunded" || order.RefundedCents != order.TotalCents || len(order.Refunds) != 3 {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	if last := order.Refunds[2]; last.AmountCents != 650 || len(last.Items) != 2 {
+		t.Fatalf("unexpected refund: %+v", last)
+	}
+
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{AmountCents: 1}); code != http.StatusConflict {
+		t.Fatalf("expected 409 for a refunded order, got %d", code)
+	}
+}
+
+func TestRefundOrder_Discounted(t *testing.T) {
+	// 10% off 800 takes 30 off the apples and 50 off the bananas.
+	app, admin, token := setupPaidOrder(t, &handler.CouponRequest{Code: "TENOFF", Kind: "percentage", PercentOff: 10})
+
+	var amounts []int64
+	for _, item := range []handler.OrderItemRequest{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 1, Quantity: 2},
+	} {
+		rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/orders/1/refunds", admin, handler.RefundRequest{
+			Items: []handler.OrderItemRequest{item},
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		amounts = append(amounts, decodeJSON[handler.RefundResponse](t, rec).AmountCents)
+	}
+	if !reflect.DeepEqual(amounts, []int64{90, 225, 180}) {
+		t.Fatalf("expected refunds of 90, 225 and 180, got %v", amounts)
+	}
+
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{Items: []handler.OrderItemRequest{{ProductID: 2, Quantity: 1}}}); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1", token, nil)
+	if order := decodeJSON[handler.OrderResponse](t, rec); order.Status != "refunded" || order.RefundedCents != 720 {
+		t.Fatalf("expected all 720 paid to be refunded, got %+v", order)
+	}
+}
+
+func TestRefundUnpaidOrder(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	admin := loginAdmin(t, app)
+	token := registerAndLogin(t, app, "customer")
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order)
+
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{}); code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", code)
+	}
+	if code := refundOrder(t, app, admin, 9, handler.RefundRequest{}); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+	if code := refundOrder(t, app, token, 1, handler.RefundRequest{}); code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", code)
+	}
+}
+
+func TestRefundOrder_NotByTransition(t *testing.T) {
+	app, admin, token := setupPaidOrder(t, nil)
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/orders/1/transitions", admin, handler.OrderTransitionRequest{Status: "refunded"})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if resp := decodeJSON[handler.ErrorResponse](t, rec); !strings.Contains(resp.Error, "/refunds") {
+		t.Fatalf("expected the error to point to the refunds endpoint, got %q", resp.Error)
+	}
+	if status := getOrderStatus(t, app, token, 1); status != "paid" {
+		t.Fatalf("expected the order to stay paid, got %s", status)
+	}
+}
+
+func TestRefundOrder_FreeItems(t *testing.T) {
+	// The apples are free, so only the 500 for the bananas was paid.
+	app, admin, _ := setupPaidOrder(t, &handler.CouponRequest{Code: "FREEAPPLES", Kind: "percentage", PercentOff: 100, ProductID: uintPtr(1)})
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/orders/1/refunds", admin, handler.RefundRequest{
+		Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}},
+	})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if code := refundOrder(t, app, admin, 1, handler.RefundRequest{Items: []handler.OrderItemRequest{{ProductID: 2, Quantity: 2}}}); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+}
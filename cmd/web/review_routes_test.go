@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestCreateReview(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.CreateReviewRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/products/1/reviews", body: handler.CreateReviewRequest{Rating: 5, Body: "Great"}, expectedCode: http.StatusCreated},
+		{name: "rating too low", path: "/products/1/reviews", body: handler.CreateReviewRequest{Rating: 0}, expectedCode: http.StatusBadRequest},
+		{name: "rating too high", path: "/products/1/reviews", body: handler.CreateReviewRequest{Rating: 6}, expectedCode: http.StatusBadRequest},
+		{name: "unknown product", path: "/products/9/reviews", body: handler.CreateReviewRequest{Rating: 3}, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			token := registerAndLogin(t, app, "customer")
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+
+			if test.expectedCode == http.StatusCreated {
+				review := decodeJSON[handler.ReviewResponse](t, rec)
+				if review.Status != "pending" || review.VerifiedPurchase {
+					t.Fatalf("expected an unverified pending review, got %+v", review)
+				}
+
+				rec = executeRequestWithToken(t, app, http.MethodPost, test.path, token, test.body)
+				if rec.Code != http.StatusConflict {
+					t.Fatalf("expected a second review to be rejected with %d, got %d", http.StatusConflict, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateReview_VerifiedPurchase(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+	admin := loginAdmin(t, app)
+	token := registerAndLogin(t, app, "customer")
+	for _, productID := range []uint{1, 2} {
+		executeRequestWithToken(t, app, http.MethodPost, "/orders", token, handler.CreateOrderRequest{
+			Items: []handler.OrderItemRequest{{ProductID: productID, Quantity: 1}},
+		})
+	}
+	// Only the paid order counts; the cancelled one doesn't.
+	for _, status := range []string{"awaiting_payment", "paid"} {
+		if code := transitionOrder(t, app, admin, 1, status); code != http.StatusOK {
+			t.Fatalf("failed to move the order to %s: %d", status, code)
+		}
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders/2/cancel", token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("failed to cancel the order: %d", rec.Code)
+	}
+
+	for productID, expected := range map[int]bool{1: true, 2: false} {
+		path := fmt.Sprintf("/products/%d/reviews", productID)
+		rec := executeRequestWithToken(t, app, http.MethodPost, path, token, handler.CreateReviewRequest{Rating: 4})
+		if review := decodeJSON[handler.ReviewResponse](t, rec); review.VerifiedPurchase != expected {
+			t.Fatalf("expected verified purchase %v for product %d, got %v", expected, productID, review.VerifiedPurchase)
+		}
+	}
+}
+
+func TestModerateReviews(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}, {Name: "banana", PriceCents: 200}})
+	admin := loginAdmin(t, app)
+
+	for i, rating := range []int{5, 4, 1} {
+		token := registerAndLogin(t, app, fmt.Sprintf("customer%d", i))
+		executeRequestWithToken(t, app, http.MethodPost, "/products/1/reviews", token, handler.CreateReviewRequest{Rating: rating})
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/reviews", admin, nil)
+	if queue := decodeJSON[map[string][]handler.ReviewResponse](t, rec)["items"]; len(queue) != 3 {
+		t.Fatalf("expected 3 reviews awaiting moderation, got %d", len(queue))
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/reviews/1/approve", admin, nil)
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/reviews/2/approve", admin, nil)
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/admin/reviews/3/reject", admin, nil)
+	if review := decodeJSON[handler.ReviewResponse](t, rec); review.Status != "rejected" || review.UserName != "customer2" {
+		t.Fatalf("unexpected moderated review %+v", review)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1/reviews", nil)
+	if reviews := decodeJSON[map[string][]handler.ReviewResponse](t, rec)["items"]; len(reviews) != 2 {
+		t.Fatalf("expected only the 2 approved reviews to be public, got %d", len(reviews))
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	product := decodeJSON[handler.ProductResponse](t, rec)
+	if product.RatingAverage != 4.5 || product.ReviewCount != 2 {
+		t.Fatalf("expected rating 4.5 from 2 reviews, got %v from %d", product.RatingAverage, product.ReviewCount)
+	}
+
+	// Un-approving a review takes it out of the average again.
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/reviews/2/reject", admin, nil)
+	rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	product = decodeJSON[handler.ProductResponse](t, rec)
+	if product.RatingAverage != 5 || product.ReviewCount != 1 {
+		t.Fatalf("expected rating 5 from 1 review, got %v from %d", product.RatingAverage, product.ReviewCount)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/admin/reviews/9/approve", admin, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestGetProducts_SortedByRating(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", RatingAverage: 3.5, ReviewCount: 10},
+		{Name: "banana", RatingAverage: 4.8, ReviewCount: 2},
+		{Name: "cherry", RatingAverage: 3.5, ReviewCount: 40},
+	})
+
+	rec := executeRequest(t, app, http.MethodGet, "/products?orderBy=rating&sortIn=desc", nil)
+	var names []string
+	for _, item := range decodeJSON[handler.GetProductsResponse](t, rec).Items {
+		names = append(names, item.Name)
+	}
+
+	expected := []string{"banana", "cherry", "apple"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
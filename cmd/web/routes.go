@@ -15,10 +15,134 @@ func routes(handler *handler.Handler) http.Handler {
 
 	mux.Get("/ping", handler.Ping)
 
-	mux.Post("/register-user", handler.RegisterUser)
+	mux.With(handler.Idempotent).Post("/register-user", handler.RegisterUser)
 	mux.Post("/login-user", handler.LoginUser)
 
 	mux.Get("/products", handler.GetProducts)
+	mux.Get("/products/{id}", handler.GetProduct)
+	mux.Get("/products/by-slug/{slug}", handler.GetProductBySlug)
+	mux.Get("/products/{id}/reviews", handler.GetProductReviews)
+	mux.Get("/products/{id}/price-history", handler.GetPriceHistory)
+	mux.Get("/products/{id}/related", handler.GetRelatedProducts)
+	mux.Get("/product-types", handler.GetProductTypes)
+	mux.Get("/exchange-rates", handler.GetExchangeRates)
+
+	mux.Post("/webhooks/payments", handler.HandlePaymentWebhook)
+
+	// Guests can fill a cart before logging in; see handler.CartTokenHeader.
+	mux.Group(func(r chi.Router) {
+		r.Use(handler.OptionalAuth)
+
+		r.Get("/cart", handler.GetCart)
+		r.Post("/cart/items", handler.AddCartItem)
+		r.Put("/cart/items/{productID}", handler.UpdateCartItem)
+		r.Delete("/cart/items/{productID}", handler.RemoveCartItem)
+		r.Put("/cart/coupon", handler.ApplyCartCoupon)
+		r.Delete("/cart/coupon", handler.RemoveCartCoupon)
+	})
+
+	mux.Group(func(r chi.Router) {
+		r.Use(handler.RequireAuth)
+
+		r.Post("/products/{id}/reviews", handler.CreateReview)
+		r.Put("/products/{id}/stock-subscription", handler.SubscribeToStock)
+		r.Delete("/products/{id}/stock-subscription", handler.UnsubscribeFromStock)
+
+		r.Get("/cart/shipping-methods", handler.GetShippingQuotes)
+		r.With(handler.Idempotent).Post("/cart/checkout", handler.CheckoutCart)
+
+		r.Get("/addresses", handler.GetAddresses)
+		r.Post("/addresses", handler.CreateAddress)
+		r.Get("/addresses/{id}", handler.GetAddress)
+		r.Put("/addresses/{id}", handler.UpdateAddress)
+		r.Delete("/addresses/{id}", handler.DeleteAddress)
+
+		r.With(handler.Idempotent).Post("/orders", handler.CreateOrder)
+		r.Get("/orders", handler.GetOrders)
+		r.Get("/orders/{id}", handler.GetOrder)
+		r.Get("/orders/{id}/invoice.pdf", handler.GetOrderInvoice)
+		r.Post("/orders/{id}/cancel", handler.CancelOrder)
+		r.With(handler.Idempotent).Post("/orders/{id}/payments", handler.PayOrder)
+		r.Get("/orders/{id}/payments", handler.GetPayments)
+	})
+
+	mux.Group(func(r chi.Router) {
+		r.Use(handler.RequireAuth, handler.RequireAdmin)
+
+		r.Post("/products", handler.CreateProduct)
+		r.Put("/products/{id}", handler.UpdateProduct)
+		r.Delete("/products/{id}", handler.DeleteProduct)
+	})
+
+	mux.Route("/admin", func(r chi.Router) {
+		r.Use(handler.RequireAuth, handler.RequireAdmin)
+
+		r.Post("/products/import", handler.ImportProducts)
+		r.Get("/products/export", handler.ExportProducts)
+		r.Get("/products/preview", handler.PreviewProducts)
+
+		r.Get("/products/trash", handler.GetDeletedProducts)
+		r.Delete("/products/trash", handler.EmptyProductTrash)
+		r.Post("/products/trash/{id}/restore", handler.RestoreProduct)
+		r.Delete("/products/trash/{id}", handler.PurgeProduct)
+
+		r.Get("/products/{id}/revisions", handler.GetProductRevisions)
+		r.Get("/products/{id}/revisions/diff", handler.GetProductRevisionDiff)
+		r.Post("/products/{id}/revisions/{version}/rollback", handler.RollbackProduct)
+
+		r.Get("/products/{id}/prices", handler.GetScheduledPrices)
+		r.Post("/products/{id}/prices", handler.CreateScheduledPrice)
+		r.Delete("/products/{id}/prices/{priceID}", handler.DeleteScheduledPrice)
+
+		r.Get("/products/{id}/stock", handler.GetStock)
+		r.Put("/products/{id}/stock/{warehouseID}", handler.SetStock)
+		r.Post("/products/{id}/stock/{warehouseID}/adjust", handler.AdjustStock)
+		r.Put("/products/{id}/reorder-threshold", handler.SetReorderThreshold)
+		r.Delete("/products/{id}/reorder-threshold", handler.DeleteReorderThreshold)
+
+		r.Get("/products/{id}/translations", handler.GetProductTranslations)
+		r.Put("/products/{id}/translations/{locale}", handler.SetProductTranslation)
+		r.Delete("/products/{id}/translations/{locale}", handler.DeleteProductTranslation)
+
+		r.Get("/orders/{id}/transitions", handler.GetOrderTransitions)
+		r.Post("/orders/{id}/transitions", handler.TransitionOrder)
+		r.Post("/orders/{id}/refunds", handler.RefundOrder)
+
+		r.Get("/warehouses", handler.GetWarehouses)
+		r.Post("/warehouses", handler.CreateWarehouse)
+		r.Put("/warehouses/{id}", handler.UpdateWarehouse)
+
+		r.Get("/coupons", handler.GetCoupons)
+		r.Post("/coupons", handler.CreateCoupon)
+		r.Get("/coupons/{id}", handler.GetCoupon)
+		r.Put("/coupons/{id}", handler.UpdateCoupon)
+		r.Delete("/coupons/{id}", handler.DeleteCoupon)
+
+		r.Get("/tax-rates", handler.GetTaxRates)
+		r.Post("/tax-rates", handler.CreateTaxRate)
+		r.Put("/tax-rates/{id}", handler.UpdateTaxRate)
+		r.Delete("/tax-rates/{id}", handler.DeleteTaxRate)
+
+		r.Get("/shipping-zones", handler.GetShippingZones)
+		r.Post("/shipping-zones", handler.CreateShippingZone)
+		r.Put("/shipping-zones/{id}", handler.UpdateShippingZone)
+		r.Delete("/shipping-zones/{id}", handler.DeleteShippingZone)
+		r.Post("/shipping-zones/{id}/methods", handler.CreateShippingMethod)
+		r.Put("/shipping-methods/{id}", handler.UpdateShippingMethod)
+		r.Delete("/shipping-methods/{id}", handler.DeleteShippingMethod)
+
+		r.Post("/product-types", handler.CreateProductType)
+
+		r.Post("/exchange-rates/import", handler.ImportExchangeRates)
+		r.Put("/exchange-rates/{currency}", handler.SetExchangeRate)
+		r.Delete("/exchange-rates/{currency}", handler.DeleteExchangeRate)
+
+		r.Get("/notifications", handler.GetOutboxMessages)
+
+		r.Get("/reviews", handler.GetReviewQueue)
+		r.Post("/reviews/{id}/approve", handler.ApproveReview)
+		r.Post("/reviews/{id}/reject", handler.RejectReview)
+	})
 
 	return mux
 }
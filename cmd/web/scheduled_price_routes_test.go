@@ -0,0 +1,180 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestCreateScheduledPrice(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.ScheduledPriceRequest
+		expectedCode int
+	}{
+		{
+			name:         "success",
+			path:         "/admin/products/1/prices",
+			body:         handler.ScheduledPriceRequest{Kind: "sale", PriceCents: 50, EndsAt: timePtr(now.Add(time.Hour))},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid kind",
+			path:         "/admin/products/1/prices",
+			body:         handler.ScheduledPriceRequest{Kind: "clearance", PriceCents: 50},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "negative price",
+			path:         "/admin/products/1/prices",
+			body:         handler.ScheduledPriceRequest{Kind: "sale", PriceCents: -1},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "ends before it starts",
+			path: "/admin/products/1/prices",
+			body: handler.ScheduledPriceRequest{
+				Kind:       "sale",
+				PriceCents: 50,
+				StartsAt:   timePtr(now.Add(time.Hour)),
+				EndsAt:     timePtr(now),
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown product",
+			path:         "/admin/products/9/prices",
+			body:         handler.ScheduledPriceRequest{Kind: "sale", PriceCents: 50},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+
+			if test.expectedCode == http.StatusCreated {
+				price := decodeJSON[handler.ScheduledPriceResponse](t, rec)
+				if !price.Active || price.Kind != "sale" || price.PriceCents != 50 {
+					t.Fatalf("unexpected scheduled price %+v", price)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduledPrices_ResolvedAtReadTime(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 1000}, {Name: "banana", PriceCents: 900}})
+	admin := loginAdmin(t, app)
+	now := time.Now()
+
+	for _, body := range []handler.ScheduledPriceRequest{
+		// A price increase that is already in effect.
+		{Kind: "regular", PriceCents: 1200, StartsAt: timePtr(now.Add(-2 * time.Hour))},
+		// A sale running now.
+		{Kind: "sale", PriceCents: 800, StartsAt: timePtr(now.Add(-time.Hour)), EndsAt: timePtr(now.Add(time.Hour))},
+		// A deeper sale starting tomorrow.
+		{Kind: "sale", PriceCents: 500, StartsAt: timePtr(now.Add(24 * time.Hour)), EndsAt: timePtr(now.Add(48 * time.Hour))},
+	} {
+		rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/prices", admin, body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	product := decodeJSON[handler.ProductResponse](t, rec)
+	if product.PriceCents != 800 || product.OriginalPriceCents != 1200 || !product.OnSale {
+		t.Fatalf("expected 800 on sale from 1200, got %+v", product)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products?minPrice=850", nil)
+	if items := decodeJSON[handler.GetProductsResponse](t, rec).Items; len(items) != 1 || items[0].Name != "banana" {
+		t.Fatalf("expected the price filter to use the sale price, got %+v", items)
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/2", nil)
+	product = decodeJSON[handler.ProductResponse](t, rec)
+	if product.PriceCents != 900 || product.OriginalPriceCents != 900 || product.OnSale {
+		t.Fatalf("expected banana at its base price, got %+v", product)
+	}
+
+	at := url.QueryEscape(now.Add(25 * time.Hour).Format(time.RFC3339))
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/preview?orderBy=price_cents&at="+at, admin, nil)
+	items := decodeJSON[handler.GetProductsResponse](t, rec).Items
+	if len(items) != 2 || items[0].Name != "apple" || items[0].PriceCents != 500 || items[0].OriginalPriceCents != 1200 {
+		t.Fatalf("expected tomorrow's sale in the preview, got %+v", items)
+	}
+
+	at = url.QueryEscape(now.Add(72 * time.Hour).Format(time.RFC3339))
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/preview?at="+at, admin, nil)
+	items = decodeJSON[handler.GetProductsResponse](t, rec).Items
+	if items[0].PriceCents != 1200 || items[0].OnSale {
+		t.Fatalf("expected the regular price once the sales end, got %+v", items[0])
+	}
+
+	user := registerAndLogin(t, app, "customer")
+	rec = executeRequestWithToken(t, app, http.MethodPost, "/orders", user, handler.CreateOrderRequest{
+		Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 2}},
+	})
+	if order := decodeJSON[handler.OrderResponse](t, rec); order.TotalCents != 1600 {
+		t.Fatalf("expected the order to be charged the sale price, got %d", order.TotalCents)
+	}
+}
+
+func TestPreviewProducts_InvalidTime(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := loginAdmin(t, app)
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/preview?at=tomorrow", token, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestDeleteScheduledPrice(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	token := loginAdmin(t, app)
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/prices", token, handler.ScheduledPriceRequest{Kind: "sale", PriceCents: 50})
+
+	rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/1/prices/1", token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/prices", token, nil)
+	if items := decodeJSON[map[string][]handler.ScheduledPriceResponse](t, rec)["items"]; len(items) != 0 {
+		t.Fatalf("expected no scheduled prices, got %d", len(items))
+	}
+
+	rec = executeRequest(t, app, http.MethodGet, "/products/1", nil)
+	if product := decodeJSON[handler.ProductResponse](t, rec); product.PriceCents != 100 {
+		t.Fatalf("expected the base price after deleting the sale, got %d", product.PriceCents)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/admin/products/1/prices/1", token, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
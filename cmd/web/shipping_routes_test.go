@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func createAddress(t *testing.T, app http.Handler, token string, address handler.AddressRequest) handler.AddressResponse {
+	t.Helper()
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/addresses", token, address)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return decodeJSON[handler.AddressResponse](t, rec)
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
+
+func uintPtr(n uint) *uint {
+	return &n
+}
+
+func TestAddresses(t *testing.T) {
+	app, _ := setupTestApp(t)
+	token := registerAndLogin(t, app, "customer")
+	other := registerAndLogin(t, app, "other")
+
+	home := handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{
+		Name: "Ann", Street: "1 Main St", City: "Montreal", Region: "qc", PostalCode: "H2X 1Y4", Country: "ca",
+	}}
+	address := createAddress(t, app, token, home)
+	if address.Country != "CA" || address.Region != "QC" {
+		t.Fatalf("unexpected address: %+v", address)
+	}
+
+	tests := []struct {
+		name    string
+		address handler.AddressRequest
+	}{
+		{name: "no street", address: handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{Name: "Ann", City: "Montreal", Country: "CA"}}},
+		{name: "bad country", address: handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{Name: "Ann", Street: "1 Main St", City: "Montreal", Country: "Canada"}}},
+		{name: "latitude alone", address: handler.AddressRequest{PostalAddressRequest: home.PostalAddressRequest, Latitude: new(float64)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/addresses", token, test.address)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+		})
+	}
+
+	path := fmt.Sprintf("/addresses/%d", address.ID)
+	if rec := executeRequestWithToken(t, app, http.MethodGet, path, other, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected another user's address to be 404, got %d", rec.Code)
+	}
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/addresses", other, nil)
+	if items := decodeJSON[map[string][]handler.AddressResponse](t, rec)["items"]; len(items) != 0 {
+		t.Fatalf("expected no addresses for another user, got %+v", items)
+	}
+
+	home.City = "Laval"
+	rec = executeRequestWithToken(t, app, http.MethodPut, path, token, home)
+	if updated := decodeJSON[handler.AddressResponse](t, rec); rec.Code != http.StatusOK || updated.City != "Laval" {
+		t.Fatalf("unexpected response %d: %+v", rec.Code, updated)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, path, other, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, path, token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodGet, path, token, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestShippingZones(t *testing.T) {
+	app, _ := setupTestApp(t)
+	admin := loginAdmin(t, app)
+
+	canada := handler.ShippingZoneRequest{Name: "Canada", Locations: []handler.ShippingLocationRequest{{Country: "ca"}}}
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones", admin, canada)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if zone := decodeJSON[handler.ShippingZoneResponse](t, rec); zone.Locations[0].Country != "CA" {
+		t.Fatalf("unexpected zone: %+v", zone)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones", admin, canada); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		body         any
+		expectedCode int
+	}{
+		{name: "zone without locations", path: "/admin/shipping-zones", body: handler.ShippingZoneRequest{Name: "Nowhere"}, expectedCode: http.StatusBadRequest},
+		{name: "unknown basis", path: "/admin/shipping-zones/1/methods", body: handler.ShippingMethodRequest{Name: "Post", Basis: "volume", Rates: []handler.ShippingRateRequest{{CostCents: 500}}}, expectedCode: http.StatusBadRequest},
+		{name: "no rates", path: "/admin/shipping-zones/1/methods", body: handler.ShippingMethodRequest{Name: "Post", Basis: "weight"}, expectedCode: http.StatusBadRequest},
+		{name: "limits out of order", path: "/admin/shipping-zones/1/methods", body: handler.ShippingMethodRequest{Name: "Post", Basis: "weight", Rates: []handler.ShippingRateRequest{{UpTo: int64Ptr(2000), CostCents: 500}, {UpTo: int64Ptr(1000), CostCents: 900}}}, expectedCode: http.StatusBadRequest},
+		{name: "unlimited rate before the last", path: "/admin/shipping-zones/1/methods", body: handler.ShippingMethodRequest{Name: "Post", Basis: "weight", Rates: []handler.ShippingRateRequest{{CostCents: 500}, {UpTo: int64Ptr(1000), CostCents: 900}}}, expectedCode: http.StatusBadRequest},
+		{name: "unknown zone", path: "/admin/shipping-zones/9/methods", body: handler.ShippingMethodRequest{Name: "Post", Basis: "weight", Rates: []handler.ShippingRateRequest{{CostCents: 500}}}, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPost, test.path, admin, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+
+	method := handler.ShippingMethodRequest{Name: "Post", Basis: "weight", Rates: []handler.ShippingRateRequest{{UpTo: int64Ptr(1000), CostCents: 500}, {CostCents: 900}}}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones/1/methods", admin, method); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	method.Rates = []handler.ShippingRateRequest{{CostCents: 700}}
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/admin/shipping-methods/1", admin, method)
+	if updated := decodeJSON[handler.ShippingMethodResponse](t, rec); rec.Code != http.StatusOK || len(updated.Rates) != 1 {
+		t.Fatalf("unexpected response %d: %+v", rec.Code, updated)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/shipping-zones", admin, nil)
+	zones := decodeJSON[map[string][]handler.ShippingZoneResponse](t, rec)["items"]
+	if len(zones) != 1 || len(zones[0].Methods) != 1 || zones[0].Methods[0].Rates[0].CostCents != 700 {
+		t.Fatalf("unexpected zones: %+v", zones)
+	}
+
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/shipping-zones/1", admin, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPut, "/admin/shipping-methods/1", admin, method); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the zone's methods to be deleted, got %d", rec.Code)
+	}
+}
+
+func TestCheckoutCart_Shipping(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 1000, WeightGrams: 400},
+		{Name: "anvil", PriceCents: 5000, WeightGrams: 20000},
+	})
+	admin := loginAdmin(t, app)
+
+	zones := []struct {
+		zone    handler.ShippingZoneRequest
+		methods []handler.ShippingMethodRequest
+	}{
+		{
+			zone: handler.ShippingZoneRequest{Name: "Canada", Locations: []handler.ShippingLocationRequest{{Country: "CA"}}},
+			methods: []handler.ShippingMethodRequest{
+				{Name: "Standard", Basis: "weight", Rates: []handler.ShippingRateRequest{{UpTo: int64Ptr(1000), CostCents: 500}, {UpTo: int64Ptr(10000), CostCents: 1500}}},
+				{Name: "Express", Basis: "price", Rates: []handler.ShippingRateRequest{{UpTo: int64Ptr(4999), CostCents: 2000}, {CostCents: 0}}},
+			},
+		},
+		{
+			zone: handler.ShippingZoneRequest{Name: "Quebec", Locations: []handler.ShippingLocationRequest{{Country: "CA", Region: "QC"}}},
+			methods: []handler.ShippingMethodRequest{
+				{Name: "Courier", Basis: "weight", Rates: []handler.ShippingRateRequest{{CostCents: 800}}},
+			},
+		},
+	}
+	for i, zone := range zones {
+		if rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/shipping-zones", admin, zone.zone); rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create shipping zone: %d", rec.Code)
+		}
+		for _, method := range zone.methods {
+			path := fmt.Sprintf("/admin/shipping-zones/%d/methods", i+1)
+			if rec := executeRequestWithToken(t, app, http.MethodPost, path, admin, method); rec.Code != http.StatusCreated {
+				t.Fatalf("failed to create shipping method: %d", rec.Code)
+			}
+		}
+	}
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "FREESHIP", Kind: "free_shipping"})
+
+	token := registerAndLogin(t, app, "customer")
+	ontario := createAddress(t, app, token, handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{
+		Name: "Ann", Street: "1 Bay St", City: "Toronto", Region: "ON", Country: "CA",
+	}})
+	quebec := createAddress(t, app, token, handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{
+		Name: "Ann", Street: "1 Main St", City: "Montreal", Region: "QC", Country: "CA",
+	}})
+	france := createAddress(t, app, token, handler.AddressRequest{PostalAddressRequest: handler.PostalAddressRequest{
+		Name: "Ann", Street: "1 Rue de Rivoli", City: "Paris", Country: "FR",
+	}})
+
+	quotesPath := fmt.Sprintf("/cart/shipping-methods?address_id=%d", ontario.ID)
+	if rec := executeRequestWithToken(t, app, http.MethodGet, quotesPath, token, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("expected an empty cart to be 409, got %d", rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/cart/items", token, handler.CartItemRequest{ProductID: 1, Quantity: 2})
+
+	quoteTests := []struct {
+		name    string
+		address uint
+		methods []string
+		costs   []int64
+	}{
+		{name: "country zone", address: ontario.ID, methods: []string{"Standard", "Express"}, costs: []int64{500, 2000}},
+		{name: "region zone wins", address: quebec.ID, methods: []string{"Courier"}, costs: []int64{800}},
+		{name: "no zone", address: france.ID},
+	}
+
+	for _, test := range quoteTests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodGet, fmt.Sprintf("/cart/shipping-methods?address_id=%d", test.address), token, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			quotes := decodeJSON[map[string][]handler.ShippingQuoteResponse](t, rec)["items"]
+			if len(quotes) != len(test.methods) {
+				t.Fatalf("expected %v, got %+v", test.methods, quotes)
+			}
+			for i, quote := range quotes {
+				if quote.Name != test.methods[i] || quote.CostCents != test.costs[i] {
+					t.Fatalf("expected %s for %d, got %+v", test.methods[i], test.costs[i], quote)
+				}
+			}
+		})
+	}
+
+	// Too heavy for Standard's rates, and too cheap for free Express.
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 2, Quantity: 1}}, AddressID: &ontario.ID, ShippingMethodID: uintPtr(1)}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	order.AddressID = nil
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, order); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without an address, got %d", rec.Code)
+	}
+
+	// Shipping isn't free just because no method was picked.
+	apples := []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}}
+	orderTests := []struct {
+		name         string
+		order        handler.CreateOrderRequest
+		expectedCode int
+	}{
+		{name: "no method", order: handler.CreateOrderRequest{Items: apples, AddressID: &ontario.ID}, expectedCode: http.StatusBadRequest},
+		{name: "no zone", order: handler.CreateOrderRequest{Items: apples, AddressID: &france.ID}, expectedCode: http.StatusConflict},
+		{
+			name:         "tax address elsewhere",
+			order:        handler.CreateOrderRequest{Items: apples, AddressID: &ontario.ID, ShippingMethodID: uintPtr(1), TaxAddress: &handler.TaxAddressRequest{Country: "CA", Region: "QC"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, test := range orderTests {
+		t.Run(test.name, func(t *testing.T) {
+			if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, test.order); rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", registerAndLogin(t, app, "other"), handler.CreateOrderRequest{Items: order.Items, AddressID: &ontario.ID}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected another user's address to be 400, got %d", rec.Code)
+	}
+	checkout := handler.CheckoutRequest{AddressID: &quebec.ID, ShippingMethodID: uintPtr(1)}
+	if rec := executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, checkout); rec.Code != http.StatusConflict {
+		t.Fatalf("expected a method from another zone to be 409, got %d", rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/cart/coupon", token, handler.CartCouponRequest{Code: "FREESHIP"})
+	checkout = handler.CheckoutRequest{AddressID: &ontario.ID, ShippingMethodID: uintPtr(1)}
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/cart/checkout", token, checkout)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	created := decodeJSON[handler.OrderResponse](t, rec)
+	if created.ShippingCents != 500 || created.DiscountCents != 500 || created.TotalCents != 2000 {
+		t.Fatalf("expected free shipping, got %+v", created)
+	}
+	if created.Shipping == nil || created.Shipping.Name != "Standard" || created.ShippingAddress == nil || created.ShippingAddress.City != "Toronto" {
+		t.Fatalf("unexpected shipping: %+v", created)
+	}
+
+	// The order keeps the address and method it was placed with.
+	executeRequestWithToken(t, app, http.MethodDelete, fmt.Sprintf("/addresses/%d", ontario.ID), token, nil)
+	executeRequestWithToken(t, app, http.MethodDelete, "/admin/shipping-methods/1", admin, nil)
+	rec = executeRequestWithToken(t, app, http.MethodGet, fmt.Sprintf("/orders/%d", created.ID), token, nil)
+	got := decodeJSON[handler.OrderResponse](t, rec)
+	if got.ShippingAddress == nil || got.ShippingAddress.City != "Toronto" || got.Shipping == nil || got.Shipping.MethodID != nil || got.Shipping.Name != "Standard" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/notification"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+// evaluateNotifications runs the background evaluator once and returns how
+// many messages it sent.
+func evaluateNotifications(t *testing.T, db *gorm.DB) int {
+	t.Helper()
+
+	sent, err := repository.NewRepository(db).EvaluateStockNotifications(notification.NewOutbox(db))
+	if err != nil {
+		t.Fatalf("failed to evaluate notifications: %v", err)
+	}
+	return sent
+}
+
+func TestSetReorderThreshold(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.ReorderThresholdRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/admin/products/1/reorder-threshold", body: handler.ReorderThresholdRequest{Threshold: 3}, expectedCode: http.StatusOK},
+		{name: "zero", path: "/admin/products/1/reorder-threshold", body: handler.ReorderThresholdRequest{Threshold: 0}, expectedCode: http.StatusBadRequest},
+		{name: "unknown product", path: "/admin/products/9/reorder-threshold", body: handler.ReorderThresholdRequest{Threshold: 3}, expectedCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPut, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestStockNotifications(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 5})
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/reorder-threshold", admin, handler.ReorderThresholdRequest{Threshold: 3})
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/products/1/stock", admin, nil)
+	if stock := decodeJSON[handler.ProductStockResponse](t, rec); stock.ReorderThreshold == nil || *stock.ReorderThreshold != 3 {
+		t.Fatalf("expected a reorder threshold of 3, got %v", stock.ReorderThreshold)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/products/1/stock-subscription", customer, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d when subscribing to an available product, got %d", http.StatusConflict, rec.Code)
+	}
+
+	if sent := evaluateNotifications(t, db); sent != 0 {
+		t.Fatalf("expected no messages while stock is above the threshold, got %d", sent)
+	}
+
+	order := handler.CreateOrderRequest{Items: []handler.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	if sent := evaluateNotifications(t, db); sent != 1 {
+		t.Fatalf("expected a low-stock alert, got %d messages", sent)
+	}
+	if sent := evaluateNotifications(t, db); sent != 0 {
+		t.Fatalf("expected the alert to be sent once, got %d more", sent)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/stock/1/adjust", admin, handler.AdjustStockRequest{Delta: -2})
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/products/1/stock-subscription", customer, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if sent := evaluateNotifications(t, db); sent != 0 {
+		t.Fatalf("expected no messages while out of stock, got %d", sent)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/stock/1/adjust", admin, handler.AdjustStockRequest{Delta: 10})
+	if sent := evaluateNotifications(t, db); sent != 1 {
+		t.Fatalf("expected a back-in-stock notice, got %d messages", sent)
+	}
+
+	// Stock recovered, so the next drop alerts again.
+	order.Items[0].Quantity = 9
+	executeRequestWithToken(t, app, http.MethodPost, "/orders", customer, order)
+	if sent := evaluateNotifications(t, db); sent != 1 {
+		t.Fatalf("expected a second low-stock alert, got %d messages", sent)
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/notifications", admin, nil)
+	items := decodeJSON[map[string][]handler.OutboxMessageResponse](t, rec)["items"]
+	expected := []struct{ kind, recipient string }{
+		{"low_stock", "ops"},
+		{"back_in_stock", "customer"},
+		{"low_stock", "ops"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d messages, got %+v", len(expected), items)
+	}
+	for i, message := range expected {
+		if items[i].Kind != message.kind || items[i].Recipient != message.recipient {
+			t.Fatalf("expected %s to %s at %d, got %+v", message.kind, message.recipient, i, items[i])
+		}
+	}
+}
+
+func TestUnsubscribeFromStock(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{{Name: "apple", PriceCents: 100}})
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+	admin := loginAdmin(t, app)
+	customer := registerAndLogin(t, app, "customer")
+	executeRequestWithToken(t, app, http.MethodPut, "/admin/products/1/stock/1", admin, handler.StockRequest{OnHand: 0})
+
+	rec := executeRequestWithToken(t, app, http.MethodDelete, "/products/1/stock-subscription", customer, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d without a subscription, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPut, "/products/1/stock-subscription", customer, nil)
+	rec = executeRequestWithToken(t, app, http.MethodDelete, "/products/1/stock-subscription", customer, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	executeRequestWithToken(t, app, http.MethodPost, "/admin/products/1/stock/1/adjust", admin, handler.AdjustStockRequest{Delta: 1})
+	if sent := evaluateNotifications(t, db); sent != 0 {
+		t.Fatalf("expected no messages after unsubscribing, got %d", sent)
+	}
+}
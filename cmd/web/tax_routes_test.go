@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+)
+
+func TestTaxRates(t *testing.T) {
+	app, _ := setupTestApp(t)
+	admin := loginAdmin(t, app)
+
+	rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/tax-rates", admin, handler.TaxRateRequest{Country: "ca", Name: "GST", Rate: "0.05"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if rate := decodeJSON[handler.TaxRateResponse](t, rec); rate.Country != "CA" || rate.Rate != "0.05" {
+		t.Fatalf("unexpected tax rate: %+v", rate)
+	}
+
+	tests := []struct {
+		name         string
+		body         handler.TaxRateRequest
+		expectedCode int
+	}{
+		{name: "same jurisdiction and category", body: handler.TaxRateRequest{Country: "CA", Name: "GST again", Rate: "0.06"}, expectedCode: http.StatusConflict},
+		{name: "bad country", body: handler.TaxRateRequest{Country: "CAN", Name: "GST", Rate: "0.05"}, expectedCode: http.StatusBadRequest},
+		{name: "no name", body: handler.TaxRateRequest{Country: "CA", Region: "QC", Rate: "0.05"}, expectedCode: http.StatusBadRequest},
+		{name: "rate as a percentage", body: handler.TaxRateRequest{Country: "CA", Region: "QC", Name: "QST", Rate: "9.975"}, expectedCode: http.StatusBadRequest},
+		{name: "negative rate", body: handler.TaxRateRequest{Country: "CA", Region: "QC", Name: "QST", Rate: "-0.1"}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/tax-rates", admin, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d", test.expectedCode, rec.Code)
+			}
+		})
+	}
+
+	rec = executeRequestWithToken(t, app, http.MethodPut, "/admin/tax-rates/1", admin, handler.TaxRateRequest{Country: "CA", Name: "GST", Rate: "0.06"})
+	if rate := decodeJSON[handler.TaxRateResponse](t, rec); rec.Code != http.StatusOK || rate.Rate != "0.06" {
+		t.Fatalf("unexpected response %d: %+v", rec.Code, rate)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/tax-rates/1", admin, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := executeRequestWithToken(t, app, http.MethodDelete, "/admin/tax-rates/1", admin, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestCreateOrder_Tax(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedProducts(t, db, []domain.Product{
+		{Name: "apple", PriceCents: 1000},
+		{Name: "bread", PriceCents: 500, TaxCategory: "food"},
+	})
+	admin := loginAdmin(t, app)
+	for _, rate := range []handler.TaxRateRequest{
+		{Country: "CA", Name: "GST", Rate: "0.05"},
+		{Country: "CA", Category: "food", Name: "GST (food)", Rate: "0"},
+		{Country: "CA", Region: "QC", Name: "QST", Rate: "0.09975"},
+		{Country: "CA", Region: "QC", Category: "food", Name: "QST (food)", Rate: "0"},
+	} {
+		if rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/tax-rates", admin, rate); rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create tax rate: %d", rec.Code)
+		}
+	}
+	createCoupon(t, app, admin, handler.CouponRequest{Code: "TENOFF", Kind: "percentage", PercentOff: 10})
+	token := registerAndLogin(t, app, "customer")
+
+	items := []handler.OrderItemRequest{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 2}}
+	tests := []struct {
+		name       string
+		address    *handler.TaxAddressRequest
+		coupon     string
+		taxCents   int64
+		totalCents int64
+		taxLines   int
+	}{
+		{name: "country and region", address: &handler.TaxAddressRequest{Country: "ca", Region: "qc"}, taxCents: 150, totalCents: 2150, taxLines: 4},
+		{name: "region without rates", address: &handler.TaxAddressRequest{Country: "CA", Region: "ON"}, taxCents: 50, totalCents: 2050, taxLines: 2},
+		{name: "country without rates", address: &handler.TaxAddressRequest{Country: "US", Region: "CA"}, totalCents: 2000},
+		// The 200 off is shared between the apple and the bread, 100 each.
+		{name: "after a discount", address: &handler.TaxAddressRequest{Country: "CA", Region: "QC"}, coupon: "TENOFF", taxCents: 135, totalCents: 1935, taxLines: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := handler.CreateOrderRequest{Items: items, TaxAddress: test.address, CouponCode: test.coupon}
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, body)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d", rec.Code)
+			}
+			order := decodeJSON[handler.OrderResponse](t, rec)
+			if order.TaxCents != test.taxCents || order.TotalCents != test.totalCents || len(order.Taxes) != test.taxLines {
+				t.Fatalf("expected %d tax and a total of %d, got %+v", test.taxCents, test.totalCents, order)
+			}
+		})
+	}
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/orders/1", token, nil)
+	order := decodeJSON[handler.OrderResponse](t, rec)
+	if qst := order.Taxes[1]; qst.Name != "QST" || qst.Region != "QC" || qst.TaxableCents != 1000 || qst.TaxCents != 100 {
+		t.Fatalf("unexpected tax line: %+v", qst)
+	}
+
+	// Orders can't go untaxed by leaving the address out.
+	for _, address := range []*handler.TaxAddressRequest{nil, {Country: "Canada"}} {
+		body := handler.CreateOrderRequest{Items: items, TaxAddress: address}
+		if rec := executeRequestWithToken(t, app, http.MethodPost, "/orders", token, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for tax address %+v, got %d", address, rec.Code)
+		}
+	}
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
 	"github.com/Hiroki111/go-backend-example/internal/repository"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -38,6 +40,7 @@ func setupTestApp(t *testing.T) (http.Handler, *gorm.DB) {
 	}
 
 	handler := handler.NewHandler(repo)
+	handler.SetPaymentGateway(payment.NewMock("", []byte(testWebhookSecret)))
 	return routes(handler), db
 }
 
@@ -49,6 +52,17 @@ func executeRequest(
 ) *httptest.ResponseRecorder {
 	t.Helper()
 
+	return executeRequestWithToken(t, app, method, path, "", body)
+}
+
+func executeRequestWithToken(
+	t *testing.T,
+	app http.Handler,
+	method, path, token string,
+	body any,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
 	var buf bytes.Buffer
 	if body != nil {
 		if err := json.NewEncoder(&buf).Encode(body); err != nil {
@@ -56,11 +70,73 @@ func executeRequest(
 		}
 	}
 
-	req := httptest.NewRequest(method, path, &buf)
-	req.Header.Set("Content-Type", "application/json")
+	return executeRawRequest(t, app, method, path, token, "application/json", &buf)
+}
+
+func executeRawRequest(
+	t *testing.T,
+	app http.Handler,
+	method, path, token, contentType string,
+	body io.Reader,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	rec := httptest.NewRecorder()
 	app.ServeHTTP(rec, req)
 
 	return rec
 }
+
+func loginUser(t *testing.T, app http.Handler, userName, password string) string {
+	t.Helper()
+
+	rec := executeRequest(t, app, http.MethodPost, "/login-user", handler.LoginUserRequest{
+		UserName: userName,
+		Password: password,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login as %s failed with %d", userName, rec.Code)
+	}
+
+	var resp handler.LoginUserResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid json response")
+	}
+	return resp.AccessToken
+}
+
+func loginAdmin(t *testing.T, app http.Handler) string {
+	t.Helper()
+
+	return loginUser(t, app, "admin", "password")
+}
+
+// registerAndLogin creates a regular (non-admin) user and returns its token.
+func registerAndLogin(t *testing.T, app http.Handler, userName string) string {
+	t.Helper()
+
+	rec := executeRequest(t, app, http.MethodPost, "/register-user", handler.RegisterUserRequest{
+		UserName: userName,
+		Password: "password",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register %s failed with %d", userName, rec.Code)
+	}
+	return loginUser(t, app, userName, "password")
+}
+
+func decodeJSON[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("invalid json response: %v", err)
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/handler"
+	"gorm.io/gorm"
+)
+
+func seedWarehouses(t *testing.T, db *gorm.DB, warehouses []domain.Warehouse) {
+	t.Helper()
+
+	for _, warehouse := range warehouses {
+		w := warehouse
+		if result := db.Create(&w); result.Error != nil {
+			t.Fatal(result.Error)
+		}
+	}
+}
+
+func TestCreateWarehouse(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         handler.WarehouseRequest
+		expectedCode int
+	}{
+		{
+			name:         "success",
+			body:         handler.WarehouseRequest{Name: "Rotterdam", Street: "Havenweg 1", City: "Rotterdam", PostalCode: "3011", Country: "nl", Latitude: 51.92, Longitude: 4.48},
+			expectedCode: http.StatusCreated,
+		},
+		{name: "duplicate name", body: handler.WarehouseRequest{Name: "main"}, expectedCode: http.StatusConflict},
+		{name: "missing name", body: handler.WarehouseRequest{Name: " "}, expectedCode: http.StatusBadRequest},
+		{name: "invalid country", body: handler.WarehouseRequest{Name: "Leeds", Country: "GBR"}, expectedCode: http.StatusBadRequest},
+		{name: "invalid coordinates", body: handler.WarehouseRequest{Name: "Leeds", Longitude: 200}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPost, "/admin/warehouses", token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusCreated {
+				if warehouse := decodeJSON[handler.WarehouseResponse](t, rec); warehouse.Country != "NL" || warehouse.City != "Rotterdam" {
+					t.Fatalf("unexpected warehouse %+v", warehouse)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateWarehouse(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		body         handler.WarehouseRequest
+		expectedCode int
+	}{
+		{name: "success", path: "/admin/warehouses/1", body: handler.WarehouseRequest{Name: "central", Priority: 5}, expectedCode: http.StatusOK},
+		{name: "name taken", path: "/admin/warehouses/1", body: handler.WarehouseRequest{Name: "overflow"}, expectedCode: http.StatusConflict},
+		{name: "unknown warehouse", path: "/admin/warehouses/9", body: handler.WarehouseRequest{Name: "central"}, expectedCode: http.StatusNotFound},
+		{name: "invalid id", path: "/admin/warehouses/abc", body: handler.WarehouseRequest{Name: "central"}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, db := setupTestApp(t)
+			seedWarehouses(t, db, []domain.Warehouse{{Name: "main"}, {Name: "overflow"}})
+			token := loginAdmin(t, app)
+
+			rec := executeRequestWithToken(t, app, http.MethodPut, test.path, token, test.body)
+			if rec.Code != test.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", test.expectedCode, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK {
+				if warehouse := decodeJSON[handler.WarehouseResponse](t, rec); warehouse.Name != "central" || warehouse.Priority != 5 {
+					t.Fatalf("unexpected warehouse %+v", warehouse)
+				}
+			}
+		})
+	}
+}
+
+func TestGetWarehouses_OrderedByPriority(t *testing.T) {
+	app, db := setupTestApp(t)
+	seedWarehouses(t, db, []domain.Warehouse{{Name: "backup", Priority: 2}, {Name: "main", Priority: 1}})
+	token := loginAdmin(t, app)
+
+	rec := executeRequestWithToken(t, app, http.MethodGet, "/admin/warehouses", token, nil)
+	items := decodeJSON[map[string][]handler.WarehouseResponse](t, rec)["items"]
+	if len(items) != 2 || items[0].Name != "main" || items[1].Name != "backup" {
+		t.Fatalf("expected main then backup, got %+v", items)
+	}
+
+	customer := registerAndLogin(t, app, "customer")
+	rec = executeRequestWithToken(t, app, http.MethodGet, "/admin/warehouses", customer, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected %d for non-admins, got %d", http.StatusForbidden, rec.Code)
+	}
+}
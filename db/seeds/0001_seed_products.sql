@@ -1,26 +1,26 @@
 -- Local development seed: products
 -- Lower ID => older created_at
 
-INSERT INTO products (name, price_cents, created_at, updated_at)
+INSERT INTO products (name, slug, price_cents, created_at, updated_at)
 VALUES
-  ('Toyota Prius',         2800000, NOW() - INTERVAL '20 days', NOW() - INTERVAL '20 days'),
-  ('Honda Civic',          2600000, NOW() - INTERVAL '19 days', NOW() - INTERVAL '19 days'),
-  ('Tesla Model 3',        4200000, NOW() - INTERVAL '18 days', NOW() - INTERVAL '18 days'),
-  ('BMW 3 Series',         5100000, NOW() - INTERVAL '17 days', NOW() - INTERVAL '17 days'),
-  ('Audi A4',              5000000, NOW() - INTERVAL '16 days', NOW() - INTERVAL '16 days'),
-  ('Mercedes-Benz C-Class',5300000, NOW() - INTERVAL '15 days', NOW() - INTERVAL '15 days'),
-  ('Volkswagen Golf',      2400000, NOW() - INTERVAL '14 days', NOW() - INTERVAL '14 days'),
-  ('Ford Focus',           2300000, NOW() - INTERVAL '13 days', NOW() - INTERVAL '13 days'),
-  ('Hyundai Elantra',      2200000, NOW() - INTERVAL '12 days', NOW() - INTERVAL '12 days'),
-  ('Kia Forte',            2100000, NOW() - INTERVAL '11 days', NOW() - INTERVAL '11 days'),
-  ('Mazda 3',              2500000, NOW() - INTERVAL '10 days', NOW() - INTERVAL '10 days'),
-  ('Subaru Impreza',       2450000, NOW() - INTERVAL '9 days',  NOW() - INTERVAL '9 days'),
-  ('Nissan Altima',        2700000, NOW() - INTERVAL '8 days',  NOW() - INTERVAL '8 days'),
-  ('Chevrolet Malibu',     2650000, NOW() - INTERVAL '7 days',  NOW() - INTERVAL '7 days'),
-  ('Peugeot 308',          2350000, NOW() - INTERVAL '6 days',  NOW() - INTERVAL '6 days'),
-  ('Renault Megane',       2300000, NOW() - INTERVAL '5 days',  NOW() - INTERVAL '5 days'),
-  ('Skoda Octavia',        2550000, NOW() - INTERVAL '4 days',  NOW() - INTERVAL '4 days'),
-  ('Volvo S60',            4800000, NOW() - INTERVAL '3 days',  NOW() - INTERVAL '3 days'),
-  ('Lexus IS',             5200000, NOW() - INTERVAL '2 days',  NOW() - INTERVAL '2 days'),
-  ('Porsche 911',         12500000, NOW() - INTERVAL '1 day',   NOW() - INTERVAL '1 day')
-ON CONFLICT (name) DO NOTHING;
+  ('Toyota Prius',         'toyota-prius',          2800000, NOW() - INTERVAL '20 days', NOW() - INTERVAL '20 days'),
+  ('Honda Civic',          'honda-civic',           2600000, NOW() - INTERVAL '19 days', NOW() - INTERVAL '19 days'),
+  ('Tesla Model 3',        'tesla-model-3',         4200000, NOW() - INTERVAL '18 days', NOW() - INTERVAL '18 days'),
+  ('BMW 3 Series',         'bmw-3-series',          5100000, NOW() - INTERVAL '17 days', NOW() - INTERVAL '17 days'),
+  ('Audi A4',              'audi-a4',               5000000, NOW() - INTERVAL '16 days', NOW() - INTERVAL '16 days'),
+  ('Mercedes-Benz C-Class','mercedes-benz-c-class', 5300000, NOW() - INTERVAL '15 days', NOW() - INTERVAL '15 days'),
+  ('Volkswagen Golf',      'volkswagen-golf',       2400000, NOW() - INTERVAL '14 days', NOW() - INTERVAL '14 days'),
+  ('Ford Focus',           'ford-focus',            2300000, NOW() - INTERVAL '13 days', NOW() - INTERVAL '13 days'),
+  ('Hyundai Elantra',      'hyundai-elantra',       2200000, NOW() - INTERVAL '12 days', NOW() - INTERVAL '12 days'),
+  ('Kia Forte',            'kia-forte',             2100000, NOW() - INTERVAL '11 days', NOW() - INTERVAL '11 days'),
+  ('Mazda 3',              'mazda-3',               2500000, NOW() - INTERVAL '10 days', NOW() - INTERVAL '10 days'),
+  ('Subaru Impreza',       'subaru-impreza',        2450000, NOW() - INTERVAL '9 days',  NOW() - INTERVAL '9 days'),
+  ('Nissan Altima',        'nissan-altima',         2700000, NOW() - INTERVAL '8 days',  NOW() - INTERVAL '8 days'),
+  ('Chevrolet Malibu',     'chevrolet-malibu',      2650000, NOW() - INTERVAL '7 days',  NOW() - INTERVAL '7 days'),
+  ('Peugeot 308',          'peugeot-308',           2350000, NOW() - INTERVAL '6 days',  NOW() - INTERVAL '6 days'),
+  ('Renault Megane',       'renault-megane',        2300000, NOW() - INTERVAL '5 days',  NOW() - INTERVAL '5 days'),
+  ('Skoda Octavia',        'skoda-octavia',         2550000, NOW() - INTERVAL '4 days',  NOW() - INTERVAL '4 days'),
+  ('Volvo S60',            'volvo-s60',             4800000, NOW() - INTERVAL '3 days',  NOW() - INTERVAL '3 days'),
+  ('Lexus IS',             'lexus-is',              5200000, NOW() - INTERVAL '2 days',  NOW() - INTERVAL '2 days'),
+  ('Porsche 911',         'porsche-911',           12500000, NOW() - INTERVAL '1 day',   NOW() - INTERVAL '1 day')
+ON CONFLICT (name) WHERE deleted_at IS NULL DO NOTHING;
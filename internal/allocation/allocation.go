@@ -0,0 +1,135 @@
+// Package allocation decides which warehouses fill an order line.
+package allocation
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Location is a point on the globe in decimal degrees.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Stock is what one warehouse can contribute to a line.
+type Stock struct {
+	WarehouseID uint
+	Available   int
+	// Priority orders warehouses for the priority strategy, lowest first.
+	Priority int
+	Location Location
+}
+
+// Allocation takes Quantity units from a warehouse.
+type Allocation struct {
+	WarehouseID uint
+	Quantity    int
+}
+
+// Strategy splits quantity across the given stock. It returns false when
+// the stock can't cover the quantity. destination may be nil when the
+// order's destination is unknown.
+type Strategy interface {
+	Allocate(quantity int, stock []Stock, destination *Location) ([]Allocation, bool)
+}
+
+const (
+	StrategyPriority     = "priority"
+	StrategyClosest      = "closest"
+	StrategyFewestSplits = "fewest_splits"
+)
+
+// New returns the strategy with the given name.
+func New(name string) (Strategy, error) {
+	switch name {
+	case StrategyPriority:
+		return Priority{}, nil
+	case StrategyClosest:
+		return Closest{}, nil
+	case StrategyFewestSplits:
+		return FewestSplits{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy %q", name)
+}
+
+// Priority takes as much as possible from each warehouse in priority
+// order.
+type Priority struct{}
+
+func (Priority) Allocate(quantity int, stock []Stock, _ *Location) ([]Allocation, bool) {
+	return fill(quantity, byPriority(stock))
+}
+
+// Closest takes as much as possible from the nearest warehouse first. It
+// falls back to priority order when the destination is unknown.
+type Closest struct{}
+
+func (Closest) Allocate(quantity int, stock []Stock, destination *Location) ([]Allocation, bool) {
+	ordered := byPriority(stock)
+	if destination != nil {
+		slices.SortStableFunc(ordered, func(a, b Stock) int {
+			return cmp.Compare(distanceKm(a.Location, *destination), distanceKm(b.Location, *destination))
+		})
+	}
+	return fill(quantity, ordered)
+}
+
+// FewestSplits ships from a single warehouse when any can cover the whole
+// line, preferring the higher priority one. Otherwise it takes from the
+// warehouses with the most stock first, which uses as few as possible.
+type FewestSplits struct{}
+
+func (FewestSplits) Allocate(quantity int, stock []Stock, _ *Location) ([]Allocation, bool) {
+	ordered := byPriority(stock)
+	for _, s := range ordered {
+		if s.Available >= quantity {
+			return []Allocation{{WarehouseID: s.WarehouseID, Quantity: quantity}}, true
+		}
+	}
+
+	slices.SortStableFunc(ordered, func(a, b Stock) int {
+		return cmp.Compare(b.Available, a.Available)
+	})
+	return fill(quantity, ordered)
+}
+
+func byPriority(stock []Stock) []Stock {
+	ordered := slices.Clone(stock)
+	slices.SortStableFunc(ordered, func(a, b Stock) int {
+		return cmp.Or(cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.WarehouseID, b.WarehouseID))
+	})
+	return ordered
+}
+
+// fill takes stock greedily in the given order.
+func fill(quantity int, ordered []Stock) ([]Allocation, bool) {
+	var allocations []Allocation
+	for _, s := range ordered {
+		if quantity == 0 {
+			break
+		}
+		take := min(quantity, s.Available)
+		if take <= 0 {
+			continue
+		}
+		allocations = append(allocations, Allocation{WarehouseID: s.WarehouseID, Quantity: take})
+		quantity -= take
+	}
+	return allocations, quantity == 0
+}
+
+const earthRadiusKm = 6371
+
+// distanceKm is the great-circle distance between two locations.
+func distanceKm(a, b Location) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
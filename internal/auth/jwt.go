@@ -44,13 +44,14 @@ func ParseJWTToken(token string) (uint, error) {
 	_, err = jwt.ParseWithClaims(token, claims,
 		func(token *jwt.Token) (interface{}, error) {
 			return secretKey, nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return 0, err
 	}
-	userID, ok := claims["user_id"].(uint)
-	if !ok {
+	// JSON numbers are decoded as float64
+	userID, ok := claims["user_id"].(float64)
+	if !ok || userID <= 0 {
 		return 0, errors.New("invalid token")
 	}
-	return userID, nil
+	return uint(userID), nil
 }
@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// ParseFormat accepts either a short format name or a media type.
+func ParseFormat(value string) (Format, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if i := strings.Index(value, ";"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+
+	switch value {
+	case "csv", "text/csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// Record is a single product row read from an import file.
+// Line is the 1-based line number in the source file. Category is nil when
+// the file doesn't provide one, leaving an existing product's category as is.
+type Record struct {
+	Line       int
+	SKU        string
+	Name       string
+	Category   *string
+	PriceCents int64
+}
+
+type RecordError struct {
+	Line    int
+	Message string
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Decode reads every record from r. Rows that cannot be parsed or fail
+// validation are reported as RecordErrors instead of aborting the decode;
+// the returned error is only set when the input as a whole is unreadable.
+func Decode(format Format, r io.Reader) ([]Record, []RecordError, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+	return nil, nil, ErrUnsupportedFormat
+}
+
+func decodeCSV(r io.Reader) ([]Record, []RecordError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price_cents"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var records []Record
+	var recordErrors []RecordError
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				recordErrors = append(recordErrors, RecordError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		record := Record{Line: line, SKU: field("sku"), Name: field("name")}
+		if _, ok := columns["category"]; ok {
+			category := field("category")
+			record.Category = &category
+		}
+		price, err := strconv.ParseInt(field("price_cents"), 10, 64)
+		if err != nil {
+			recordErrors = append(recordErrors, RecordError{Line: line, Message: "invalid price_cents"})
+			continue
+		}
+		record.PriceCents = price
+
+		if err := validate(record); err != nil {
+			recordErrors = append(recordErrors, *err)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, recordErrors, nil
+}
+
+type ndjsonRecord struct {
+	SKU        string  `json:"sku"`
+	Name       string  `json:"name"`
+	Category   *string `json:"category"`
+	PriceCents *int64  `json:"price_cents"`
+}
+
+func decodeNDJSON(r io.Reader) ([]Record, []RecordError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var records []Record
+	var recordErrors []RecordError
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var data ndjsonRecord
+		if err := json.Unmarshal(raw, &data); err != nil {
+			recordErrors = append(recordErrors, RecordError{Line: line, Message: "invalid json"})
+			continue
+		}
+		if data.PriceCents == nil {
+			recordErrors = append(recordErrors, RecordError{Line: line, Message: "price_cents is required"})
+			continue
+		}
+
+		record := Record{
+			Line:       line,
+			SKU:        strings.TrimSpace(data.SKU),
+			Name:       strings.TrimSpace(data.Name),
+			PriceCents: *data.PriceCents,
+		}
+		if data.Category != nil {
+			category := strings.TrimSpace(*data.Category)
+			record.Category = &category
+		}
+		if err := validate(record); err != nil {
+			recordErrors = append(recordErrors, *err)
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return records, recordErrors, nil
+}
+
+func validate(record Record) *RecordError {
+	if record.Name == "" {
+		return &RecordError{Line: record.Line, Message: "name is required"}
+	}
+	if record.PriceCents < 0 {
+		return &RecordError{Line: record.Line, Message: "price_cents must not be negative"}
+	}
+	return nil
+}
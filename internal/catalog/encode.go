@@ -0,0 +1,77 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+)
+
+var csvColumns = []string{"sku", "name", "price_cents", "category"}
+
+// Encoder writes products one at a time so a full catalog export never
+// has to be held in memory. The output can be fed back into Decode.
+type Encoder interface {
+	Encode(product domain.Product) error
+	Flush() error
+}
+
+func NewEncoder(format Format, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{writer: writer}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) Encode(product domain.Product) error {
+	return e.writer.Write([]string{
+		skuOf(product),
+		product.Name,
+		strconv.FormatInt(product.PriceCents, 10),
+		product.Category,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(product domain.Product) error {
+	price := product.PriceCents
+	category := product.Category
+	return e.encoder.Encode(ndjsonRecord{
+		SKU:        skuOf(product),
+		Name:       product.Name,
+		Category:   &category,
+		PriceCents: &price,
+	})
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+func skuOf(product domain.Product) string {
+	if product.SKU == nil {
+		return ""
+	}
+	return *product.SKU
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"fmt"
+	"os"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// NewPostgresDB connects using the DB_* environment variables.
+func NewPostgresDB() (*gorm.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		getEnv("DB_HOST"),
+		getEnv("DB_USER"),
+		getEnv("DB_PASSWORD"),
+		getEnv("DB_NAME"),
+		getEnv("DB_PORT"),
+		getEnv("DB_SSLMODE"),
+		getEnv("DB_TIMEZONE"),
+	)
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+}
+
+func getEnv(key string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	panic(fmt.Sprintf("Env variable %s not found", key))
+}
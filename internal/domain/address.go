@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// PostalAddress is where a parcel is delivered. Country is a two-letter
+// code.
+type PostalAddress struct {
+	Name       string `gorm:"not null;default:''"`
+	Street     string `gorm:"not null;default:''"`
+	City       string `gorm:"not null;default:''"`
+	Region     string `gorm:"not null;default:''"`
+	PostalCode string `gorm:"not null;default:''"`
+	Country    string `gorm:"size:2;not null;default:''"`
+}
+
+// Address is an entry in a user's address book. Latitude and Longitude,
+// when known, let stock be allocated from the closest warehouses.
+type Address struct {
+	ID            uint `gorm:"primarykey"`
+	UserID        uint `gorm:"not null;index"`
+	PostalAddress `gorm:"embedded"`
+	Latitude      *float64
+	Longitude     *float64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Cart holds the products a user means to order. It is kept on the server
+// so it follows the user across devices. A guest cart has no user and is
+// identified by its Token instead, until it is merged into the user's cart
+// on login. CouponID is the coupon applied to the cart, if any.
+type Cart struct {
+	ID        uint       `gorm:"primarykey"`
+	UserID    *uint      `gorm:"uniqueIndex"`
+	Token     *string    `gorm:"uniqueIndex"`
+	CouponID  *uint      `gorm:"index"`
+	Items     []CartItem `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CartItem.UnitPriceCents is the product's price in the base currency when
+// the item was last added or changed, so the cart can point out prices
+// that have changed since.
+type CartItem struct {
+	ID             uint  `gorm:"primarykey"`
+	CartID         uint  `gorm:"not null;uniqueIndex:idx_cart_items_product,priority:1"`
+	ProductID      uint  `gorm:"not null;uniqueIndex:idx_cart_items_product,priority:2"`
+	Quantity       int   `gorm:"not null"`
+	UnitPriceCents int64 `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// Coupon is a code that gives a discount under the rules of its Kind, one
+// of the promotion kinds. Amounts are in the base currency. MaxUses and
+// MaxUsesPerUser limit how many orders can use it, when set, and StartsAt
+// and EndsAt bound when it can be used.
+type Coupon struct {
+	ID               uint   `gorm:"primarykey"`
+	Code             string `gorm:"not null;uniqueIndex"`
+	Description      string `gorm:"not null;default:''"`
+	Kind             string `gorm:"not null"`
+	PercentOff       int    `gorm:"not null;default:0"`
+	AmountOffCents   int64  `gorm:"not null;default:0"`
+	BuyQuantity      int    `gorm:"not null;default:0"`
+	GetQuantity      int    `gorm:"not null;default:0"`
+	ProductID        *uint  `gorm:"index"`
+	MinSubtotalCents int64  `gorm:"not null;default:0"`
+	MaxUses          *int
+	MaxUsesPerUser   *int
+	StartsAt         *time.Time
+	EndsAt           *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+
+	// TimesUsed counts the orders that used the coupon, when it is
+	// selected.
+	TimesUsed int64 `gorm:"->;-:migration"`
+}
+
+// CouponRedemption records an order that used a coupon, for its usage
+// limits. It is removed if the order is cancelled.
+type CouponRedemption struct {
+	ID        uint `gorm:"primarykey"`
+	CouponID  uint `gorm:"not null;index"`
+	UserID    uint `gorm:"not null;index"`
+	OrderID   uint `gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// OrderDiscount is a discount line on an order, copied from the coupon's
+// rules when the order was placed. AmountCents is in the order's currency.
+type OrderDiscount struct {
+	ID          uint   `gorm:"primarykey"`
+	OrderID     uint   `gorm:"not null;index"`
+	CouponID    *uint  `gorm:"index"`
+	Code        string `gorm:"not null"`
+	Kind        string `gorm:"not null"`
+	ProductID   *uint
+	Description string `gorm:"not null"`
+	AmountCents int64  `gorm:"not null"`
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// is worth. Rate is a decimal string so it is stored exactly.
+type ExchangeRate struct {
+	Currency  string `gorm:"primaryKey;size:3"`
+	Rate      string `gorm:"not null"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// IdempotencyKey records a request made with an Idempotency-Key header so
+// that retries get the same response. Scope is who made the request and
+// where to; keys are only unique within it. Fingerprint is a hash of the
+// request. StatusCode is zero while the first request is still being
+// handled.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	Scope       string `gorm:"not null;uniqueIndex:idx_idempotency_keys_key,priority:1"`
+	Key         string `gorm:"not null;uniqueIndex:idx_idempotency_keys_key,priority:2"`
+	Fingerprint string `gorm:"not null"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	ContentType string `gorm:"not null;default:''"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+}
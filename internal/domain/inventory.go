@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// StockLevel is a product's stock in one warehouse. Products without any
+// stock level are not tracked and can always be ordered. Reserved counts
+// units held by active reservations, so OnHand - Reserved is what can
+// still be sold.
+type StockLevel struct {
+	ID          uint      `gorm:"primarykey"`
+	ProductID   uint      `gorm:"not null;uniqueIndex:idx_stock_levels_warehouse,priority:1"`
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_stock_levels_warehouse,priority:2"`
+	Warehouse   Warehouse `gorm:"constraint:OnDelete:RESTRICT"`
+	OnHand      int       `gorm:"not null;default:0"`
+	Reserved    int       `gorm:"not null;default:0"`
+	UpdatedAt   time.Time
+}
+
+func (s StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// StockReservation holds stock for an order between checkout and payment.
+// It ends exactly once: committed when the stock leaves the warehouse,
+// released when the order is cancelled, or expired after ExpiresAt.
+type StockReservation struct {
+	ID        uint                   `gorm:"primarykey"`
+	OrderID   uint                   `gorm:"not null;index"`
+	Status    ReservationStatus      `gorm:"not null;index"`
+	ExpiresAt time.Time              `gorm:"not null;index"`
+	Items     []StockReservationItem `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type StockReservationItem struct {
+	ID                 uint `gorm:"primarykey"`
+	StockReservationID uint `gorm:"not null;index"`
+	ProductID          uint `gorm:"not null"`
+	WarehouseID        uint `gorm:"not null"`
+	Quantity           int  `gorm:"not null"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Seller is who invoices are issued by. Address holds one line per entry.
+type Seller struct {
+	Name    string
+	Address []string
+	TaxID   string
+	Email   string
+}
+
+// Invoice is issued once for an order, when it is paid. Number is unique
+// and numbers run without gaps within each year. The seller and the
+// customer are copied onto it, so later changes to either don't rewrite
+// it; multi-line fields hold one line per row.
+type Invoice struct {
+	ID            uint      `gorm:"primarykey"`
+	OrderID       uint      `gorm:"not null;uniqueIndex"`
+	Number        string    `gorm:"not null;uniqueIndex"`
+	Year          int       `gorm:"not null;uniqueIndex:idx_invoices_sequence,priority:1"`
+	Sequence      int       `gorm:"not null;uniqueIndex:idx_invoices_sequence,priority:2"`
+	IssuedAt      time.Time `gorm:"not null"`
+	SellerName    string    `gorm:"not null;default:''"`
+	SellerAddress string    `gorm:"not null;default:''"`
+	SellerTaxID   string    `gorm:"not null;default:''"`
+	SellerEmail   string    `gorm:"not null;default:''"`
+	BillTo        string    `gorm:"not null;default:''"`
+}
+
+// InvoiceSequence is the last invoice number used in a year.
+type InvoiceSequence struct {
+	Year int `gorm:"primaryKey;autoIncrement:false"`
+	Last int `gorm:"not null;default:0"`
+}
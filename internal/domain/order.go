@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OrderStatus string
+
+const (
+	OrderPending         OrderStatus = "pending"
+	OrderAwaitingPayment OrderStatus = "awaiting_payment"
+	OrderPaymentFailed   OrderStatus = "payment_failed"
+	OrderPaid            OrderStatus = "paid"
+	OrderOnHold          OrderStatus = "on_hold"
+	OrderFulfilled       OrderStatus = "fulfilled"
+	OrderShipped         OrderStatus = "shipped"
+	OrderDelivered       OrderStatus = "delivered"
+	OrderCancelled       OrderStatus = "cancelled"
+	OrderRefunded        OrderStatus = "refunded"
+)
+
+// PaidOrderStatuses are the statuses of orders that have been paid for
+// and not refunded.
+var PaidOrderStatuses = []OrderStatus{OrderPaid, OrderFulfilled, OrderShipped, OrderDelivered}
+
+// orderTransitions lists the statuses an order can move to from each
+// status. Cancelled and refunded orders are final; an order whose payment
+// failed can be paid again. An order paid for after its stock ran out is on
+// hold until stock is found for it or it is refunded.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:         {OrderAwaitingPayment, OrderCancelled},
+	OrderAwaitingPayment: {OrderPaid, OrderOnHold, OrderPaymentFailed, OrderCancelled},
+	OrderPaymentFailed:   {OrderAwaitingPayment, OrderCancelled},
+	OrderPaid:            {OrderFulfilled, OrderRefunded},
+	OrderOnHold:          {OrderPaid, OrderRefunded},
+	OrderFulfilled:       {OrderShipped, OrderRefunded},
+	OrderShipped:         {OrderDelivered},
+	OrderDelivered:       {OrderRefunded},
+}
+
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok || s == OrderCancelled || s == OrderRefunded
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
+}
+
+// Order amounts are in Currency's minor units. ExchangeRate is the rate
+// from the base currency that was used to price the order. TotalCents is
+// what is charged: SubtotalCents, the sum of the lines, less
+// DiscountCents, plus ShippingCents, plus TaxCents unless
+// PricesIncludeTax. TaxCountry and TaxRegion are where the order was
+// taxed, if anywhere. The shipping address and method are copied onto the
+// order when it is placed; ShippingMethodID is cleared if the method is
+// deleted. Invoice is issued when the order is paid.
+type Order struct {
+	gorm.Model
+	UserID             uint            `gorm:"not null;index"`
+	Status             OrderStatus     `gorm:"not null;index"`
+	Currency           string          `gorm:"size:3;not null;default:''"`
+	ExchangeRate       string          `gorm:"not null;default:'1'"`
+	SubtotalCents      int64           `gorm:"not null;default:0"`
+	DiscountCents      int64           `gorm:"not null;default:0"`
+	ShippingCents      int64           `gorm:"not null;default:0"`
+	TaxCents           int64           `gorm:"not null;default:0"`
+	PricesIncludeTax   bool            `gorm:"not null;default:false"`
+	TaxCountry         string          `gorm:"size:2;not null;default:''"`
+	TaxRegion          string          `gorm:"not null;default:''"`
+	TotalCents         int64           `gorm:"not null"`
+	ShippingAddress    PostalAddress   `gorm:"embedded;embeddedPrefix:shipping_"`
+	ShippingMethodID   *uint           `gorm:"index"`
+	ShippingMethodName string          `gorm:"not null;default:''"`
+	Items              []OrderItem     `gorm:"constraint:OnDelete:CASCADE"`
+	Discounts          []OrderDiscount `gorm:"constraint:OnDelete:CASCADE"`
+	Taxes              []OrderTax      `gorm:"constraint:OnDelete:CASCADE"`
+	Refunds            []Refund        `gorm:"constraint:OnDelete:CASCADE"`
+	Invoice            *Invoice
+}
+
+// OrderItem copies the product's name and price at the time of the order,
+// so later catalog changes don't rewrite order history.
+type OrderItem struct {
+	ID             uint   `gorm:"primarykey"`
+	OrderID        uint   `gorm:"not null;index"`
+	ProductID      uint   `gorm:"not null;index"`
+	ProductName    string `gorm:"not null"`
+	UnitPriceCents int64  `gorm:"not null"`
+	Quantity       int    `gorm:"not null"`
+	LineTotalCents int64  `gorm:"not null"`
+}
+
+// OrderTransition records an order moving from one status to another.
+// ActorID is nil for transitions made by the system.
+type OrderTransition struct {
+	ID        uint        `gorm:"primarykey"`
+	OrderID   uint        `gorm:"not null;index"`
+	From      OrderStatus `gorm:"not null"`
+	To        OrderStatus `gorm:"not null"`
+	ActorID   *uint
+	CreatedAt time.Time
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	// PaymentPending payments are being authorized.
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	// PaymentProcessing payments have been captured and are waiting for
+	// the provider to confirm it.
+	PaymentProcessing PaymentStatus = "processing"
+	PaymentSucceeded  PaymentStatus = "succeeded"
+	PaymentFailed     PaymentStatus = "failed"
+)
+
+// InFlightPaymentStatuses are the statuses of payments whose outcome isn't
+// known yet.
+var InFlightPaymentStatuses = []PaymentStatus{PaymentPending, PaymentAuthorized, PaymentProcessing}
+
+// Payment is an attempt to pay for an order through a payment provider.
+// Reference is the provider's ID for it, set once it is authorized.
+// AmountCents is in Currency's minor units.
+type Payment struct {
+	ID            uint          `gorm:"primarykey"`
+	OrderID       uint          `gorm:"not null;index"`
+	Provider      string        `gorm:"not null;uniqueIndex:idx_payments_reference,priority:1"`
+	Reference     *string       `gorm:"uniqueIndex:idx_payments_reference,priority:2"`
+	Status        PaymentStatus `gorm:"not null"`
+	AmountCents   int64         `gorm:"not null"`
+	Currency      string        `gorm:"size:3;not null"`
+	FailureReason string        `gorm:"not null;default:''"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PaymentEvent records a provider's webhook once it has been handled, so
+// the same event delivered again is ignored.
+type PaymentEvent struct {
+	Provider  string `gorm:"primaryKey"`
+	EventID   string `gorm:"primaryKey"`
+	Type      string `gorm:"not null"`
+	Reference string `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// PriceChange records the price a product was sold at from EffectiveFrom
+// until the next change. Entries in the past are never rewritten; entries
+// in the future are the currently scheduled changes and are recomputed
+// whenever the product's prices are edited.
+type PriceChange struct {
+	ID                uint      `gorm:"primarykey"`
+	ProductID         uint      `gorm:"not null;index:idx_price_changes_product,priority:1"`
+	PriceCents        int64     `gorm:"not null"`
+	RegularPriceCents int64     `gorm:"not null"`
+	EffectiveFrom     time.Time `gorm:"not null;index:idx_price_changes_product,priority:2"`
+	CreatedAt         time.Time
+}
@@ -1,9 +1,54 @@
 package domain
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Uniqueness only applies to rows that are not soft-deleted, so a product
+// in the trash doesn't block re-creating one with the same name or SKU.
 type Product struct {
 	gorm.Model
-	Name       string `gorm:"uniqueIndex;not null"`
-	PriceCents int64  `gorm:"not null"`
+	SKU  *string `gorm:"uniqueIndex:idx_products_sku_active,where:deleted_at IS NULL"`
+	Name string  `gorm:"uniqueIndex:idx_products_name_active,where:deleted_at IS NULL;not null"`
+	// Slug is derived from Name. It stays unique across the trash too, so
+	// restoring a product never breaks links to it.
+	Slug          string     `gorm:"uniqueIndex;not null"`
+	Description   string     `gorm:"not null;default:''"`
+	Category      string     `gorm:"not null;default:'';index"`
+	PriceCents    int64      `gorm:"not null"`
+	ProductTypeID *uint      `gorm:"index"`
+	Attributes    Attributes `gorm:"not null;default:'{}'"`
+	// TaxCategory picks the product's tax rates; empty is the standard
+	// category.
+	TaxCategory string `gorm:"not null;default:''"`
+	// WeightGrams is used to price weight-based shipping.
+	WeightGrams int64 `gorm:"not null;default:0"`
+	// RatingAverage and ReviewCount summarise approved reviews. They are
+	// derived data, kept up to date by review moderation.
+	RatingAverage float64 `gorm:"not null;default:0"`
+	ReviewCount   int     `gorm:"not null;default:0"`
+	// RegularPriceCents and CurrentPriceCents are resolved from the price
+	// schedule when the product is read; they are not stored.
+	RegularPriceCents int64 `gorm:"->;-:migration"`
+	CurrentPriceCents int64 `gorm:"->;-:migration"`
+	// LowestPrice30dCents is the lowest price in the 30 days before the
+	// current sale started. It is only set while the product is on sale.
+	LowestPrice30dCents *int64 `gorm:"-"`
+	// LocalizedName and LocalizedDescription are resolved from translations
+	// for the requested locales when the product is read.
+	LocalizedName        string `gorm:"->;-:migration"`
+	LocalizedDescription string `gorm:"->;-:migration"`
+	// AvailableQuantity is the stock that can still be sold, or nil when
+	// the product's stock isn't tracked.
+	AvailableQuantity *int `gorm:"->;-:migration"`
+}
+
+// ProductSlug is a slug a product had before it was renamed. Requests for
+// it are redirected to the product's current slug.
+type ProductSlug struct {
+	Slug      string `gorm:"primarykey"`
+	ProductID uint   `gorm:"not null;index"`
+	CreatedAt time.Time
 }
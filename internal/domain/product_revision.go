@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type RevisionAction string
+
+const (
+	RevisionCreate   RevisionAction = "create"
+	RevisionUpdate   RevisionAction = "update"
+	RevisionDelete   RevisionAction = "delete"
+	RevisionRestore  RevisionAction = "restore"
+	RevisionRollback RevisionAction = "rollback"
+)
+
+// ProductRevision is an append-only record of a product's state after a
+// change. Rows are never updated or deleted, so it deliberately does not
+// embed gorm.Model.
+type ProductRevision struct {
+	ID        uint            `gorm:"primarykey"`
+	ProductID uint            `gorm:"not null;uniqueIndex:idx_product_revisions_version,priority:1"`
+	Version   int             `gorm:"not null;uniqueIndex:idx_product_revisions_version,priority:2"`
+	Action    RevisionAction  `gorm:"not null"`
+	ActorID   *uint           `gorm:"index"`
+	Snapshot  ProductSnapshot `gorm:"serializer:json;not null"`
+	CreatedAt time.Time       `gorm:"not null"`
+}
+
+// ProductSnapshot holds the product fields that are tracked by revisions.
+type ProductSnapshot struct {
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	SKU           *string    `json:"sku"`
+	Category      string     `json:"category"`
+	PriceCents    int64      `json:"price_cents"`
+	TaxCategory   string     `json:"tax_category"`
+	WeightGrams   int64      `json:"weight_grams"`
+	ProductTypeID *uint      `json:"product_type_id"`
+	Attributes    Attributes `json:"attributes"`
+}
+
+func NewProductSnapshot(product Product) ProductSnapshot {
+	attributes := product.Attributes
+	if attributes == nil {
+		attributes = Attributes{}
+	}
+
+	return ProductSnapshot{
+		Name:          product.Name,
+		Description:   product.Description,
+		SKU:           product.SKU,
+		Category:      product.Category,
+		PriceCents:    product.PriceCents,
+		TaxCategory:   product.TaxCategory,
+		WeightGrams:   product.WeightGrams,
+		ProductTypeID: product.ProductTypeID,
+		Attributes:    attributes,
+	}
+}
+
+// Apply copies the snapshot's fields onto the product.
+func (s ProductSnapshot) Apply(product *Product) {
+	product.Name = s.Name
+	product.Description = s.Description
+	product.SKU = s.SKU
+	product.Category = s.Category
+	product.PriceCents = s.PriceCents
+	product.TaxCategory = s.TaxCategory
+	product.WeightGrams = s.WeightGrams
+	product.ProductTypeID = s.ProductTypeID
+	product.Attributes = s.Attributes
+}
+
+type FieldChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+// Diff lists the fields that differ between s and other, keyed by their
+// JSON names and sorted by field name.
+func (s ProductSnapshot) Diff(other ProductSnapshot) []FieldChange {
+	from := s.fields()
+	to := other.fields()
+
+	var changes []FieldChange
+	for field, value := range from {
+		if !reflect.DeepEqual(value, to[field]) {
+			changes = append(changes, FieldChange{Field: field, From: value, To: to[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+func (s ProductSnapshot) fields() map[string]any {
+	raw, _ := json.Marshal(s)
+	var fields map[string]any
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
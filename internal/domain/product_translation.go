@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// ProductTranslation holds a product's name and description in one locale.
+// Empty fields fall back to the next locale in the chain.
+type ProductTranslation struct {
+	ID          uint   `gorm:"primarykey"`
+	ProductID   uint   `gorm:"not null;uniqueIndex:idx_product_translations_locale,priority:1"`
+	Locale      string `gorm:"not null;uniqueIndex:idx_product_translations_locale,priority:2"`
+	Name        string `gorm:"not null;default:''"`
+	Description string `gorm:"not null;default:''"`
+}
+
+// NormalizeLocale validates a BCP 47 tag and returns it in canonical form,
+// for example "de-at" becomes "de-AT".
+func NormalizeLocale(value string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", err
+	}
+	return tag.String(), nil
+}
+
+// LocaleFallbacks returns the locale followed by its less specific forms,
+// so "de-AT" gives "de-AT" and "de". The locale must be normalized.
+func LocaleFallbacks(locale string) []string {
+	var chain []string
+	for locale != "" {
+		chain = append(chain, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return chain
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type AttributeType string
+
+const (
+	AttributeString AttributeType = "string"
+	AttributeNumber AttributeType = "number"
+	AttributeBool   AttributeType = "bool"
+	AttributeEnum   AttributeType = "enum"
+)
+
+func (t AttributeType) IsValid() bool {
+	switch t {
+	case AttributeString, AttributeNumber, AttributeBool, AttributeEnum:
+		return true
+	}
+	return false
+}
+
+// ProductType is the attribute schema shared by a kind of product, for
+// example cars with mileage and fuel type.
+type ProductType struct {
+	gorm.Model
+	Name       string                `gorm:"uniqueIndex;not null"`
+	Attributes []AttributeDefinition `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type AttributeDefinition struct {
+	ID            uint          `gorm:"primarykey"`
+	ProductTypeID uint          `gorm:"not null;uniqueIndex:idx_attribute_definitions_name,priority:1"`
+	Name          string        `gorm:"not null;uniqueIndex:idx_attribute_definitions_name,priority:2;index"`
+	Type          AttributeType `gorm:"not null"`
+	Required      bool          `gorm:"not null;default:false"`
+	// Options lists the allowed values of an enum attribute.
+	Options []string `gorm:"serializer:json"`
+}
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// IsValidAttributeName reports whether name can be used as an attribute
+// key. Names are restricted because they end up in JSON path expressions.
+func IsValidAttributeName(name string) bool {
+	return attributeNamePattern.MatchString(name)
+}
+
+type AttributeError struct {
+	Attribute string
+	Message   string
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("attribute %s: %s", e.Attribute, e.Message)
+}
+
+// Validate checks attribute values against the type's schema.
+func (t ProductType) Validate(attributes Attributes) error {
+	definitions := make(map[string]AttributeDefinition, len(t.Attributes))
+	for _, definition := range t.Attributes {
+		definitions[definition.Name] = definition
+	}
+
+	for name, value := range attributes {
+		definition, ok := definitions[name]
+		if !ok {
+			return &AttributeError{Attribute: name, Message: "is not defined for " + t.Name}
+		}
+		if err := definition.validate(value); err != nil {
+			return err
+		}
+	}
+
+	for _, definition := range t.Attributes {
+		if _, ok := attributes[definition.Name]; definition.Required && !ok {
+			return &AttributeError{Attribute: definition.Name, Message: "is required"}
+		}
+	}
+	return nil
+}
+
+func (d AttributeDefinition) validate(value any) error {
+	valid := false
+	switch d.Type {
+	case AttributeString:
+		_, valid = value.(string)
+	case AttributeNumber:
+		_, valid = value.(float64)
+	case AttributeBool:
+		_, valid = value.(bool)
+	case AttributeEnum:
+		s, ok := value.(string)
+		if ok && !slices.Contains(d.Options, s) {
+			return &AttributeError{Attribute: d.Name, Message: fmt.Sprintf("must be one of %v", d.Options)}
+		}
+		valid = ok
+	}
+
+	if !valid {
+		return &AttributeError{Attribute: d.Name, Message: "must be a " + string(d.Type)}
+	}
+	return nil
+}
+
+// Attributes holds a product's attribute values. It is stored as JSONB on
+// Postgres and as JSON text on other databases.
+type Attributes map[string]any
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(map[string]any(a))
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (a *Attributes) Scan(value any) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*a = Attributes{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", value)
+	}
+
+	result := Attributes{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return err
+	}
+	*a = result
+	return nil
+}
+
+func (Attributes) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "text"
+}
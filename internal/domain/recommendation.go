@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// ProductAffinity counts the orders in which two products were bought
+// together. Every pair is stored in both directions.
+type ProductAffinity struct {
+	ProductID        uint `gorm:"primarykey;autoIncrement:false"`
+	RelatedProductID uint `gorm:"primarykey;autoIncrement:false;index"`
+	Count            int  `gorm:"not null"`
+}
+
+// JobCursor records how far an incremental job has got, so the next run
+// carries on from Position instead of starting over.
+type JobCursor struct {
+	Name      string `gorm:"primarykey"`
+	Position  uint   `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
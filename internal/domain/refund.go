@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+type RefundStatus string
+
+const (
+	// RefundPending refunds have been recorded and are waiting for the
+	// payment provider's answer.
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund gives back part or all of a payment for an order. AmountCents is
+// in the order's currency. Items are the units refunded when the refund
+// was for particular lines, and Restocked says whether they were put back
+// in stock. ActorID is the user who made the refund.
+type Refund struct {
+	ID            uint         `gorm:"primarykey"`
+	OrderID       uint         `gorm:"not null;index"`
+	PaymentID     uint         `gorm:"not null;index"`
+	Status        RefundStatus `gorm:"not null"`
+	AmountCents   int64        `gorm:"not null"`
+	Reason        string       `gorm:"not null;default:''"`
+	Restocked     bool         `gorm:"not null;default:false"`
+	FailureReason string       `gorm:"not null;default:''"`
+	ActorID       *uint
+	Items         []RefundItem `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time
+}
+
+type RefundItem struct {
+	ID          uint `gorm:"primarykey"`
+	RefundID    uint `gorm:"not null;index"`
+	OrderItemID uint `gorm:"not null;index"`
+	ProductID   uint `gorm:"not null"`
+	Quantity    int  `gorm:"not null"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// Review is a customer's rating of a product. Only approved reviews are
+// shown publicly and counted in the product's rating.
+type Review struct {
+	gorm.Model
+	ProductID        uint `gorm:"not null;uniqueIndex:idx_reviews_product_user,where:deleted_at IS NULL"`
+	UserID           uint `gorm:"not null;uniqueIndex:idx_reviews_product_user,where:deleted_at IS NULL"`
+	User             User
+	Rating           int          `gorm:"not null"`
+	Body             string       `gorm:"not null;default:''"`
+	VerifiedPurchase bool         `gorm:"not null;default:false"`
+	Status           ReviewStatus `gorm:"not null;index"`
+	ModeratedBy      *uint
+	ModeratedAt      *time.Time
+}
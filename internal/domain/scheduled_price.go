@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+type PriceKind string
+
+const (
+	// PriceRegular replaces the product's base price while it is active.
+	PriceRegular PriceKind = "regular"
+	// PriceSale is charged instead of the regular price while it is active;
+	// the regular price is still shown as the original price.
+	PriceSale PriceKind = "sale"
+)
+
+func (k PriceKind) IsValid() bool {
+	return k == PriceRegular || k == PriceSale
+}
+
+// ScheduledPrice is a price that applies from StartsAt until EndsAt, or
+// indefinitely when EndsAt is nil. When several entries of the same kind
+// are active, the one that started last wins.
+type ScheduledPrice struct {
+	ID         uint       `gorm:"primarykey"`
+	ProductID  uint       `gorm:"not null;index"`
+	Kind       PriceKind  `gorm:"not null"`
+	PriceCents int64      `gorm:"not null"`
+	StartsAt   time.Time  `gorm:"not null;index"`
+	EndsAt     *time.Time `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+func (p ScheduledPrice) IsActiveAt(at time.Time) bool {
+	return !p.StartsAt.After(at) && (p.EndsAt == nil || p.EndsAt.After(at))
+}
+
+// ResolvePrices returns a product's regular and current price at the given
+// time. It follows the same rules as the catalog queries: the latest-starting
+// active entry of each kind wins, with ties going to the newer entry.
+func ResolvePrices(basePriceCents int64, schedule []ScheduledPrice, at time.Time) (regular, current int64) {
+	var latestRegular, latestSale *ScheduledPrice
+	for i := range schedule {
+		price := &schedule[i]
+		if !price.IsActiveAt(at) {
+			continue
+		}
+
+		latest := &latestRegular
+		if price.Kind == PriceSale {
+			latest = &latestSale
+		}
+		if *latest == nil || startsLater(price, *latest) {
+			*latest = price
+		}
+	}
+
+	regular = basePriceCents
+	if latestRegular != nil {
+		regular = latestRegular.PriceCents
+	}
+	current = regular
+	if latestSale != nil {
+		current = latestSale.PriceCents
+	}
+	return regular, current
+}
+
+func startsLater(a, b *ScheduledPrice) bool {
+	if a.StartsAt.Equal(b.StartsAt) {
+		return a.ID > b.ID
+	}
+	return a.StartsAt.After(b.StartsAt)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// ShippingZone groups the locations that share shipping methods.
+type ShippingZone struct {
+	ID        uint                   `gorm:"primarykey"`
+	Name      string                 `gorm:"not null;uniqueIndex"`
+	Locations []ShippingZoneLocation `gorm:"foreignKey:ZoneID;constraint:OnDelete:CASCADE"`
+	Methods   []ShippingMethod       `gorm:"foreignKey:ZoneID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ShippingZoneLocation is a country in a zone, or just a region of it
+// when Region is set.
+type ShippingZoneLocation struct {
+	ID      uint   `gorm:"primarykey"`
+	ZoneID  uint   `gorm:"not null;index"`
+	Country string `gorm:"size:2;not null"`
+	Region  string `gorm:"not null;default:''"`
+}
+
+// ShippingMethod is priced by a table of rates looked up by the parcel's
+// weight or price, as Basis says. Costs are in the base currency.
+type ShippingMethod struct {
+	ID        uint           `gorm:"primarykey"`
+	ZoneID    uint           `gorm:"not null;index"`
+	Name      string         `gorm:"not null"`
+	Basis     string         `gorm:"not null"`
+	Rates     []ShippingRate `gorm:"foreignKey:MethodID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ShippingRate covers parcels up to UpTo grams or cents, or any parcel
+// when UpTo is nil. Rates are looked up in Position order.
+type ShippingRate struct {
+	ID        uint `gorm:"primarykey"`
+	MethodID  uint `gorm:"not null;index"`
+	Position  int  `gorm:"not null"`
+	UpTo      *int64
+	CostCents int64 `gorm:"not null"`
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength leaves room for a collision suffix within typical URL
+// segment limits.
+const maxSlugLength = 80
+
+// transliterations spells letters that don't decompose into an ASCII letter
+// plus accents.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'ø': "o", 'œ': "oe", 'đ': "d", 'ð': "d", 'þ': "th", 'ł': "l", 'ı': "i",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'є': "ye", 'ж': "zh",
+	'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Slugify turns a product name into a lowercase ASCII URL segment, for
+// example "Crème Brûlée (Large)" becomes "creme-brulee-large". Names
+// without any letters or digits that can be spelled in ASCII become
+// "product".
+func Slugify(name string) string {
+	var b strings.Builder
+	separate := false
+
+	write := func(s string) {
+		if separate && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		separate = false
+		b.WriteString(s)
+	}
+
+	for _, r := range norm.NFKD.String(name) {
+		r = unicode.ToLower(r)
+		if spelled, ok := transliterations[r]; ok {
+			write(spelled)
+			continue
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents split off by the decomposition.
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			write(string(r))
+		case r == '\'' || r == '’':
+			// "Kid's" reads better as "kids" than "kid-s".
+		default:
+			separate = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	if slug == "" {
+		return "product"
+	}
+	return slug
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// StockThreshold is a product's reorder threshold. Ops are alerted once
+// when the product's available stock drops below Threshold; Alerted is
+// cleared when stock recovers so the next drop alerts again.
+type StockThreshold struct {
+	ProductID uint `gorm:"primarykey;autoIncrement:false"`
+	Threshold int  `gorm:"not null"`
+	Alerted   bool `gorm:"not null;default:false"`
+	UpdatedAt time.Time
+}
+
+// StockSubscription asks for a customer to be told when an out-of-stock
+// product becomes available. NotifiedAt is set once they have been told.
+type StockSubscription struct {
+	ID         uint `gorm:"primarykey"`
+	ProductID  uint `gorm:"not null;uniqueIndex:idx_stock_subscriptions_user,priority:1"`
+	UserID     uint `gorm:"not null;uniqueIndex:idx_stock_subscriptions_user,priority:2"`
+	NotifiedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// OutboxMessage is a notification waiting to be delivered by whatever
+// relays the outbox to email, chat and so on.
+type OutboxMessage struct {
+	ID        uint   `gorm:"primarykey"`
+	Kind      string `gorm:"not null;index"`
+	Recipient string `gorm:"not null"`
+	Subject   string `gorm:"not null"`
+	Body      string `gorm:"not null;default:''"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// TaxRate is a tax rate for a country, or a region within it when Region
+// is set. A rate with a Category only applies to products in that tax
+// category. Rate is a decimal fraction, such as "0.0825", stored as a
+// string so it is exact.
+type TaxRate struct {
+	ID        uint   `gorm:"primarykey"`
+	Country   string `gorm:"size:2;not null;uniqueIndex:idx_tax_rates_jurisdiction"`
+	Region    string `gorm:"not null;default:'';uniqueIndex:idx_tax_rates_jurisdiction"`
+	Category  string `gorm:"not null;default:'';uniqueIndex:idx_tax_rates_jurisdiction"`
+	Name      string `gorm:"not null"`
+	Rate      string `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrderTax is the tax one rate put on one of an order's lines, copied
+// when the order was placed so it can be audited after the rates change.
+// Amounts are in the order's currency.
+type OrderTax struct {
+	ID           uint   `gorm:"primarykey"`
+	OrderID      uint   `gorm:"not null;index"`
+	ProductID    uint   `gorm:"not null"`
+	Category     string `gorm:"not null;default:''"`
+	Name         string `gorm:"not null"`
+	Country      string `gorm:"size:2;not null"`
+	Region       string `gorm:"not null;default:''"`
+	Rate         string `gorm:"not null"`
+	TaxableCents int64  `gorm:"not null"`
+	TaxCents     int64  `gorm:"not null"`
+}
@@ -6,4 +6,5 @@ type User struct {
 	gorm.Model
 	UserName string `gorm:"uniqueIndex;not null"`
 	Password string `gorm:"not null"`
+	IsAdmin  bool   `gorm:"not null;default:false"`
 }
@@ -0,0 +1,18 @@
+package domain
+
+import "gorm.io/gorm"
+
+// Warehouse is a location that holds stock. Latitude and Longitude are used
+// to find the warehouse closest to an order's destination; Priority orders
+// warehouses when allocating by priority, lowest first.
+type Warehouse struct {
+	gorm.Model
+	Name       string  `gorm:"uniqueIndex;not null"`
+	Street     string  `gorm:"not null;default:''"`
+	City       string  `gorm:"not null;default:''"`
+	PostalCode string  `gorm:"not null;default:''"`
+	Country    string  `gorm:"size:2;not null;default:''"`
+	Latitude   float64 `gorm:"not null;default:0"`
+	Longitude  float64 `gorm:"not null;default:0"`
+	Priority   int     `gorm:"not null;default:0"`
+}
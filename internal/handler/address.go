@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.repo.GetAddresses(currentUser(r).ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get addresses",
+		})
+		return
+	}
+
+	items := make([]AddressResponse, len(addresses))
+	for i, address := range addresses {
+		items[i] = newAddressResponse(address)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]AddressResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid address id",
+		})
+		return
+	}
+
+	address, err := h.repo.GetAddress(currentUser(r).ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "address not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get address",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAddressResponse(*address))
+}
+
+func (h *Handler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	address, ok := decodeAddress(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateAddress(currentUser(r).ID, address)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create address",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newAddressResponse(*created))
+}
+
+func (h *Handler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid address id",
+		})
+		return
+	}
+
+	address, ok := decodeAddress(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateAddress(currentUser(r).ID, id, address)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "address not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update address",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAddressResponse(*updated))
+}
+
+func (h *Handler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid address id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteAddress(currentUser(r).ID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "address not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete address",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeAddress reads and validates an AddressRequest, writing the error
+// response itself when the request is invalid.
+func decodeAddress(w http.ResponseWriter, r *http.Request) (domain.Address, bool) {
+	var data AddressRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.Address{}, false
+	}
+
+	address := domain.Address{
+		PostalAddress: domain.PostalAddress{
+			Name:       strings.TrimSpace(data.Name),
+			Street:     strings.TrimSpace(data.Street),
+			City:       strings.TrimSpace(data.City),
+			Region:     strings.ToUpper(strings.TrimSpace(data.Region)),
+			PostalCode: strings.TrimSpace(data.PostalCode),
+			Country:    strings.ToUpper(strings.TrimSpace(data.Country)),
+		},
+		Latitude:  data.Latitude,
+		Longitude: data.Longitude,
+	}
+
+	if address.Name == "" || address.Street == "" || address.City == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name, street and city required",
+		})
+		return domain.Address{}, false
+	}
+	if len(address.Country) != 2 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "country must be a two-letter code",
+		})
+		return domain.Address{}, false
+	}
+	if (address.Latitude == nil) != (address.Longitude == nil) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "latitude and longitude go together",
+		})
+		return domain.Address{}, false
+	}
+	if address.Latitude != nil && !validCoordinates(*address.Latitude, *address.Longitude) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid coordinates",
+		})
+		return domain.Address{}, false
+	}
+	return address, true
+}
+
+func newAddressResponse(address domain.Address) AddressResponse {
+	return AddressResponse{
+		ID:                    address.ID,
+		PostalAddressResponse: newPostalAddressResponse(address.PostalAddress),
+		Latitude:              address.Latitude,
+		Longitude:             address.Longitude,
+		CreatedAt:             address.CreatedAt,
+		UpdatedAt:             address.UpdatedAt,
+	}
+}
+
+func newPostalAddressResponse(address domain.PostalAddress) PostalAddressResponse {
+	return PostalAddressResponse{
+		Name:       address.Name,
+		Street:     address.Street,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/shipping"
+	"gorm.io/gorm"
+)
+
+// CartTokenHeader carries the token of a guest cart, both in requests and
+// in the responses that create one.
+const CartTokenHeader = "X-Cart-Token"
+
+const (
+	CartWarningPriceChanged      = "price_changed"
+	CartWarningUnavailable       = "unavailable"
+	CartWarningInsufficientStock = "insufficient_stock"
+	// CartWarningCouponNotApplicable means the cart's coupon gives no
+	// discount as the cart is now, and would fail checkout.
+	CartWarningCouponNotApplicable = "coupon_not_applicable"
+)
+
+// GetCart responds with the user's cart or, for a guest, the cart named by
+// CartTokenHeader. A guest without a cart gets an empty one.
+func (h *Handler) GetCart(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cartOwner(r)
+	if !ok {
+		prices, err := h.priceConverter(r)
+		if err != nil {
+			if writeCurrencyError(w, err) {
+				return
+			}
+
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to get cart",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newCartResponse(nil, prices))
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+// AddCartItem starts a guest cart when a shopper who isn't logged in adds
+// their first item; its token is returned in CartTokenHeader.
+
+func (h *Handler) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	var data CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.Quantity <= 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "quantity must be positive",
+		})
+		return
+	}
+
+	owner, ok := cartOwner(r)
+	if !ok {
+		token, err := h.repo.CreateGuestCart()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to add item to cart",
+			})
+			return
+		}
+		owner = repository.CartOwner{Token: token}
+	}
+
+	if err := h.repo.AddCartItem(owner, data.ProductID, data.Quantity); err != nil {
+		if errors.Is(err, repository.ErrCartNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "cart not found",
+			})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to add item to cart",
+		})
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+func (h *Handler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "productID")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	var data CartItemQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.Quantity <= 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "quantity must be positive",
+		})
+		return
+	}
+
+	owner, ok := cartOwner(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "product not in cart",
+		})
+		return
+	}
+
+	if err := h.repo.SetCartItemQuantity(owner, productID, data.Quantity); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not in cart",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update cart",
+		})
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+func (h *Handler) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "productID")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	owner, ok := cartOwner(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "product not in cart",
+		})
+		return
+	}
+
+	if err := h.repo.RemoveCartItem(owner, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not in cart",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update cart",
+		})
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+// CheckoutCart places an order for everything in the cart. The body is
+// optional.
+func (h *Handler) CheckoutCart(w http.ResponseWriter, r *http.Request) {
+	var data CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	opts, ok := orderOptions(w, data.ShipTo, data.TaxAddress)
+	if !ok {
+		return
+	}
+	opts.AddressID = data.AddressID
+	opts.ShippingMethodID = data.ShippingMethodID
+
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to check out",
+		})
+		return
+	}
+
+	order, err := h.repo.CheckoutCart(currentUser(r).ID, prices, opts)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartEmpty) ||
+			errors.Is(err, repository.ErrProductNotFound) ||
+			errors.Is(err, repository.ErrInsufficientStock) ||
+			errors.Is(err, repository.ErrCouponNotFound) ||
+			errors.Is(err, promotion.ErrNotApplicable) ||
+			errors.Is(err, shipping.ErrUnavailable) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrAddressNotFound) ||
+			errors.Is(err, repository.ErrShippingAddressRequired) ||
+			errors.Is(err, repository.ErrShippingMethodRequired) ||
+			errors.Is(err, repository.ErrTaxAddressRequired) ||
+			errors.Is(err, repository.ErrTaxAddressMismatch) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, repository.ErrTaxUnavailable) {
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to check out",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newOrderResponse(*order))
+}
+
+// cartOwner returns the cart the request is for: the logged-in user's, or
+// else the guest cart named by CartTokenHeader, if any.
+func cartOwner(r *http.Request) (repository.CartOwner, bool) {
+	if user := currentUser(r); user != nil {
+		return repository.CartOwner{UserID: user.ID}, true
+	}
+	if token := r.Header.Get(CartTokenHeader); token != "" {
+		return repository.CartOwner{Token: token}, true
+	}
+	return repository.CartOwner{}, false
+}
+
+// writeCart responds with the owner's cart priced at current prices.
+func (h *Handler) writeCart(w http.ResponseWriter, r *http.Request, owner repository.CartOwner) {
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get cart",
+		})
+		return
+	}
+
+	locales, err := requestLocales(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return
+	}
+
+	cart, err := h.repo.GetCart(owner, locales)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "cart not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get cart",
+		})
+		return
+	}
+
+	resp := newCartResponse(cart, prices)
+	if owner.UserID == 0 {
+		w.Header().Set(CartTokenHeader, owner.Token)
+		resp.CartToken = owner.Token
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// newCartResponse totals the available items at their current prices, less
+// the coupon's discounts, and warns about anything that would change or
+// fail at checkout. A nil cart is an empty one.
+func newCartResponse(cart *repository.CartContents, prices money.Converter) CartResponse {
+	if cart == nil {
+		cart = &repository.CartContents{}
+	}
+	resp := CartResponse{
+		Currency:  prices.Currency().Code,
+		Items:     make([]CartItemResponse, len(cart.Lines)),
+		Discounts: make([]DiscountResponse, len(cart.Discounts)),
+		Warnings:  []CartWarningResponse{},
+	}
+
+	for i, line := range cart.Lines {
+		item := CartItemResponse{
+			ProductID:   line.Item.ProductID,
+			ProductName: line.Product.LocalizedName,
+			Quantity:    line.Item.Quantity,
+			Available:   line.Available,
+		}
+
+		if !line.Available {
+			resp.Items[i] = item
+			resp.Warnings = append(resp.Warnings, CartWarningResponse{
+				ProductID: item.ProductID,
+				Code:      CartWarningUnavailable,
+				Message:   fmt.Sprintf("%s is no longer available", item.ProductName),
+			})
+			continue
+		}
+
+		item.UnitPriceCents = prices.Convert(line.Product.CurrentPriceCents).Amount
+		item.LineTotalCents = item.UnitPriceCents * int64(item.Quantity)
+		item.AvailableQuantity = line.Product.AvailableQuantity
+		resp.SubtotalCents += item.LineTotalCents
+
+		if line.Product.CurrentPriceCents != line.Item.UnitPriceCents {
+			previous := prices.Convert(line.Item.UnitPriceCents).Amount
+			item.PreviousUnitPriceCents = &previous
+			resp.Warnings = append(resp.Warnings, CartWarningResponse{
+				ProductID: item.ProductID,
+				Code:      CartWarningPriceChanged,
+				Message:   fmt.Sprintf("the price of %s has changed since it was added", item.ProductName),
+			})
+		}
+		if available := line.Product.AvailableQuantity; available != nil && *available < item.Quantity {
+			resp.Warnings = append(resp.Warnings, CartWarningResponse{
+				ProductID: item.ProductID,
+				Code:      CartWarningInsufficientStock,
+				Message:   fmt.Sprintf("only %d of %s are available", max(*available, 0), item.ProductName),
+			})
+		}
+		resp.Items[i] = item
+	}
+
+	if cart.Coupon != nil {
+		resp.CouponCode = &cart.Coupon.Code
+	}
+	if cart.CouponError != nil {
+		resp.Warnings = append(resp.Warnings, CartWarningResponse{
+			Code:    CartWarningCouponNotApplicable,
+			Message: cart.CouponError.Error(),
+		})
+	}
+	for i, discount := range cart.Discounts {
+		resp.Discounts[i] = DiscountResponse{
+			Code:        discount.Code,
+			Kind:        string(discount.Kind),
+			ProductID:   discount.ProductID,
+			Description: discount.Description,
+			AmountCents: prices.Convert(discount.AmountCents).Amount,
+		}
+		resp.DiscountCents += resp.Discounts[i].AmountCents
+	}
+	resp.DiscountCents = min(resp.DiscountCents, resp.SubtotalCents)
+	resp.TotalCents = resp.SubtotalCents - resp.DiscountCents
+	return resp
+}
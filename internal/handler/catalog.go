@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/Hiroki111/go-backend-example/internal/catalog"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+)
+
+const maxImportBytes = 32 << 20
+
+func (h *Handler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	formatValue := r.URL.Query().Get("format")
+	if formatValue == "" {
+		formatValue = r.Header.Get("Content-Type")
+	}
+	format, err := catalog.ParseFormat(formatValue)
+	if err != nil {
+		writeJSON(w, http.StatusUnsupportedMediaType, ErrorResponse{
+			Error: "format must be csv or ndjson",
+		})
+		return
+	}
+
+	dryRun, err := parseOptionalBool(r.URL.Query().Get("dryRun"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid dryRun",
+		})
+		return
+	}
+
+	records, recordErrors, err := catalog.Decode(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("invalid import file: %v", err),
+		})
+		return
+	}
+
+	// Rows that failed to parse still get checked against the database so
+	// the caller sees every problem in one round trip, but nothing is saved.
+	result, err := h.repo.ImportProducts(records, dryRun || len(recordErrors) > 0, currentUser(r).ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to import products",
+		})
+		return
+	}
+
+	allErrors := append(recordErrors, result.Errors...)
+	sort.SliceStable(allErrors, func(i, j int) bool {
+		return allErrors[i].Line < allErrors[j].Line
+	})
+
+	resp := ImportProductsResponse{
+		DryRun:  dryRun,
+		Applied: !dryRun && len(allErrors) == 0,
+		Created: result.Created,
+		Updated: result.Updated,
+		Errors:  make([]ImportErrorResponse, len(allErrors)),
+	}
+	for i, recordErr := range allErrors {
+		resp.Errors[i] = ImportErrorResponse{Line: recordErr.Line, Error: recordErr.Message}
+	}
+
+	status := http.StatusOK
+	if len(allErrors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *Handler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	formatValue := r.URL.Query().Get("format")
+	if formatValue == "" {
+		formatValue = string(catalog.FormatCSV)
+	}
+	format, err := catalog.ParseFormat(formatValue)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be csv or ndjson",
+		})
+		return
+	}
+
+	// The encoder buffers its output, so nothing reaches the client until
+	// the first products are encoded and a failure here can still be
+	// reported normally.
+	encoder, err := catalog.NewEncoder(format, w)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to export products",
+		})
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+
+	err = h.repo.EachProduct(func(product domain.Product) error {
+		return encoder.Encode(product)
+	})
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err != nil {
+		// The status line has already been sent, so the only way to signal
+		// a failed export is to cut the response short.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func parseOptionalBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.repo.GetCoupons()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get coupons",
+		})
+		return
+	}
+
+	items := make([]CouponResponse, len(coupons))
+	for i, coupon := range coupons {
+		items[i] = newCouponResponse(coupon)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]CouponResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid coupon id",
+		})
+		return
+	}
+
+	coupon, err := h.repo.GetCoupon(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "coupon not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get coupon",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCouponResponse(*coupon))
+}
+
+func (h *Handler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	coupon, ok := decodeCoupon(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateCoupon(coupon)
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create coupon",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newCouponResponse(*created))
+}
+
+func (h *Handler) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid coupon id",
+		})
+		return
+	}
+
+	coupon, ok := decodeCoupon(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateCoupon(id, coupon)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "coupon not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrCouponAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update coupon",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCouponResponse(*updated))
+}
+
+func (h *Handler) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid coupon id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteCoupon(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "coupon not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete coupon",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ApplyCartCoupon applies a coupon to the cart, replacing any other, and
+// responds with the discounted cart. A coupon that gives the cart no
+// discount is refused with the reason.
+func (h *Handler) ApplyCartCoupon(w http.ResponseWriter, r *http.Request) {
+	var data CartCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if strings.TrimSpace(data.Code) == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "code required",
+		})
+		return
+	}
+
+	owner, ok := cartOwner(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "cart not found",
+		})
+		return
+	}
+
+	if err := h.repo.ApplyCartCoupon(owner, data.Code); err != nil {
+		if errors.Is(err, repository.ErrCartNotFound) ||
+			errors.Is(err, repository.ErrCouponNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, promotion.ErrNotApplicable) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to apply coupon",
+		})
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+func (h *Handler) RemoveCartCoupon(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cartOwner(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "no coupon applied",
+		})
+		return
+	}
+
+	if err := h.repo.RemoveCartCoupon(owner); err != nil {
+		if errors.Is(err, repository.ErrCartNotFound) ||
+			errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "no coupon applied",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to remove coupon",
+		})
+		return
+	}
+
+	h.writeCart(w, r, owner)
+}
+
+// decodeCoupon reads and validates a CouponRequest, writing the error
+// response itself when the request is invalid.
+func decodeCoupon(w http.ResponseWriter, r *http.Request) (domain.Coupon, bool) {
+	var data CouponRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.Coupon{}, false
+	}
+
+	coupon := domain.Coupon{
+		Code:             repository.NormalizeCouponCode(data.Code),
+		Description:      strings.TrimSpace(data.Description),
+		Kind:             data.Kind,
+		PercentOff:       data.PercentOff,
+		AmountOffCents:   data.AmountOffCents,
+		BuyQuantity:      data.BuyQuantity,
+		GetQuantity:      data.GetQuantity,
+		ProductID:        data.ProductID,
+		MinSubtotalCents: data.MinSubtotalCents,
+		MaxUses:          data.MaxUses,
+		MaxUsesPerUser:   data.MaxUsesPerUser,
+		StartsAt:         data.StartsAt,
+		EndsAt:           data.EndsAt,
+	}
+
+	if coupon.Code == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "code required",
+		})
+		return domain.Coupon{}, false
+	}
+	if (coupon.MaxUses != nil && *coupon.MaxUses < 1) ||
+		(coupon.MaxUsesPerUser != nil && *coupon.MaxUsesPerUser < 1) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "max_uses and max_uses_per_user must be positive",
+		})
+		return domain.Coupon{}, false
+	}
+	rule := promotion.Rule{
+		Code:             coupon.Code,
+		Kind:             promotion.Kind(coupon.Kind),
+		PercentOff:       coupon.PercentOff,
+		AmountOffCents:   coupon.AmountOffCents,
+		BuyQuantity:      coupon.BuyQuantity,
+		GetQuantity:      coupon.GetQuantity,
+		ProductID:        coupon.ProductID,
+		MinSubtotalCents: coupon.MinSubtotalCents,
+		StartsAt:         coupon.StartsAt,
+		EndsAt:           coupon.EndsAt,
+	}
+	if err := rule.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return domain.Coupon{}, false
+	}
+	return coupon, true
+}
+
+func newCouponResponse(coupon domain.Coupon) CouponResponse {
+	return CouponResponse{
+		ID:               coupon.ID,
+		Code:             coupon.Code,
+		Description:      coupon.Description,
+		Kind:             coupon.Kind,
+		PercentOff:       coupon.PercentOff,
+		AmountOffCents:   coupon.AmountOffCents,
+		BuyQuantity:      coupon.BuyQuantity,
+		GetQuantity:      coupon.GetQuantity,
+		ProductID:        coupon.ProductID,
+		MinSubtotalCents: coupon.MinSubtotalCents,
+		MaxUses:          coupon.MaxUses,
+		MaxUsesPerUser:   coupon.MaxUsesPerUser,
+		StartsAt:         coupon.StartsAt,
+		EndsAt:           coupon.EndsAt,
+		TimesUsed:        coupon.TimesUsed,
+		CreatedAt:        coupon.CreatedAt,
+		UpdatedAt:        coupon.UpdatedAt,
+	}
+}
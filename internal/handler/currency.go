@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const maxRatesBytes = 1 << 20
+
+// priceConverter picks the currency prices are shown in. The currency
+// query parameter must name a currency with a known rate; the
+// Accept-Currency header is a preference list, and currencies without a
+// rate are skipped. Without either, prices stay in the base currency.
+func (h *Handler) priceConverter(r *http.Request) (money.Converter, error) {
+	if code := r.URL.Query().Get("currency"); code != "" {
+		return h.repo.Converter(code)
+	}
+
+	for _, preference := range strings.Split(r.Header.Get("Accept-Currency"), ",") {
+		code, _, _ := strings.Cut(preference, ";")
+		if code = strings.TrimSpace(code); code == "" {
+			continue
+		}
+		if converter, err := h.repo.Converter(code); err == nil {
+			return converter, nil
+		}
+	}
+	return h.baseConverter(), nil
+}
+
+func (h *Handler) baseConverter() money.Converter {
+	return money.Identity(h.repo.BaseCurrency())
+}
+
+// writeCurrencyError writes a 400 response and returns true when err is
+// about the requested currency.
+func writeCurrencyError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrUnsupportedCurrency) ||
+		errors.Is(err, repository.ErrExchangeRateNotFound) ||
+		errors.Is(err, repository.ErrInvalidExchangeRate) ||
+		err == repository.ErrBaseCurrencyRate {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return true
+	}
+	return false
+}
+
+func (h *Handler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.repo.GetExchangeRates()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get exchange rates",
+		})
+		return
+	}
+
+	items := make([]ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		items[i] = ExchangeRateResponse{
+			Currency:  rate.Currency,
+			Rate:      rate.Rate,
+			UpdatedAt: rate.UpdatedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, GetExchangeRatesResponse{
+		BaseCurrency: h.repo.BaseCurrency().Code,
+		Items:        items,
+	})
+}
+
+func (h *Handler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var data ExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	rate := money.Rate{Currency: strings.ToUpper(chi.URLParam(r, "currency")), Rate: data.Rate}
+	if err := h.repo.SetExchangeRates([]money.Rate{rate}); err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to save exchange rate",
+		})
+		return
+	}
+
+	h.GetExchangeRates(w, r)
+}
+
+// ImportExchangeRates replaces rates from a CSV body with a currency,rate
+// header. Nothing is saved if any line is invalid.
+func (h *Handler) ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := money.DecodeRates(io.LimitReader(r.Body, maxRatesBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	if err := h.repo.SetExchangeRates(rates); err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to save exchange rates",
+		})
+		return
+	}
+
+	h.GetExchangeRates(w, r)
+}
+
+func (h *Handler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	if err := h.repo.DeleteExchangeRate(strings.ToUpper(chi.URLParam(r, "currency"))); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "exchange rate not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete exchange rate",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
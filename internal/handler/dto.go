@@ -1,5 +1,7 @@
 package handler
 
+import "time"
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -23,8 +25,637 @@ type LoginUserRequest struct {
 	Password string `json:"password"`
 }
 
+// ProductResponse.PriceCents is the price charged now, sales included;
+// OriginalPriceCents is the regular price it is compared against. Prices
+// are in Currency's minor units.
 type ProductResponse struct {
-	ID         uint   `json:"id"`
+	ID                  uint           `json:"id"`
+	Slug                string         `json:"slug"`
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	Category            string         `json:"category"`
+	Currency            string         `json:"currency"`
+	PriceCents          int64          `json:"price_cents"`
+	OriginalPriceCents  int64          `json:"original_price_cents"`
+	OnSale              bool           `json:"on_sale"`
+	LowestPrice30dCents *int64         `json:"lowest_price_30d_cents,omitempty"`
+	AvailableQuantity   *int           `json:"available_quantity"`
+	TaxCategory         string         `json:"tax_category"`
+	WeightGrams         int64          `json:"weight_grams"`
+	ProductTypeID       *uint          `json:"product_type_id"`
+	Attributes          map[string]any `json:"attributes"`
+	RatingAverage       float64        `json:"rating_average"`
+	ReviewCount         int            `json:"review_count"`
+}
+
+// RelatedProductResponse.Reason is "bought_together" or "similar".
+type RelatedProductResponse struct {
+	ProductResponse
+	Reason string `json:"reason"`
+}
+
+type SlugRedirectResponse struct {
+	Slug string `json:"slug"`
+}
+
+type ImportProductsResponse struct {
+	DryRun  bool                  `json:"dry_run"`
+	Applied bool                  `json:"applied"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Errors  []ImportErrorResponse `json:"errors"`
+}
+
+type ImportErrorResponse struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type DeletedProductResponse struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	PriceCents int64     `json:"price_cents"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+type PurgeProductsResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+type ProductRequest struct {
+	SKU           *string        `json:"sku"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	Category      string         `json:"category"`
+	PriceCents    int64          `json:"price_cents"`
+	TaxCategory   string         `json:"tax_category"`
+	WeightGrams   int64          `json:"weight_grams"`
+	ProductTypeID *uint          `json:"product_type_id"`
+	Attributes    map[string]any `json:"attributes"`
+}
+
+type ProductSnapshotResponse struct {
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	SKU           *string        `json:"sku"`
+	Category      string         `json:"category"`
+	PriceCents    int64          `json:"price_cents"`
+	TaxCategory   string         `json:"tax_category"`
+	WeightGrams   int64          `json:"weight_grams"`
+	ProductTypeID *uint          `json:"product_type_id"`
+	Attributes    map[string]any `json:"attributes"`
+}
+
+type ProductRevisionResponse struct {
+	Version   int                     `json:"version"`
+	Action    string                  `json:"action"`
+	ActorID   *uint                   `json:"actor_id"`
+	Snapshot  ProductSnapshotResponse `json:"snapshot"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type ProductRevisionDiffResponse struct {
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes []FieldChangeResponse `json:"changes"`
+}
+
+type GetProductsResponse struct {
+	Items  []ProductResponse                `json:"items"`
+	Facets map[string][]FacetBucketResponse `json:"facets,omitempty"`
+}
+
+type FacetBucketResponse struct {
+	Value string `json:"value,omitempty"`
+	From  *int64 `json:"from,omitempty"`
+	To    *int64 `json:"to,omitempty"`
+	Count int64  `json:"count"`
+}
+
+type ProductTypeRequest struct {
+	Name       string                       `json:"name"`
+	Attributes []AttributeDefinitionRequest `json:"attributes"`
+}
+
+type AttributeDefinitionRequest struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options"`
+}
+
+type ProductTypeResponse struct {
+	ID         uint                          `json:"id"`
+	Name       string                        `json:"name"`
+	Attributes []AttributeDefinitionResponse `json:"attributes"`
+}
+
+type AttributeDefinitionResponse struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// CreateOrderRequest.ShipTo, when given, lets stock be allocated from the
+// warehouses closest to the destination. The order is taxed for
+// TaxAddress, which is required once tax rates are set up.
+// CreateOrderRequest.AddressID picks one of the user's addresses to ship
+// to, which ShippingMethodID needs. It stands in for ShipTo when that is
+// left out, and its jurisdiction is what the order is taxed for.
+type CreateOrderRequest struct {
+	Items            []OrderItemRequest `json:"items"`
+	ShipTo           *LocationRequest   `json:"ship_to"`
+	TaxAddress       *TaxAddressRequest `json:"tax_address"`
+	CouponCode       string             `json:"coupon_code"`
+	AddressID        *uint              `json:"address_id"`
+	ShippingMethodID *uint              `json:"shipping_method_id"`
+}
+
+type TaxAddressRequest struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
+
+type OrderItemRequest struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+// OrderResponse.TaxCents is part of TotalCents either way: added on top,
+// or already in the prices when PricesIncludeTax.
+type OrderResponse struct {
+	ID               uint                   `json:"id"`
+	Status           string                 `json:"status"`
+	Currency         string                 `json:"currency"`
+	SubtotalCents    int64                  `json:"subtotal_cents"`
+	DiscountCents    int64                  `json:"discount_cents"`
+	ShippingCents    int64                  `json:"shipping_cents"`
+	TaxCents         int64                  `json:"tax_cents"`
+	PricesIncludeTax bool                   `json:"prices_include_tax"`
+	TotalCents       int64                  `json:"total_cents"`
+	RefundedCents    int64                  `json:"refunded_cents"`
+	InvoiceNumber    *string                `json:"invoice_number"`
+	ShippingAddress  *PostalAddressResponse `json:"shipping_address"`
+	Shipping         *OrderShippingResponse `json:"shipping"`
+	Items            []OrderItemResponse    `json:"items"`
+	Discounts        []DiscountResponse     `json:"discounts"`
+	Taxes            []OrderTaxResponse     `json:"taxes"`
+	Refunds          []RefundResponse       `json:"refunds"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// OrderShippingResponse.MethodID is null once the method has been deleted;
+// Name is what it was called when the order was placed.
+type OrderShippingResponse struct {
+	MethodID *uint  `json:"method_id"`
+	Name     string `json:"name"`
+}
+
+type OrderTaxResponse struct {
+	ProductID    uint   `json:"product_id"`
+	Category     string `json:"category"`
+	Name         string `json:"name"`
+	Country      string `json:"country"`
+	Region       string `json:"region"`
+	Rate         string `json:"rate"`
+	TaxableCents int64  `json:"taxable_cents"`
+	TaxCents     int64  `json:"tax_cents"`
+}
+
+type OrderItemResponse struct {
+	ProductID      uint   `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+	Quantity       int    `json:"quantity"`
+	LineTotalCents int64  `json:"line_total_cents"`
+}
+
+type CartItemRequest struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type CartItemQuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type CartCouponRequest struct {
+	Code string `json:"code"`
+}
+
+type DiscountResponse struct {
+	Code        string `json:"code"`
+	Kind        string `json:"kind"`
+	ProductID   *uint  `json:"product_id"`
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+type CheckoutRequest struct {
+	ShipTo           *LocationRequest   `json:"ship_to"`
+	TaxAddress       *TaxAddressRequest `json:"tax_address"`
+	AddressID        *uint              `json:"address_id"`
+	ShippingMethodID *uint              `json:"shipping_method_id"`
+}
+
+// CartResponse.SubtotalCents only counts items that are still available,
+// and TotalCents takes off the discounts of the coupon, if it applies.
+// CartToken is set for guest carts.
+type CartResponse struct {
+	CartToken     string                `json:"cart_token,omitempty"`
+	Currency      string                `json:"currency"`
+	Items         []CartItemResponse    `json:"items"`
+	CouponCode    *string               `json:"coupon_code"`
+	Discounts     []DiscountResponse    `json:"discounts"`
+	SubtotalCents int64                 `json:"subtotal_cents"`
+	DiscountCents int64                 `json:"discount_cents"`
+	TotalCents    int64                 `json:"total_cents"`
+	Warnings      []CartWarningResponse `json:"warnings"`
+}
+
+// CartItemResponse.PreviousUnitPriceCents is the price when the item was
+// added, set only when the price has changed since.
+type CartItemResponse struct {
+	ProductID              uint   `json:"product_id"`
+	ProductName            string `json:"product_name"`
+	Quantity               int    `json:"quantity"`
+	UnitPriceCents         int64  `json:"unit_price_cents"`
+	PreviousUnitPriceCents *int64 `json:"previous_unit_price_cents,omitempty"`
+	LineTotalCents         int64  `json:"line_total_cents"`
+	Available              bool   `json:"available"`
+	AvailableQuantity      *int   `json:"available_quantity"`
+}
+
+// CartWarningResponse.ProductID is left out of warnings about the whole
+// cart.
+type CartWarningResponse struct {
+	ProductID uint   `json:"product_id,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+type OrderTransitionRequest struct {
+	Status string `json:"status"`
+}
+
+type OrderTransitionResponse struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ActorID   *uint     `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	CardNumber string `json:"card_number"`
+}
+
+// PaymentResponse.AmountCents is in Currency's minor units.
+type PaymentResponse struct {
+	ID            uint      `json:"id"`
+	OrderID       uint      `json:"order_id"`
+	Provider      string    `json:"provider"`
+	Reference     *string   `json:"reference"`
+	Status        string    `json:"status"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RefundRequest refunds Items, or else AmountCents, or else everything not
+// yet refunded.
+type RefundRequest struct {
+	Items       []OrderItemRequest `json:"items"`
+	AmountCents int64              `json:"amount_cents"`
+	Reason      string             `json:"reason"`
+	Restock     bool               `json:"restock"`
+}
+
+// RefundResponse.AmountCents is in the order's currency.
+type RefundResponse struct {
+	ID            uint                 `json:"id"`
+	Status        string               `json:"status"`
+	AmountCents   int64                `json:"amount_cents"`
+	Reason        string               `json:"reason"`
+	Restocked     bool                 `json:"restocked"`
+	FailureReason string               `json:"failure_reason,omitempty"`
+	Items         []RefundItemResponse `json:"items"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+type RefundItemResponse struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type PaymentWebhookResponse struct {
+	Status string `json:"status"`
+}
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating"`
+	Body   string `json:"body"`
+}
+
+type ReviewResponse struct {
+	ID               uint      `json:"id"`
+	ProductID        uint      `json:"product_id"`
+	UserName         string    `json:"user_name"`
+	Rating           int       `json:"rating"`
+	Body             string    `json:"body"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ScheduledPriceRequest struct {
+	Kind       string     `json:"kind"`
+	PriceCents int64      `json:"price_cents"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+}
+
+type ScheduledPriceResponse struct {
+	ID         uint       `json:"id"`
+	ProductID  uint       `json:"product_id"`
+	Kind       string     `json:"kind"`
+	PriceCents int64      `json:"price_cents"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	Active     bool       `json:"active"`
+}
+
+type PriceChangeResponse struct {
+	PriceCents         int64     `json:"price_cents"`
+	OriginalPriceCents int64     `json:"original_price_cents"`
+	EffectiveFrom      time.Time `json:"effective_from"`
+}
+
+type ExchangeRateRequest struct {
+	Rate string `json:"rate"`
+}
+
+type ExchangeRateResponse struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type GetExchangeRatesResponse struct {
+	BaseCurrency string                 `json:"base_currency"`
+	Items        []ExchangeRateResponse `json:"items"`
+}
+
+type ProductTranslationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ProductTranslationResponse struct {
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type StockRequest struct {
+	OnHand int `json:"on_hand"`
+}
+
+type AdjustStockRequest struct {
+	Delta int `json:"delta"`
+}
+
+type StockResponse struct {
+	ProductID     uint      `json:"product_id"`
+	WarehouseID   uint      `json:"warehouse_id"`
+	WarehouseName string    `json:"warehouse_name"`
+	OnHand        int       `json:"on_hand"`
+	Reserved      int       `json:"reserved"`
+	Available     int       `json:"available"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ProductStockResponse struct {
+	ProductID        uint            `json:"product_id"`
+	OnHand           int             `json:"on_hand"`
+	Reserved         int             `json:"reserved"`
+	Available        int             `json:"available"`
+	ReorderThreshold *int            `json:"reorder_threshold"`
+	Warehouses       []StockResponse `json:"warehouses"`
+}
+
+type WarehouseRequest struct {
+	Name       string  `json:"name"`
+	Street     string  `json:"street"`
+	City       string  `json:"city"`
+	PostalCode string  `json:"postal_code"`
+	Country    string  `json:"country"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Priority   int     `json:"priority"`
+}
+
+type WarehouseResponse struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name"`
+	Street     string  `json:"street"`
+	City       string  `json:"city"`
+	PostalCode string  `json:"postal_code"`
+	Country    string  `json:"country"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Priority   int     `json:"priority"`
+}
+
+// CouponRequest amounts are in the base currency. Which of the fields
+// matter depends on Kind; see promotion.Rule.
+type CouponRequest struct {
+	Code             string     `json:"code"`
+	Description      string     `json:"description"`
+	Kind             string     `json:"kind"`
+	PercentOff       int        `json:"percent_off"`
+	AmountOffCents   int64      `json:"amount_off_cents"`
+	BuyQuantity      int        `json:"buy_quantity"`
+	GetQuantity      int        `json:"get_quantity"`
+	ProductID        *uint      `json:"product_id"`
+	MinSubtotalCents int64      `json:"min_subtotal_cents"`
+	MaxUses          *int       `json:"max_uses"`
+	MaxUsesPerUser   *int       `json:"max_uses_per_user"`
+	StartsAt         *time.Time `json:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at"`
+}
+
+type CouponResponse struct {
+	ID               uint       `json:"id"`
+	Code             string     `json:"code"`
+	Description      string     `json:"description"`
+	Kind             string     `json:"kind"`
+	PercentOff       int        `json:"percent_off"`
+	AmountOffCents   int64      `json:"amount_off_cents"`
+	BuyQuantity      int        `json:"buy_quantity"`
+	GetQuantity      int        `json:"get_quantity"`
+	ProductID        *uint      `json:"product_id"`
+	MinSubtotalCents int64      `json:"min_subtotal_cents"`
+	MaxUses          *int       `json:"max_uses"`
+	MaxUsesPerUser   *int       `json:"max_uses_per_user"`
+	StartsAt         *time.Time `json:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at"`
+	TimesUsed        int64      `json:"times_used"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TaxRateRequest.Rate is a decimal fraction, such as "0.0825" for 8.25%.
+// An empty Region is the whole country, and an empty Category the standard
+// rate.
+type TaxRateRequest struct {
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Rate     string `json:"rate"`
+}
+
+type TaxRateResponse struct {
+	ID       uint   `json:"id"`
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Rate     string `json:"rate"`
+}
+
+type LocationRequest struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ReorderThresholdRequest struct {
+	Threshold int `json:"threshold"`
+}
+
+type ReorderThresholdResponse struct {
+	ProductID uint      `json:"product_id"`
+	Threshold int       `json:"threshold"`
+	Alerted   bool      `json:"alerted"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StockSubscriptionResponse struct {
+	ProductID uint      `json:"product_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OutboxMessageResponse struct {
+	ID        uint      `json:"id"`
+	Kind      string    `json:"kind"`
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostalAddressRequest.Country is a two-letter code. Region is the state
+// or province code used for tax and shipping zones, where there is one.
+type PostalAddressRequest struct {
 	Name       string `json:"name"`
-	PriceCents int64  `json:"price_cents"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+type PostalAddressResponse struct {
+	Name       string `json:"name"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// AddressRequest's coordinates are optional, and pick the warehouses
+// orders to the address ship from.
+type AddressRequest struct {
+	PostalAddressRequest
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+type AddressResponse struct {
+	ID uint `json:"id"`
+	PostalAddressResponse
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ShippingZoneRequest.Locations are countries, or regions within them when
+// Region is set. An address is shipped to by the zone that lists its
+// region, or else by the first zone that lists its country.
+type ShippingZoneRequest struct {
+	Name      string                    `json:"name"`
+	Locations []ShippingLocationRequest `json:"locations"`
+}
+
+type ShippingLocationRequest struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
+
+type ShippingZoneResponse struct {
+	ID        uint                       `json:"id"`
+	Name      string                     `json:"name"`
+	Locations []ShippingLocationResponse `json:"locations"`
+	Methods   []ShippingMethodResponse   `json:"methods"`
+}
+
+type ShippingLocationResponse struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
+
+// ShippingMethodRequest.Basis is "weight" or "price". The rates are looked
+// up by the order's weight in grams or its subtotal in base currency cents,
+// and the first rate whose up_to covers it sets the cost. Only the last
+// rate may leave up_to out.
+type ShippingMethodRequest struct {
+	Name  string                `json:"name"`
+	Basis string                `json:"basis"`
+	Rates []ShippingRateRequest `json:"rates"`
+}
+
+type ShippingRateRequest struct {
+	UpTo      *int64 `json:"up_to"`
+	CostCents int64  `json:"cost_cents"`
+}
+
+type ShippingMethodResponse struct {
+	ID     uint                   `json:"id"`
+	ZoneID uint                   `json:"zone_id"`
+	Name   string                 `json:"name"`
+	Basis  string                 `json:"basis"`
+	Rates  []ShippingRateResponse `json:"rates"`
+}
+
+type ShippingRateResponse struct {
+	UpTo      *int64 `json:"up_to"`
+	CostCents int64  `json:"cost_cents"`
+}
+
+// ShippingQuoteResponse.CostCents is in the response's currency.
+type ShippingQuoteResponse struct {
+	MethodID  uint   `json:"method_id"`
+	Name      string `json:"name"`
+	Currency  string `json:"currency"`
+	CostCents int64  `json:"cost_cents"`
 }
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+)
+
+// parseFacetsInput reads the comma-separated facets and priceBuckets
+// query parameters.
+func parseFacetsInput(r *http.Request) (repository.GetProductFacetsInput, error) {
+	var input repository.GetProductFacetsInput
+
+	for _, facet := range splitList(r.URL.Query().Get("facets")) {
+		if !repository.IsValidFacet(facet) {
+			return input, errors.New("invalid facet " + facet)
+		}
+		input.Facets = append(input.Facets, facet)
+	}
+
+	for _, value := range splitList(r.URL.Query().Get("priceBuckets")) {
+		boundary, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || boundary <= 0 {
+			return input, errors.New("invalid priceBuckets")
+		}
+		if n := len(input.PriceBuckets); n > 0 && boundary <= input.PriceBuckets[n-1] {
+			return input, errors.New("priceBuckets must be ascending")
+		}
+		input.PriceBuckets = append(input.PriceBuckets, boundary)
+	}
+
+	return input, nil
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func newFacetsResponse(facets map[string][]repository.FacetBucket) map[string][]FacetBucketResponse {
+	resp := make(map[string][]FacetBucketResponse, len(facets))
+	for name, buckets := range facets {
+		items := make([]FacetBucketResponse, len(buckets))
+		for i, bucket := range buckets {
+			items[i] = FacetBucketResponse{
+				Value: bucket.Value,
+				From:  bucket.From,
+				To:    bucket.To,
+				Count: bucket.Count,
+			}
+		}
+		resp[name] = items
+	}
+	return resp
+}
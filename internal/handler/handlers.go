@@ -6,20 +6,38 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Hiroki111/go-backend-example/internal/auth"
 	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
 	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	repo *repository.Repository
+	repo           *repository.Repository
+	payments       payment.Gateway
+	idempotencyTTL time.Duration
 }
 
+// NewHandler takes payments with a payment.Mock that neither sends nor
+// accepts webhooks until SetPaymentGateway says otherwise.
 func NewHandler(repo *repository.Repository) *Handler {
-	return &Handler{repo: repo}
+	return &Handler{
+		repo:           repo,
+		payments:       payment.NewMock("", nil),
+		idempotencyTTL: DefaultIdempotencyKeyTTL,
+	}
+}
+
+func (h *Handler) SetPaymentGateway(gateway payment.Gateway) {
+	h.payments = gateway
 }
 
 func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
@@ -107,6 +125,16 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// What the user put in their cart before logging in is kept.
+	if cartToken := r.Header.Get(CartTokenHeader); cartToken != "" {
+		if err := h.repo.MergeGuestCart(user.ID, cartToken); err != nil {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to merge cart",
+			})
+			return
+		}
+	}
+
 	writeJSON(w, http.StatusOK, LoginUserResponse{
 		AccessToken: token,
 		TokenType:   "Bearer",
@@ -114,6 +142,41 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	h.listProducts(w, r, time.Time{})
+}
+
+// PreviewProducts lists the catalog with prices as they will be, or were,
+// at the time given by the at query parameter.
+func (h *Handler) PreviewProducts(w http.ResponseWriter, r *http.Request) {
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "at must be an RFC 3339 time",
+		})
+		return
+	}
+
+	h.listProducts(w, r, at)
+}
+
+// listProducts serves the product listing with prices resolved at the
+// given time, or now when at is zero.
+//
+// Prices in the response are converted to the requested currency, while
+// the minPrice and maxPrice filters and price facets use the base currency.
+func (h *Handler) listProducts(w http.ResponseWriter, r *http.Request, at time.Time) {
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get products",
+		})
+		return
+	}
+
 	orderBy := r.URL.Query().Get("orderBy")
 	sortIn := r.URL.Query().Get("sortIn")
 	name := r.URL.Query().Get("name")
@@ -136,9 +199,50 @@ func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inputs := repository.GetProductsInput{OrderBy: orderBy, SortIn: sortIn, Name: name, MinPrice: minPriceInt, MaxPrice: maxPriceInt}
+	facets, err := parseFacetsInput(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	attributes, err := parseAttributeFilters(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	locales, err := requestLocales(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return
+	}
+
+	inputs := repository.GetProductsInput{
+		OrderBy:    orderBy,
+		SortIn:     sortIn,
+		Name:       name,
+		MinPrice:   minPriceInt,
+		MaxPrice:   maxPriceInt,
+		Categories: r.URL.Query()["category"],
+		Attributes: attributes,
+		At:         at,
+		Locales:    locales,
+	}
 	products, err := h.repo.GetProducts(inputs)
 	if err != nil {
+		if isAttributeFilterError(err) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
 		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
 			Error: "failed to get products",
 		})
@@ -146,16 +250,311 @@ func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	}
 	items := make([]ProductResponse, len(products))
 	for i, product := range products {
-		items[i] = ProductResponse{
-			ID:         product.ID,
-			Name:       product.Name,
-			PriceCents: product.PriceCents,
+		items[i] = newProductResponse(product, prices)
+	}
+
+	resp := GetProductsResponse{Items: items}
+	if len(facets.Facets) > 0 {
+		buckets, err := h.repo.GetProductFacets(inputs, facets)
+		if err != nil {
+			if isAttributeFilterError(err) {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error: err.Error(),
+				})
+				return
+			}
+
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to get facets",
+			})
+			return
 		}
+		resp.Facets = newFacetsResponse(buckets)
 	}
 
-	writeJSON(w, http.StatusOK, map[string][]ProductResponse{
-		"items": items,
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	product, prices, ok := h.loadProduct(w, r, func(locales []string) (*domain.Product, error) {
+		return h.repo.GetProduct(id, locales)
+	})
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(*product, prices))
+}
+
+// GetProductBySlug looks a product up by its slug. Slugs the product had
+// before being renamed redirect permanently to the current one.
+func (h *Handler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	product, prices, ok := h.loadProduct(w, r, func(locales []string) (*domain.Product, error) {
+		return h.repo.GetProductBySlug(slug, locales)
 	})
+	if !ok {
+		return
+	}
+
+	if product.Slug != slug {
+		location := "/products/by-slug/" + url.PathEscape(product.Slug)
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Location", location)
+		writeJSON(w, http.StatusMovedPermanently, SlugRedirectResponse{
+			Slug: product.Slug,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(*product, prices))
+}
+
+// loadProduct resolves the request's currency and locales and loads the
+// product with them, writing the error response itself when that fails.
+func (h *Handler) loadProduct(
+	w http.ResponseWriter,
+	r *http.Request,
+	load func(locales []string) (*domain.Product, error),
+) (*domain.Product, money.Converter, bool) {
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return nil, money.Converter{}, false
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get product",
+		})
+		return nil, money.Converter{}, false
+	}
+
+	locales, err := requestLocales(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return nil, money.Converter{}, false
+	}
+
+	product, err := load(locales)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return nil, money.Converter{}, false
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get product",
+		})
+		return nil, money.Converter{}, false
+	}
+	return product, prices, true
+}
+
+func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	data, ok := decodeProductRequest(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.repo.CreateProduct(data, currentUser(r).ID)
+	if err != nil {
+		if writeProductValidationError(w, err) {
+			return
+		}
+		if err == repository.ErrProductAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "product already exists",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create product",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newProductResponse(*product, h.baseConverter()))
+}
+
+func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	data, ok := decodeProductRequest(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.repo.UpdateProduct(id, data, currentUser(r).ID)
+	if err != nil {
+		if writeProductValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+		if err == repository.ErrProductAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "product already exists",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update product",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(*product, h.baseConverter()))
+}
+
+// decodeProductRequest writes a 400 response and returns false when the
+// body is not a valid product.
+func decodeProductRequest(w http.ResponseWriter, r *http.Request) (domain.Product, bool) {
+	var data ProductRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.Product{}, false
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return domain.Product{}, false
+	}
+
+	if data.PriceCents < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "price_cents must not be negative",
+		})
+		return domain.Product{}, false
+	}
+
+	if data.WeightGrams < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "weight_grams must not be negative",
+		})
+		return domain.Product{}, false
+	}
+
+	if data.SKU != nil && strings.TrimSpace(*data.SKU) == "" {
+		data.SKU = nil
+	}
+
+	return domain.Product{
+		SKU:           data.SKU,
+		Name:          data.Name,
+		Description:   strings.TrimSpace(data.Description),
+		Category:      strings.TrimSpace(data.Category),
+		PriceCents:    data.PriceCents,
+		TaxCategory:   strings.TrimSpace(data.TaxCategory),
+		WeightGrams:   data.WeightGrams,
+		ProductTypeID: data.ProductTypeID,
+		Attributes:    data.Attributes,
+	}, true
+}
+
+// writeProductValidationError writes a 400 response and returns true when
+// err is about the product's type or attribute values.
+func writeProductValidationError(w http.ResponseWriter, err error) bool {
+	var attributeErr *domain.AttributeError
+	if errors.As(err, &attributeErr) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: attributeErr.Error(),
+		})
+		return true
+	}
+	if err == repository.ErrProductTypeNotFound {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "unknown product type",
+		})
+		return true
+	}
+	return false
+}
+
+func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteProduct(id, currentUser(r).ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete product",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newProductResponse(product domain.Product, prices money.Converter) ProductResponse {
+	attributes := product.Attributes
+	if attributes == nil {
+		attributes = domain.Attributes{}
+	}
+
+	current := prices.Convert(product.CurrentPriceCents)
+	return ProductResponse{
+		ID:                  product.ID,
+		Slug:                product.Slug,
+		Name:                product.LocalizedName,
+		Description:         product.LocalizedDescription,
+		Category:            product.Category,
+		Currency:            current.Currency,
+		PriceCents:          current.Amount,
+		OriginalPriceCents:  prices.Convert(product.RegularPriceCents).Amount,
+		OnSale:              product.CurrentPriceCents < product.RegularPriceCents,
+		LowestPrice30dCents: prices.ConvertPtr(product.LowestPrice30dCents),
+		AvailableQuantity:   product.AvailableQuantity,
+		TaxCategory:         product.TaxCategory,
+		WeightGrams:         product.WeightGrams,
+		ProductTypeID:       product.ProductTypeID,
+		Attributes:          attributes,
+		RatingAverage:       product.RatingAverage,
+		ReviewCount:         product.ReviewCount,
+	}
 }
 
 func parseOptionalInt64(value string, defaultValue int64) (int64, error) {
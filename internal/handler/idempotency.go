@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader lets a client retry a request without it taking
+// effect twice; see Idempotent. Replayed responses carry
+// IdempotentReplayedHeader.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// DefaultIdempotencyKeyTTL is how long a key is remembered unless
+// SetIdempotencyKeyTTL says otherwise.
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
+func (h *Handler) SetIdempotencyKeyTTL(ttl time.Duration) {
+	h.idempotencyTTL = ttl
+}
+
+// Idempotent makes a request with an Idempotency-Key header take effect
+// once. A retry with the same key and body gets the first response back
+// instead of being handled again; the same key with a different body is
+// rejected with 422, and a retry while the first request is still being
+// handled with 409. Keys are scoped to the user and the route, and are
+// forgotten after the TTL. Anonymous keys are scoped to the body too, so
+// reusing one with a different body is a new request rather than a 422.
+// Server errors aren't remembered, so the request can be retried.
+//
+// Mount it after RequireAuth, where there is one.
+func (h *Handler) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+			})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid request body",
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		// Anonymous callers can't be told apart, so their keys are scoped
+		// to the request itself: one caller's key never replays another's
+		// response.
+		scope := fmt.Sprintf("anonymous %s %s %s", r.Method, r.URL.Path, fingerprint)
+		if user := currentUser(r); user != nil {
+			scope = fmt.Sprintf("%d %s %s", user.ID, r.Method, r.URL.Path)
+		}
+
+		now := time.Now().UTC()
+		record, claimed, err := h.repo.ClaimIdempotencyKey(scope, key, fingerprint, now, now.Add(h.idempotencyTTL))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to check the idempotency key",
+			})
+			return
+		}
+
+		if !claimed {
+			if record.Fingerprint != fingerprint {
+				writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+					Error: fmt.Sprintf("%s was already used with a different request", IdempotencyKeyHeader),
+				})
+				return
+			}
+			if record.StatusCode == 0 {
+				writeJSON(w, http.StatusConflict, ErrorResponse{
+					Error: fmt.Sprintf("a request with this %s is still being handled", IdempotencyKeyHeader),
+				})
+				return
+			}
+
+			if record.ContentType != "" {
+				w.Header().Set("Content-Type", record.ContentType)
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+			return
+		}
+
+		// The key is released if the handler panics or fails, so that a
+		// retry isn't stuck behind it. Once the handler has succeeded it is
+		// kept: if the response can't be stored, retries get 409 until the
+		// key expires rather than running the request again.
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handled := false
+		defer func() {
+			if !handled {
+				_ = h.repo.ReleaseIdempotencyKey(record.ID)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+		if recorder.status >= http.StatusInternalServerError {
+			return
+		}
+		handled = true
+
+		contentType := recorder.Header().Get("Content-Type")
+		for range 2 {
+			if err := h.repo.CompleteIdempotencyKey(record.ID, recorder.status, contentType, recorder.body.Bytes()); err == nil {
+				break
+			}
+		}
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
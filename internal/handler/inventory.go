@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	stock, err := h.repo.GetStock(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "stock is not tracked for this product",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get stock",
+		})
+		return
+	}
+
+	response := newProductStockResponse(productID, stock)
+	threshold, err := h.repo.GetReorderThreshold(productID)
+	switch {
+	case err == nil:
+		response.ReorderThreshold = &threshold.Threshold
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get stock",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) SetStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	warehouseID, err := parseIDParam(r, "warehouseID")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid warehouse id",
+		})
+		return
+	}
+
+	var data StockRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.OnHand < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "on_hand must not be negative",
+		})
+		return
+	}
+
+	stock, err := h.repo.SetStock(productID, warehouseID, data.OnHand)
+	if err != nil {
+		if err == repository.ErrWarehouseNotFound {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+		if err == repository.ErrStockBelowReserved {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to set stock",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStockResponse(*stock))
+}
+
+func (h *Handler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	warehouseID, err := parseIDParam(r, "warehouseID")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid warehouse id",
+		})
+		return
+	}
+
+	var data AdjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	stock, err := h.repo.AdjustStock(productID, warehouseID, data.Delta)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "stock is not tracked for this product in this warehouse",
+			})
+			return
+		}
+		if err == repository.ErrStockBelowReserved {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to adjust stock",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStockResponse(*stock))
+}
+
+func newStockResponse(stock domain.StockLevel) StockResponse {
+	return StockResponse{
+		ProductID:     stock.ProductID,
+		WarehouseID:   stock.WarehouseID,
+		WarehouseName: stock.Warehouse.Name,
+		OnHand:        stock.OnHand,
+		Reserved:      stock.Reserved,
+		Available:     stock.Available(),
+		UpdatedAt:     stock.UpdatedAt,
+	}
+}
+
+// newProductStockResponse totals a product's stock over its warehouses.
+func newProductStockResponse(productID uint, levels []domain.StockLevel) ProductStockResponse {
+	response := ProductStockResponse{
+		ProductID:  productID,
+		Warehouses: make([]StockResponse, len(levels)),
+	}
+	for i, stock := range levels {
+		response.OnHand += stock.OnHand
+		response.Reserved += stock.Reserved
+		response.Available += stock.Available()
+		response.Warehouses[i] = newStockResponse(stock)
+	}
+	return response
+}
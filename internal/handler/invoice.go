@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/invoice"
+	"gorm.io/gorm"
+)
+
+// GetOrderInvoice responds with the invoice for one of the user's orders
+// as a PDF. Orders get their invoice when they are paid.
+func (h *Handler) GetOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	order, err := h.repo.GetOrder(currentUser(r).ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get invoice",
+		})
+		return
+	}
+	if order.Invoice == nil {
+		writeJSON(w, http.StatusConflict, ErrorResponse{
+			Error: "the order has no invoice until it is paid",
+		})
+		return
+	}
+
+	pdf, err := invoice.Render(*order.Invoice, *order)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to render invoice",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="invoice-%s.pdf"`, order.Invoice.Number))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pdf)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"golang.org/x/text/language"
+)
+
+// maxLocales bounds how many locales a request can ask to fall back
+// through, since each one adds to the product query.
+const maxLocales = 8
+
+// requestLocales returns the locales to show product content in, most
+// preferred first, each followed by its less specific forms. The locale
+// query parameter takes precedence over Accept-Language. An invalid
+// Accept-Language header is ignored, so content falls back to the default.
+func requestLocales(r *http.Request) ([]string, error) {
+	if value := r.URL.Query().Get("locale"); value != "" {
+		locale, err := domain.NormalizeLocale(value)
+		if err != nil {
+			return nil, err
+		}
+		return domain.LocaleFallbacks(locale), nil
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return nil, nil
+	}
+
+	var locales []string
+	for _, tag := range tags {
+		for _, locale := range domain.LocaleFallbacks(tag.String()) {
+			if !slices.Contains(locales, locale) && len(locales) < maxLocales {
+				locales = append(locales, locale)
+			}
+		}
+	}
+	return locales, nil
+}
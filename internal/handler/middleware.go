@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/auth"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// RequireAuth rejects requests without a valid bearer token and stores the
+// authenticated user in the request context.
+func (h *Handler) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+				Error: "missing bearer token",
+			})
+			return
+		}
+
+		userID, err := auth.ParseJWTToken(token)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+				Error: "invalid token",
+			})
+			return
+		}
+
+		user, err := h.repo.GetUserByID(userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+					Error: "invalid token",
+				})
+				return
+			}
+
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to find the user",
+			})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuth authenticates requests that carry a bearer token, like
+// RequireAuth, and lets requests without one through anonymously.
+func (h *Handler) OptionalAuth(next http.Handler) http.Handler {
+	requireAuth := h.RequireAuth(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		requireAuth.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin must be mounted after RequireAuth.
+func (h *Handler) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		if user == nil || !user.IsAdmin {
+			writeJSON(w, http.StatusForbidden, ErrorResponse{
+				Error: "admin access required",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func currentUser(r *http.Request) *domain.User {
+	user, _ := r.Context().Value(userContextKey).(*domain.User)
+	return user
+}
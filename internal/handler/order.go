@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/allocation"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/shipping"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var data CreateOrderRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if len(data.Items) == 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "items required",
+		})
+		return
+	}
+
+	items := make([]repository.OrderItemInput, len(data.Items))
+	for i, item := range data.Items {
+		if item.Quantity <= 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "quantity must be positive",
+			})
+			return
+		}
+		items[i] = repository.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	opts, ok := orderOptions(w, data.ShipTo, data.TaxAddress)
+	if !ok {
+		return
+	}
+	opts.CouponCode = data.CouponCode
+	opts.AddressID = data.AddressID
+	opts.ShippingMethodID = data.ShippingMethodID
+
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create order",
+		})
+		return
+	}
+
+	order, err := h.repo.CreateOrder(currentUser(r).ID, items, prices, opts)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) ||
+			errors.Is(err, repository.ErrCouponNotFound) ||
+			errors.Is(err, repository.ErrAddressNotFound) ||
+			errors.Is(err, repository.ErrShippingAddressRequired) ||
+			errors.Is(err, repository.ErrShippingMethodRequired) ||
+			errors.Is(err, repository.ErrTaxAddressRequired) ||
+			errors.Is(err, repository.ErrTaxAddressMismatch) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) ||
+			errors.Is(err, promotion.ErrNotApplicable) ||
+			errors.Is(err, shipping.ErrUnavailable) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, repository.ErrTaxUnavailable) {
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newOrderResponse(*order))
+}
+
+// orderOptions validates where an order is going, writing the error
+// response itself when it is invalid.
+func orderOptions(w http.ResponseWriter, shipTo *LocationRequest, taxAddress *TaxAddressRequest) (repository.OrderOptions, bool) {
+	var opts repository.OrderOptions
+	if shipTo != nil {
+		if !validCoordinates(shipTo.Latitude, shipTo.Longitude) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid ship_to coordinates",
+			})
+			return opts, false
+		}
+		opts.ShipTo = &allocation.Location{Latitude: shipTo.Latitude, Longitude: shipTo.Longitude}
+	}
+	if taxAddress != nil {
+		address := tax.Address{
+			Country: strings.ToUpper(strings.TrimSpace(taxAddress.Country)),
+			Region:  strings.ToUpper(strings.TrimSpace(taxAddress.Region)),
+		}
+		if len(address.Country) != 2 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "tax_address country must be a two-letter code",
+			})
+			return opts, false
+		}
+		opts.TaxAddress = &address
+	}
+	return opts, true
+}
+
+func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := h.repo.GetOrders(currentUser(r).ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get orders",
+		})
+		return
+	}
+
+	items := make([]OrderResponse, len(orders))
+	for i, order := range orders {
+		items[i] = newOrderResponse(order)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]OrderResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	order, err := h.repo.GetOrder(currentUser(r).ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newOrderResponse(*order))
+}
+
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	order, err := h.repo.CancelOrder(currentUser(r).ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidOrderTransition) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "the order can no longer be cancelled",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrPaymentInProgress) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to cancel order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newOrderResponse(*order))
+}
+
+func newOrderResponse(order domain.Order) OrderResponse {
+	resp := OrderResponse{
+		ID:               order.ID,
+		Status:           string(order.Status),
+		Currency:         order.Currency,
+		SubtotalCents:    order.SubtotalCents,
+		DiscountCents:    order.DiscountCents,
+		TaxCents:         order.TaxCents,
+		PricesIncludeTax: order.PricesIncludeTax,
+		ShippingCents:    order.ShippingCents,
+		TotalCents:       order.TotalCents,
+		Items:            make([]OrderItemResponse, len(order.Items)),
+		Discounts:        make([]DiscountResponse, len(order.Discounts)),
+		Taxes:            make([]OrderTaxResponse, len(order.Taxes)),
+		Refunds:          make([]RefundResponse, len(order.Refunds)),
+		CreatedAt:        order.CreatedAt,
+	}
+	if order.ShippingAddress.Country != "" {
+		address := newPostalAddressResponse(order.ShippingAddress)
+		resp.ShippingAddress = &address
+	}
+	if order.Invoice != nil {
+		resp.InvoiceNumber = &order.Invoice.Number
+	}
+	if order.ShippingMethodName != "" {
+		resp.Shipping = &OrderShippingResponse{
+			MethodID: order.ShippingMethodID,
+			Name:     order.ShippingMethodName,
+		}
+	}
+	for i, item := range order.Items {
+		resp.Items[i] = OrderItemResponse{
+			ProductID:      item.ProductID,
+			ProductName:    item.ProductName,
+			UnitPriceCents: item.UnitPriceCents,
+			Quantity:       item.Quantity,
+			LineTotalCents: item.LineTotalCents,
+		}
+	}
+	for i, discount := range order.Discounts {
+		resp.Discounts[i] = DiscountResponse{
+			Code:        discount.Code,
+			Kind:        discount.Kind,
+			ProductID:   discount.ProductID,
+			Description: discount.Description,
+			AmountCents: discount.AmountCents,
+		}
+	}
+	for i, line := range order.Taxes {
+		resp.Taxes[i] = OrderTaxResponse{
+			ProductID:    line.ProductID,
+			Category:     line.Category,
+			Name:         line.Name,
+			Country:      line.Country,
+			Region:       line.Region,
+			Rate:         line.Rate,
+			TaxableCents: line.TaxableCents,
+			TaxCents:     line.TaxCents,
+		}
+	}
+	for i, refund := range order.Refunds {
+		resp.Refunds[i] = newRefundResponse(refund)
+		if refund.Status == domain.RefundSucceeded {
+			resp.RefundedCents += refund.AmountCents
+		}
+	}
+	return resp
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) TransitionOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	var data OrderTransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	status := domain.OrderStatus(data.Status)
+	if !status.IsValid() {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid status",
+		})
+		return
+	}
+
+	order, err := h.repo.TransitionOrder(id, status, currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrRefundByRefundOnly) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "orders are refunded through POST /admin/orders/{id}/refunds",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidOrderTransition) ||
+			errors.Is(err, repository.ErrInsufficientStock) ||
+			errors.Is(err, repository.ErrPaymentInProgress) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newOrderResponse(*order))
+}
+
+func (h *Handler) GetOrderTransitions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	transitions, err := h.repo.GetOrderTransitions(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get order transitions",
+		})
+		return
+	}
+
+	items := make([]OrderTransitionResponse, len(transitions))
+	for i, transition := range transitions {
+		items[i] = OrderTransitionResponse{
+			From:      string(transition.From),
+			To:        string(transition.To),
+			ActorID:   transition.ActorID,
+			CreatedAt: transition.CreatedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]OrderTransitionResponse{
+		"items": items,
+	})
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxWebhookBytes bounds the webhook bodies that are read and verified.
+const maxWebhookBytes = 1 << 20
+
+// PayOrder charges a card for the order. A payment that is accepted is
+// processing until the provider confirms it through a webhook; a declined
+// card is 402 and the order can be paid again.
+func (h *Handler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	var data PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.CardNumber == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "card_number required",
+		})
+		return
+	}
+
+	p, err := h.repo.PayOrder(currentUser(r).ID, id, h.payments, payment.Card{Number: data.CardNumber})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+		if errors.Is(err, payment.ErrDeclined) {
+			writeJSON(w, http.StatusPaymentRequired, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidOrderTransition) ||
+			errors.Is(err, repository.ErrPaymentInProgress) ||
+			errors.Is(err, repository.ErrInsufficientStock) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to pay order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newPaymentResponse(*p))
+}
+
+func (h *Handler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	payments, err := h.repo.GetPayments(currentUser(r).ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get payments",
+		})
+		return
+	}
+
+	items := make([]PaymentResponse, len(payments))
+	for i, p := range payments {
+		items[i] = newPaymentResponse(p)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]PaymentResponse{
+		"items": items,
+	})
+}
+
+// HandlePaymentWebhook receives events from the payment provider. Their
+// signature is checked by the gateway, and events that were already
+// handled are acknowledged without being applied again.
+func (h *Handler) HandlePaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+	if err := h.payments.VerifyWebhook(r.Header.Get(payment.SignatureHeader), body, time.Now()); err != nil {
+		if errors.Is(err, payment.ErrWebhooksNotConfigured) {
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var event payment.Event
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Reference == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid event",
+		})
+		return
+	}
+
+	if err := h.repo.HandlePaymentEvent(h.payments.Name(), event); err != nil {
+		if errors.Is(err, repository.ErrPaymentEventReplayed) {
+			writeJSON(w, http.StatusOK, PaymentWebhookResponse{Status: "duplicate"})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "payment not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidOrderTransition) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to handle event",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PaymentWebhookResponse{Status: "processed"})
+}
+
+func newPaymentResponse(p domain.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:            p.ID,
+		OrderID:       p.OrderID,
+		Provider:      p.Provider,
+		Reference:     p.Reference,
+		Status:        string(p.Status),
+		AmountCents:   p.AmountCents,
+		Currency:      p.Currency,
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt,
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetProductRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	revisions, err := h.repo.GetProductRevisions(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get revisions",
+		})
+		return
+	}
+
+	items := make([]ProductRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		items[i] = newProductRevisionResponse(revision)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ProductRevisionResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) GetProductRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid from",
+		})
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid to",
+		})
+		return
+	}
+
+	fromRevision, err := h.repo.GetProductRevision(id, from)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	toRevision, err := h.repo.GetProductRevision(id, to)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	resp := ProductRevisionDiffResponse{From: from, To: to, Changes: []FieldChangeResponse{}}
+	for _, change := range fromRevision.Snapshot.Diff(toRevision.Snapshot) {
+		resp.Changes = append(resp.Changes, FieldChangeResponse{
+			Field: change.Field,
+			From:  change.From,
+			To:    change.To,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) RollbackProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid version",
+		})
+		return
+	}
+
+	product, err := h.repo.RollbackProduct(id, version, currentUser(r).ID)
+	if err != nil {
+		if err == repository.ErrProductAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "an active product already uses this name or sku",
+			})
+			return
+		}
+
+		writeRevisionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(*product, h.baseConverter()))
+}
+
+func writeRevisionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "product or revision not found",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+		Error: "failed to get revision",
+	})
+}
+
+func newProductRevisionResponse(revision domain.ProductRevision) ProductRevisionResponse {
+	return ProductRevisionResponse{
+		Version: revision.Version,
+		Action:  string(revision.Action),
+		ActorID: revision.ActorID,
+		Snapshot: ProductSnapshotResponse{
+			Name:          revision.Snapshot.Name,
+			Description:   revision.Snapshot.Description,
+			SKU:           revision.Snapshot.SKU,
+			Category:      revision.Snapshot.Category,
+			PriceCents:    revision.Snapshot.PriceCents,
+			TaxCategory:   revision.Snapshot.TaxCategory,
+			WeightGrams:   revision.Snapshot.WeightGrams,
+			ProductTypeID: revision.Snapshot.ProductTypeID,
+			Attributes:    revision.Snapshot.Attributes,
+		},
+		CreatedAt: revision.CreatedAt,
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetProductTranslations(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	translations, err := h.repo.GetProductTranslations(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get translations",
+		})
+		return
+	}
+
+	items := make([]ProductTranslationResponse, len(translations))
+	for i, translation := range translations {
+		items[i] = newProductTranslationResponse(translation)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ProductTranslationResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) SetProductTranslation(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	locale, err := domain.NormalizeLocale(chi.URLParam(r, "locale"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return
+	}
+
+	var data ProductTranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+	data.Description = strings.TrimSpace(data.Description)
+	if data.Name == "" && data.Description == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name or description required",
+		})
+		return
+	}
+
+	translation, err := h.repo.SetProductTranslation(domain.ProductTranslation{
+		ProductID:   productID,
+		Locale:      locale,
+		Name:        data.Name,
+		Description: data.Description,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to save translation",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductTranslationResponse(*translation))
+}
+
+func (h *Handler) DeleteProductTranslation(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	locale, err := domain.NormalizeLocale(chi.URLParam(r, "locale"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteProductTranslation(productID, locale); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "translation not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete translation",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newProductTranslationResponse(translation domain.ProductTranslation) ProductTranslationResponse {
+	return ProductTranslationResponse{
+		Locale:      translation.Locale,
+		Name:        translation.Name,
+		Description: translation.Description,
+	}
+}
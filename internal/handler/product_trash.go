@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.repo.GetDeletedProducts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get deleted products",
+		})
+		return
+	}
+
+	items := make([]DeletedProductResponse, len(products))
+	for i, product := range products {
+		items[i] = DeletedProductResponse{
+			ID:         product.ID,
+			Name:       product.Name,
+			PriceCents: product.PriceCents,
+			DeletedAt:  product.DeletedAt.Time,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]DeletedProductResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	product, err := h.repo.RestoreProduct(id, currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "deleted product not found",
+			})
+			return
+		}
+		if err == repository.ErrProductAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "an active product already uses this name or sku",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to restore product",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(*product, h.baseConverter()))
+}
+
+func (h *Handler) PurgeProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	if err := h.repo.PurgeProduct(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "deleted product not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrProductInUse) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to purge product",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) EmptyProductTrash(w http.ResponseWriter, r *http.Request) {
+	purged, err := h.repo.EmptyProductTrash()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to empty trash",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PurgeProductsResponse{Purged: purged})
+}
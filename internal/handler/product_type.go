@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+)
+
+func (h *Handler) GetProductTypes(w http.ResponseWriter, r *http.Request) {
+	productTypes, err := h.repo.GetProductTypes()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get product types",
+		})
+		return
+	}
+
+	items := make([]ProductTypeResponse, len(productTypes))
+	for i, productType := range productTypes {
+		items[i] = newProductTypeResponse(productType)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ProductTypeResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) CreateProductType(w http.ResponseWriter, r *http.Request) {
+	var data ProductTypeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	productType := domain.ProductType{Name: strings.TrimSpace(data.Name)}
+	if productType.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return
+	}
+
+	seen := make(map[string]bool, len(data.Attributes))
+	for _, attribute := range data.Attributes {
+		definition := domain.AttributeDefinition{
+			Name:     attribute.Name,
+			Type:     domain.AttributeType(attribute.Type),
+			Required: attribute.Required,
+		}
+		if !domain.IsValidAttributeName(definition.Name) || seen[definition.Name] {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid attribute name " + strconv.Quote(definition.Name),
+			})
+			return
+		}
+		if !definition.Type.IsValid() {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid type for attribute " + definition.Name,
+			})
+			return
+		}
+		if definition.Type == domain.AttributeEnum {
+			if len(attribute.Options) == 0 {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error: "enum attribute " + definition.Name + " needs options",
+				})
+				return
+			}
+			definition.Options = attribute.Options
+		}
+
+		seen[definition.Name] = true
+		productType.Attributes = append(productType.Attributes, definition)
+	}
+
+	created, err := h.repo.CreateProductType(productType)
+	if err != nil {
+		if err == repository.ErrProductTypeAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "product type already exists",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrAttributeTypeConflict) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create product type",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newProductTypeResponse(*created))
+}
+
+func newProductTypeResponse(productType domain.ProductType) ProductTypeResponse {
+	resp := ProductTypeResponse{
+		ID:         productType.ID,
+		Name:       productType.Name,
+		Attributes: make([]AttributeDefinitionResponse, len(productType.Attributes)),
+	}
+	for i, attribute := range productType.Attributes {
+		resp.Attributes[i] = AttributeDefinitionResponse{
+			Name:     attribute.Name,
+			Type:     string(attribute.Type),
+			Required: attribute.Required,
+			Options:  attribute.Options,
+		}
+	}
+	return resp
+}
+
+// parseAttributeFilters reads attr.<name>=<value> (repeatable) and
+// attr.<name>.min / attr.<name>.max query parameters.
+func parseAttributeFilters(r *http.Request) ([]repository.AttributeFilter, error) {
+	filters := make(map[string]*repository.AttributeFilter)
+	filter := func(name string) (*repository.AttributeFilter, error) {
+		if !domain.IsValidAttributeName(name) {
+			return nil, errors.New("invalid attribute filter " + strconv.Quote(name))
+		}
+		if filters[name] == nil {
+			filters[name] = &repository.AttributeFilter{Name: name}
+		}
+		return filters[name], nil
+	}
+
+	for key, values := range r.URL.Query() {
+		rest, ok := strings.CutPrefix(key, "attr.")
+		if !ok {
+			continue
+		}
+
+		name, bound, hasBound := strings.Cut(rest, ".")
+		f, err := filter(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasBound {
+			f.Values = append(f.Values, values...)
+			continue
+		}
+
+		value, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || (bound != "min" && bound != "max") {
+			return nil, errors.New("invalid attribute filter " + strconv.Quote(key))
+		}
+		if bound == "min" {
+			f.Min = &value
+		} else {
+			f.Max = &value
+		}
+	}
+
+	result := make([]repository.AttributeFilter, 0, len(filters))
+	for _, f := range filters {
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func isAttributeFilterError(err error) bool {
+	return errors.Is(err, repository.ErrUnknownAttribute) || errors.Is(err, repository.ErrInvalidAttributeFilter)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRelatedLimit = 4
+	maxRelatedLimit     = 20
+)
+
+func (h *Handler) GetRelatedProducts(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	limit, err := parseOptionalInt64(r.URL.Query().Get("limit"), defaultRelatedLimit)
+	if err != nil || limit < 1 || limit > maxRelatedLimit {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "limit must be between 1 and 20",
+		})
+		return
+	}
+
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get related products",
+		})
+		return
+	}
+
+	locales, err := requestLocales(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid locale",
+		})
+		return
+	}
+
+	related, err := h.repo.GetRelatedProducts(id, int(limit), locales)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get related products",
+		})
+		return
+	}
+
+	items := make([]RelatedProductResponse, len(related))
+	for i, product := range related {
+		items[i] = RelatedProductResponse{
+			ProductResponse: newProductResponse(product.Product, prices),
+			Reason:          string(product.Reason),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]RelatedProductResponse{
+		"items": items,
+	})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid order id",
+		})
+		return
+	}
+
+	var data RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if len(data.Items) > 0 && data.AmountCents != 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "refund either items or an amount",
+		})
+		return
+	}
+	if data.AmountCents < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "amount_cents must be positive",
+		})
+		return
+	}
+
+	input := repository.RefundInput{
+		Items:       make([]repository.RefundItemInput, len(data.Items)),
+		AmountCents: data.AmountCents,
+		Reason:      data.Reason,
+		Restock:     data.Restock,
+	}
+	for i, item := range data.Items {
+		if item.Quantity <= 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "quantity must be positive",
+			})
+			return
+		}
+		input.Items[i] = repository.RefundItemInput{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	refund, err := h.repo.RefundOrder(id, input, h.payments, currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "order not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidRefund) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrNothingToRefund) {
+			writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrOrderNotRefundable) ||
+			errors.Is(err, repository.ErrRefundTooLarge) ||
+			errors.Is(err, repository.ErrInvalidOrderTransition) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrRefundFailed) {
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to refund order",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newRefundResponse(*refund))
+}
+
+func newRefundResponse(refund domain.Refund) RefundResponse {
+	resp := RefundResponse{
+		ID:            refund.ID,
+		Status:        string(refund.Status),
+		AmountCents:   refund.AmountCents,
+		Reason:        refund.Reason,
+		Restocked:     refund.Restocked,
+		FailureReason: refund.FailureReason,
+		Items:         make([]RefundItemResponse, len(refund.Items)),
+		CreatedAt:     refund.CreatedAt,
+	}
+	for i, item := range refund.Items {
+		resp.Items[i] = RefundItemResponse{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	return resp
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+const maxReviewLength = 5000
+
+func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	var data CreateReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.Rating < 1 || data.Rating > 5 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "rating must be between 1 and 5",
+		})
+		return
+	}
+
+	data.Body = strings.TrimSpace(data.Body)
+	if len(data.Body) > maxReviewLength {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "review is too long",
+		})
+		return
+	}
+
+	user := currentUser(r)
+	review, err := h.repo.CreateReview(domain.Review{
+		ProductID: productID,
+		UserID:    user.ID,
+		Rating:    data.Rating,
+		Body:      data.Body,
+	})
+	if err != nil {
+		if err == repository.ErrProductNotFound {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+		if err == repository.ErrReviewAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: "you have already reviewed this product",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create review",
+		})
+		return
+	}
+
+	review.User = *user
+	writeJSON(w, http.StatusCreated, newReviewResponse(*review))
+}
+
+func (h *Handler) GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	reviews, err := h.repo.GetProductReviews(productID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get reviews",
+		})
+		return
+	}
+
+	writeReviews(w, reviews)
+}
+
+// GetReviewQueue lists reviews by moderation status, pending by default.
+func (h *Handler) GetReviewQueue(w http.ResponseWriter, r *http.Request) {
+	status := domain.ReviewStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = domain.ReviewPending
+	case domain.ReviewPending, domain.ReviewApproved, domain.ReviewRejected:
+	default:
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid status",
+		})
+		return
+	}
+
+	reviews, err := h.repo.GetReviewsByStatus(status)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get reviews",
+		})
+		return
+	}
+
+	writeReviews(w, reviews)
+}
+
+func (h *Handler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, domain.ReviewApproved)
+}
+
+func (h *Handler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, domain.ReviewRejected)
+}
+
+func (h *Handler) moderateReview(w http.ResponseWriter, r *http.Request, status domain.ReviewStatus) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid review id",
+		})
+		return
+	}
+
+	review, err := h.repo.ModerateReview(id, status, currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "review not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to moderate review",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newReviewResponse(*review))
+}
+
+func writeReviews(w http.ResponseWriter, reviews []domain.Review) {
+	items := make([]ReviewResponse, len(reviews))
+	for i, review := range reviews {
+		items[i] = newReviewResponse(review)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ReviewResponse{
+		"items": items,
+	})
+}
+
+func newReviewResponse(review domain.Review) ReviewResponse {
+	return ReviewResponse{
+		ID:               review.ID,
+		ProductID:        review.ProductID,
+		UserName:         review.User.UserName,
+		Rating:           review.Rating,
+		Body:             review.Body,
+		VerifiedPurchase: review.VerifiedPurchase,
+		Status:           string(review.Status),
+		CreatedAt:        review.CreatedAt,
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) CreateScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	var data ScheduledPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	kind := domain.PriceKind(data.Kind)
+	if !kind.IsValid() {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "kind must be regular or sale",
+		})
+		return
+	}
+
+	if data.PriceCents < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "price_cents must not be negative",
+		})
+		return
+	}
+
+	startsAt := time.Now()
+	if data.StartsAt != nil {
+		startsAt = *data.StartsAt
+	}
+	if data.EndsAt != nil && !data.EndsAt.After(startsAt) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "ends_at must be after starts_at",
+		})
+		return
+	}
+
+	price, err := h.repo.CreateScheduledPrice(domain.ScheduledPrice{
+		ProductID:  productID,
+		Kind:       kind,
+		PriceCents: data.PriceCents,
+		StartsAt:   startsAt,
+		EndsAt:     data.EndsAt,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create scheduled price",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newScheduledPriceResponse(*price, time.Now()))
+}
+
+func (h *Handler) GetScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	prices, err := h.repo.GetScheduledPrices(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get scheduled prices",
+		})
+		return
+	}
+
+	now := time.Now()
+	items := make([]ScheduledPriceResponse, len(prices))
+	for i, price := range prices {
+		items[i] = newScheduledPriceResponse(price, now)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ScheduledPriceResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) DeleteScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	priceID, err := parseIDParam(r, "priceID")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid price id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteScheduledPrice(productID, priceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "scheduled price not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete scheduled price",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	changes, err := h.repo.GetPriceHistory(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get price history",
+		})
+		return
+	}
+
+	items := make([]PriceChangeResponse, len(changes))
+	for i, change := range changes {
+		items[i] = PriceChangeResponse{
+			PriceCents:         change.PriceCents,
+			OriginalPriceCents: change.RegularPriceCents,
+			EffectiveFrom:      change.EffectiveFrom,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]PriceChangeResponse{
+		"items": items,
+	})
+}
+
+func newScheduledPriceResponse(price domain.ScheduledPrice, now time.Time) ScheduledPriceResponse {
+	return ScheduledPriceResponse{
+		ID:         price.ID,
+		ProductID:  price.ProductID,
+		Kind:       string(price.Kind),
+		PriceCents: price.PriceCents,
+		StartsAt:   price.StartsAt,
+		EndsAt:     price.EndsAt,
+		Active:     price.IsActiveAt(now),
+	}
+}
@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/shipping"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetShippingZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.repo.GetShippingZones()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get shipping zones",
+		})
+		return
+	}
+
+	items := make([]ShippingZoneResponse, len(zones))
+	for i, zone := range zones {
+		items[i] = newShippingZoneResponse(zone)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ShippingZoneResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) CreateShippingZone(w http.ResponseWriter, r *http.Request) {
+	zone, ok := decodeShippingZone(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateShippingZone(zone)
+	if err != nil {
+		if errors.Is(err, repository.ErrShippingZoneAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create shipping zone",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newShippingZoneResponse(*created))
+}
+
+func (h *Handler) UpdateShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid shipping zone id",
+		})
+		return
+	}
+
+	zone, ok := decodeShippingZone(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateShippingZone(id, zone)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "shipping zone not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrShippingZoneAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update shipping zone",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newShippingZoneResponse(*updated))
+}
+
+func (h *Handler) DeleteShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid shipping zone id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteShippingZone(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "shipping zone not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete shipping zone",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CreateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid shipping zone id",
+		})
+		return
+	}
+
+	method, ok := decodeShippingMethod(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateShippingMethod(zoneID, method)
+	if err != nil {
+		if errors.Is(err, repository.ErrShippingZoneNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create shipping method",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newShippingMethodResponse(*created))
+}
+
+func (h *Handler) UpdateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid shipping method id",
+		})
+		return
+	}
+
+	method, ok := decodeShippingMethod(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateShippingMethod(id, method)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "shipping method not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update shipping method",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newShippingMethodResponse(*updated))
+}
+
+func (h *Handler) DeleteShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid shipping method id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteShippingMethod(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "shipping method not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete shipping method",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetShippingQuotes lists the shipping methods that can take the user's
+// cart to the address named by the address_id query parameter, priced in
+// the request's currency.
+func (h *Handler) GetShippingQuotes(w http.ResponseWriter, r *http.Request) {
+	addressID, err := strconv.ParseUint(r.URL.Query().Get("address_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "address_id required",
+		})
+		return
+	}
+
+	prices, err := h.priceConverter(r)
+	if err != nil {
+		if writeCurrencyError(w, err) {
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get shipping methods",
+		})
+		return
+	}
+
+	quotes, err := h.repo.GetShippingQuotes(currentUser(r).ID, uint(addressID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "address not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrCartEmpty) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get shipping methods",
+		})
+		return
+	}
+
+	items := make([]ShippingQuoteResponse, len(quotes))
+	for i, quote := range quotes {
+		cost := prices.Convert(quote.CostCents)
+		items[i] = ShippingQuoteResponse{
+			MethodID:  quote.Method.ID,
+			Name:      quote.Method.Name,
+			Currency:  cost.Currency,
+			CostCents: cost.Amount,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]ShippingQuoteResponse{
+		"items": items,
+	})
+}
+
+// decodeShippingZone reads and validates a ShippingZoneRequest, writing
+// the error response itself when the request is invalid.
+func decodeShippingZone(w http.ResponseWriter, r *http.Request) (domain.ShippingZone, bool) {
+	var data ShippingZoneRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.ShippingZone{}, false
+	}
+
+	zone := domain.ShippingZone{Name: strings.TrimSpace(data.Name)}
+	if zone.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return domain.ShippingZone{}, false
+	}
+	if len(data.Locations) == 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "at least one location required",
+		})
+		return domain.ShippingZone{}, false
+	}
+	for _, location := range data.Locations {
+		country := strings.ToUpper(strings.TrimSpace(location.Country))
+		if len(country) != 2 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "country must be a two-letter code",
+			})
+			return domain.ShippingZone{}, false
+		}
+		zone.Locations = append(zone.Locations, domain.ShippingZoneLocation{
+			Country: country,
+			Region:  strings.ToUpper(strings.TrimSpace(location.Region)),
+		})
+	}
+	return zone, true
+}
+
+// decodeShippingMethod reads and validates a ShippingMethodRequest,
+// writing the error response itself when the request is invalid.
+func decodeShippingMethod(w http.ResponseWriter, r *http.Request) (domain.ShippingMethod, bool) {
+	var data ShippingMethodRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.ShippingMethod{}, false
+	}
+
+	method := domain.ShippingMethod{
+		Name:  strings.TrimSpace(data.Name),
+		Basis: strings.TrimSpace(data.Basis),
+	}
+	if method.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return domain.ShippingMethod{}, false
+	}
+	if !shipping.Basis(method.Basis).IsValid() {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "basis must be weight or price",
+		})
+		return domain.ShippingMethod{}, false
+	}
+
+	rates := make([]shipping.Rate, len(data.Rates))
+	for i, rate := range data.Rates {
+		rates[i] = shipping.Rate{UpTo: rate.UpTo, CostCents: rate.CostCents}
+		method.Rates = append(method.Rates, domain.ShippingRate{UpTo: rate.UpTo, CostCents: rate.CostCents})
+	}
+	if err := shipping.ValidateRates(rates); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return domain.ShippingMethod{}, false
+	}
+	return method, true
+}
+
+func newShippingZoneResponse(zone domain.ShippingZone) ShippingZoneResponse {
+	resp := ShippingZoneResponse{
+		ID:        zone.ID,
+		Name:      zone.Name,
+		Locations: make([]ShippingLocationResponse, len(zone.Locations)),
+		Methods:   make([]ShippingMethodResponse, len(zone.Methods)),
+	}
+	for i, location := range zone.Locations {
+		resp.Locations[i] = ShippingLocationResponse{Country: location.Country, Region: location.Region}
+	}
+	for i, method := range zone.Methods {
+		resp.Methods[i] = newShippingMethodResponse(method)
+	}
+	return resp
+}
+
+func newShippingMethodResponse(method domain.ShippingMethod) ShippingMethodResponse {
+	resp := ShippingMethodResponse{
+		ID:     method.ID,
+		ZoneID: method.ZoneID,
+		Name:   method.Name,
+		Basis:  method.Basis,
+		Rates:  make([]ShippingRateResponse, len(method.Rates)),
+	}
+	for i, rate := range method.Rates {
+		resp.Rates[i] = ShippingRateResponse{UpTo: rate.UpTo, CostCents: rate.CostCents}
+	}
+	return resp
+}
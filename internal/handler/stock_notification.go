@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+// outboxPageSize caps how many outbox messages are listed at once.
+const outboxPageSize = 100
+
+func (h *Handler) SetReorderThreshold(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	var data ReorderThresholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if data.Threshold <= 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "threshold must be positive",
+		})
+		return
+	}
+
+	threshold, err := h.repo.SetReorderThreshold(productID, data.Threshold)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to set reorder threshold",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newReorderThresholdResponse(*threshold))
+}
+
+func (h *Handler) DeleteReorderThreshold(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteReorderThreshold(productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "reorder threshold not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete reorder threshold",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SubscribeToStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	subscription, err := h.repo.SubscribeToStock(productID, currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "product not found",
+			})
+			return
+		}
+		if err == repository.ErrProductInStock {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to subscribe",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StockSubscriptionResponse{
+		ProductID: subscription.ProductID,
+		CreatedAt: subscription.CreatedAt,
+	})
+}
+
+func (h *Handler) UnsubscribeFromStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid product id",
+		})
+		return
+	}
+
+	if err := h.repo.UnsubscribeFromStock(productID, currentUser(r).ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "subscription not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to unsubscribe",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOutboxMessages lists the latest notifications in the outbox. Messages
+// sent through another notification channel don't show up here.
+func (h *Handler) GetOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	messages, err := h.repo.GetOutboxMessages(outboxPageSize)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get notifications",
+		})
+		return
+	}
+
+	items := make([]OutboxMessageResponse, len(messages))
+	for i, message := range messages {
+		items[i] = OutboxMessageResponse{
+			ID:        message.ID,
+			Kind:      message.Kind,
+			Recipient: message.Recipient,
+			Subject:   message.Subject,
+			Body:      message.Body,
+			CreatedAt: message.CreatedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]OutboxMessageResponse{
+		"items": items,
+	})
+}
+
+func newReorderThresholdResponse(threshold domain.StockThreshold) ReorderThresholdResponse {
+	return ReorderThresholdResponse{
+		ProductID: threshold.ProductID,
+		Threshold: threshold.Threshold,
+		Alerted:   threshold.Alerted,
+		UpdatedAt: threshold.UpdatedAt,
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.repo.GetTaxRates()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get tax rates",
+		})
+		return
+	}
+
+	items := make([]TaxRateResponse, len(rates))
+	for i, rate := range rates {
+		items[i] = newTaxRateResponse(rate)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]TaxRateResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	rate, ok := decodeTaxRate(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateTaxRate(rate)
+	if err != nil {
+		if errors.Is(err, repository.ErrTaxRateAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create tax rate",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newTaxRateResponse(*created))
+}
+
+func (h *Handler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid tax rate id",
+		})
+		return
+	}
+
+	rate, ok := decodeTaxRate(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateTaxRate(id, rate)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "tax rate not found",
+			})
+			return
+		}
+		if errors.Is(err, repository.ErrTaxRateAlreadyExists) {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update tax rate",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newTaxRateResponse(*updated))
+}
+
+func (h *Handler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid tax rate id",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteTaxRate(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "tax rate not found",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to delete tax rate",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTaxRate reads and validates a TaxRateRequest, writing the error
+// response itself when the request is invalid.
+func decodeTaxRate(w http.ResponseWriter, r *http.Request) (domain.TaxRate, bool) {
+	var data TaxRateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.TaxRate{}, false
+	}
+
+	rate := domain.TaxRate{
+		Country:  strings.ToUpper(strings.TrimSpace(data.Country)),
+		Region:   strings.ToUpper(strings.TrimSpace(data.Region)),
+		Category: strings.TrimSpace(data.Category),
+		Name:     strings.TrimSpace(data.Name),
+		Rate:     strings.TrimSpace(data.Rate),
+	}
+
+	if len(rate.Country) != 2 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "country must be a two-letter code",
+		})
+		return domain.TaxRate{}, false
+	}
+	if rate.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return domain.TaxRate{}, false
+	}
+	if _, err := tax.ParseRate(rate.Rate); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return domain.TaxRate{}, false
+	}
+	return rate, true
+}
+
+func newTaxRateResponse(rate domain.TaxRate) TaxRateResponse {
+	return TaxRateResponse{
+		ID:       rate.ID,
+		Country:  rate.Country,
+		Region:   rate.Region,
+		Category: rate.Category,
+		Name:     rate.Name,
+		Rate:     rate.Rate,
+	}
+}
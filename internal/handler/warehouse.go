@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := h.repo.GetWarehouses()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to get warehouses",
+		})
+		return
+	}
+
+	items := make([]WarehouseResponse, len(warehouses))
+	for i, warehouse := range warehouses {
+		items[i] = newWarehouseResponse(warehouse)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]WarehouseResponse{
+		"items": items,
+	})
+}
+
+func (h *Handler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	warehouse, ok := decodeWarehouse(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.repo.CreateWarehouse(warehouse)
+	if err != nil {
+		if err == repository.ErrWarehouseAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to create warehouse",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newWarehouseResponse(*created))
+}
+
+func (h *Handler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid warehouse id",
+		})
+		return
+	}
+
+	warehouse, ok := decodeWarehouse(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.UpdateWarehouse(id, warehouse)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "warehouse not found",
+			})
+			return
+		}
+		if err == repository.ErrWarehouseAlreadyExists {
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to update warehouse",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWarehouseResponse(*updated))
+}
+
+// decodeWarehouse reads and validates a WarehouseRequest, writing the error
+// response itself when the request is invalid.
+func decodeWarehouse(w http.ResponseWriter, r *http.Request) (domain.Warehouse, bool) {
+	var data WarehouseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return domain.Warehouse{}, false
+	}
+
+	warehouse := domain.Warehouse{
+		Name:       strings.TrimSpace(data.Name),
+		Street:     strings.TrimSpace(data.Street),
+		City:       strings.TrimSpace(data.City),
+		PostalCode: strings.TrimSpace(data.PostalCode),
+		Country:    strings.ToUpper(strings.TrimSpace(data.Country)),
+		Latitude:   data.Latitude,
+		Longitude:  data.Longitude,
+		Priority:   data.Priority,
+	}
+
+	if warehouse.Name == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "name required",
+		})
+		return domain.Warehouse{}, false
+	}
+	if warehouse.Country != "" && len(warehouse.Country) != 2 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "country must be a two-letter code",
+		})
+		return domain.Warehouse{}, false
+	}
+	if !validCoordinates(warehouse.Latitude, warehouse.Longitude) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid coordinates",
+		})
+		return domain.Warehouse{}, false
+	}
+	return warehouse, true
+}
+
+func validCoordinates(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+func newWarehouseResponse(warehouse domain.Warehouse) WarehouseResponse {
+	return WarehouseResponse{
+		ID:         warehouse.ID,
+		Name:       warehouse.Name,
+		Street:     warehouse.Street,
+		City:       warehouse.City,
+		PostalCode: warehouse.PostalCode,
+		Country:    warehouse.Country,
+		Latitude:   warehouse.Latitude,
+		Longitude:  warehouse.Longitude,
+		Priority:   warehouse.Priority,
+	}
+}
@@ -0,0 +1,218 @@
+// Package invoice renders an order's invoice as a PDF.
+package invoice
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+)
+
+const (
+	margin     = 50
+	lineHeight = 14
+	fontSize   = 10
+	// The table's columns: the description starts at the margin and the
+	// others are right-aligned at these.
+	quantityRight = 350
+	unitRight     = 450
+	amountRight   = pageWidth - margin
+	// totalsLeft is where the labels of the totals under the table start.
+	totalsLeft = 280
+	// pageBottom is as low as lines go before moving to a new page.
+	pageBottom = 80
+)
+
+// Render draws the invoice for the order, which must have its items,
+// discounts and taxes loaded. It only draws what was recorded when the
+// order was placed and the invoice issued, so rendering it again gives the
+// same bytes.
+func Render(inv domain.Invoice, order domain.Order) ([]byte, error) {
+	currency, err := money.LookupCurrency(order.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	l := layout{currency: currency}
+	l.doc.addPage()
+	l.header(inv, order)
+	l.items(order)
+	l.totals(order)
+	l.footer(inv)
+	return l.doc.bytes("Invoice " + inv.Number), nil
+}
+
+type layout struct {
+	doc      document
+	currency money.Currency
+	y        float64
+}
+
+func (l *layout) header(inv domain.Invoice, order domain.Order) {
+	top := float64(pageHeight - margin - 20)
+	l.doc.text(bold, 20, margin, top, "INVOICE")
+
+	details := [][2]string{
+		{"Invoice number", inv.Number},
+		{"Issue date", inv.IssuedAt.UTC().Format("2006-01-02")},
+		{"Order", fmt.Sprintf("#%d", order.ID)},
+	}
+	for i, detail := range details {
+		y := top - float64(i)*lineHeight
+		l.doc.text(bold, fontSize, 340, y, detail[0])
+		l.doc.text(regular, fontSize, 440, y, detail[1])
+	}
+
+	var seller []string
+	if inv.SellerName != "" {
+		seller = append(seller, inv.SellerName)
+	}
+	seller = append(seller, splitLines(inv.SellerAddress)...)
+	if inv.SellerTaxID != "" {
+		seller = append(seller, "Tax ID: "+inv.SellerTaxID)
+	}
+	if inv.SellerEmail != "" {
+		seller = append(seller, inv.SellerEmail)
+	}
+
+	blockTop := top - 4*lineHeight - 10
+	fromEnd := l.block(margin, blockTop, "From", seller)
+	toEnd := l.block(340, blockTop, "Bill to", splitLines(inv.BillTo))
+	l.y = min(fromEnd, toEnd) - lineHeight
+}
+
+// block draws a heading with lines under it and returns where it ends.
+func (l *layout) block(x, y float64, heading string, lines []string) float64 {
+	l.doc.text(bold, fontSize, x, y, heading)
+	for _, line := range lines {
+		y -= lineHeight
+		l.doc.text(regular, fontSize, x, y, truncate(line, 40))
+	}
+	return y - lineHeight
+}
+
+func (l *layout) items(order domain.Order) {
+	l.tableHeader()
+	for _, item := range order.Items {
+		l.next()
+		l.doc.text(regular, fontSize, margin, l.y, truncate(item.ProductName, 36))
+		l.doc.textRight(fontSize, quantityRight, l.y, fmt.Sprint(item.Quantity))
+		l.doc.textRight(fontSize, unitRight, l.y, l.currency.Format(item.UnitPriceCents))
+		l.doc.textRight(fontSize, amountRight, l.y, l.currency.Format(item.LineTotalCents))
+	}
+	l.y -= lineHeight / 2
+	l.doc.line(margin, l.y, amountRight, l.y)
+}
+
+func (l *layout) tableHeader() {
+	l.y -= lineHeight
+	l.doc.text(bold, fontSize, margin, l.y, "Description")
+	l.doc.text(bold, fontSize, quantityRight-20, l.y, "Qty")
+	l.doc.text(bold, fontSize, unitRight-50, l.y, "Unit price")
+	l.doc.text(bold, fontSize, amountRight-40, l.y, "Amount")
+	l.doc.line(margin, l.y-4, amountRight, l.y-4)
+	l.y -= 4
+}
+
+// next moves down a line, onto a new page when this one is full.
+func (l *layout) next() {
+	l.y -= lineHeight
+	if l.y >= pageBottom {
+		return
+	}
+	l.doc.addPage()
+	l.y = pageHeight - margin
+	l.tableHeader()
+	l.y -= lineHeight
+}
+
+func (l *layout) totals(order domain.Order) {
+	l.total(regular, "Subtotal", order.SubtotalCents)
+	for _, discount := range order.Discounts {
+		label := discount.Description
+		if discount.Code != "" {
+			label = fmt.Sprintf("%s (%s)", label, discount.Code)
+		}
+		l.total(regular, label, -discount.AmountCents)
+	}
+	if order.ShippingCents != 0 || order.ShippingMethodName != "" {
+		label := "Shipping"
+		if order.ShippingMethodName != "" {
+			label += ": " + order.ShippingMethodName
+		}
+		l.total(regular, label, order.ShippingCents)
+	}
+
+	taxes := summarizeTaxes(order.Taxes)
+	if !order.PricesIncludeTax {
+		for _, line := range taxes {
+			l.total(regular, line.label, line.cents)
+		}
+	}
+	l.doc.line(totalsLeft, l.y-4, amountRight, l.y-4)
+	l.total(bold, "Total", order.TotalCents)
+	if order.PricesIncludeTax {
+		for _, line := range taxes {
+			l.total(regular, "Includes "+line.label, line.cents)
+		}
+	}
+}
+
+func (l *layout) total(f font, label string, cents int64) {
+	l.next()
+	l.doc.text(f, fontSize, totalsLeft, l.y, truncate(label, 30))
+	l.doc.textRight(fontSize, amountRight, l.y, l.currency.Format(cents))
+}
+
+// footer numbers the pages once there are all of them.
+func (l *layout) footer(inv domain.Invoice) {
+	for i, page := range l.doc.pages {
+		text := fmt.Sprintf("Invoice %s - page %d of %d", inv.Number, i+1, len(l.doc.pages))
+		fmt.Fprintf(page, "BT /%s 8 Tf %d 30 Td (%s) Tj ET\n", regular.resource, margin, escape(text))
+	}
+}
+
+type taxSummary struct {
+	label string
+	cents int64
+}
+
+// summarizeTaxes adds up the tax lines by tax, in the order they first
+// appear, labelled with their rate as a percentage.
+func summarizeTaxes(taxes []domain.OrderTax) []taxSummary {
+	var result []taxSummary
+	index := make(map[string]int)
+	for _, line := range taxes {
+		key := strings.Join([]string{line.Country, line.Region, line.Name, line.Rate}, "\x00")
+		i, ok := index[key]
+		if !ok {
+			label := line.Name
+			if rate, ok := new(big.Rat).SetString(line.Rate); ok {
+				label = fmt.Sprintf("%s %s%%", line.Name, money.FormatRate(rate.Mul(rate, big.NewRat(100, 1))))
+			}
+			i = len(result)
+			index[key] = i
+			result = append(result, taxSummary{label: label})
+		}
+		result[i].cents += line.TaxCents
+	}
+	return result
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// truncate shortens s to at most n characters, so it stays in its column.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
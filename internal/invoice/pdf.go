@@ -0,0 +1,140 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// The page is A4, in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+)
+
+// font is one of the standard PDF fonts, which every reader has, so
+// nothing needs embedding.
+type font struct {
+	resource string
+	baseFont string
+}
+
+var (
+	regular = font{"F1", "Helvetica"}
+	bold    = font{"F2", "Helvetica-Bold"}
+	// mono is used for amounts: every glyph is 600/1000 of the font size
+	// wide, so they can be right-aligned without font metrics.
+	mono = font{"F3", "Courier"}
+)
+
+var fonts = []font{regular, bold, mono}
+
+// winAnsi encodes text for the fonts' WinAnsiEncoding, with ? for what it
+// can't represent.
+var winAnsi = encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder())
+
+// document is a PDF being drawn page by page. It writes no timestamps or
+// random IDs, so drawing the same thing gives the same bytes.
+type document struct {
+	pages []*bytes.Buffer
+}
+
+func (d *document) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// text draws s with its baseline starting at x, y.
+func (d *document) text(f font, size, x, y float64, s string) {
+	page := d.pages[len(d.pages)-1]
+	fmt.Fprintf(page, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", f.resource, number(size), number(x), number(y), escape(s))
+}
+
+// textRight draws s in the mono font so that it ends at x.
+func (d *document) textRight(size, x, y float64, s string) {
+	width := float64(len([]rune(s))) * 0.6 * size
+	d.text(mono, size, x-width, y, s)
+}
+
+func (d *document) line(x1, y1, x2, y2 float64) {
+	page := d.pages[len(d.pages)-1]
+	fmt.Fprintf(page, "0.5 w %s %s m %s %s l S\n", number(x1), number(y1), number(x2), number(y2))
+}
+
+// bytes writes the document out as a PDF file.
+func (d *document) bytes(title string) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 and 2 are the catalog and the page tree, then come the
+	// info dictionary, the fonts, and a page and its contents per page.
+	firstFont := 4
+	firstPage := firstFont + len(fonts)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	var resources strings.Builder
+	for i, f := range fonts {
+		fmt.Fprintf(&resources, "/%s %d 0 R ", f.resource, firstFont+i)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (go-backend-example) >>", escape(title)))
+	for _, f := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont))
+	}
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, resources.String(), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape encodes s as the inside of a PDF string literal.
+func escape(s string) string {
+	encoded, err := winAnsi.String(s)
+	if err != nil {
+		encoded = strings.Map(func(r rune) rune {
+			if r > 126 {
+				return '?'
+			}
+			return r
+		}, s)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(encoded); i++ {
+		switch c := encoded[i]; {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func number(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,60 @@
+// Package money converts prices between currencies with explicit rounding.
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency. MinorUnits is the number of decimal
+// places amounts are kept in: 2 for cents, 0 for yen.
+type Currency struct {
+	Code       string
+	MinorUnits int
+}
+
+// DefaultBaseCurrency is the currency product prices are stored in unless
+// configured otherwise.
+const DefaultBaseCurrency = "USD"
+
+var currencies = map[string]Currency{}
+
+func init() {
+	for _, currency := range []Currency{
+		{"AUD", 2}, {"BHD", 3}, {"BRL", 2}, {"CAD", 2}, {"CHF", 2},
+		{"CNY", 2}, {"CZK", 2}, {"DKK", 2}, {"EUR", 2}, {"GBP", 2},
+		{"HKD", 2}, {"HUF", 2}, {"INR", 2}, {"ISK", 0}, {"JOD", 3},
+		{"JPY", 0}, {"KRW", 0}, {"KWD", 3}, {"MXN", 2}, {"NOK", 2},
+		{"NZD", 2}, {"OMR", 3}, {"PLN", 2}, {"SEK", 2}, {"SGD", 2},
+		{"TND", 3}, {"TRY", 2}, {"USD", 2}, {"VND", 0}, {"ZAR", 2},
+	} {
+		currencies[currency.Code] = currency
+	}
+}
+
+// LookupCurrency finds a supported currency by its code, ignoring case.
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, fmt.Errorf("unsupported currency %q", code)
+	}
+	return currency, nil
+}
+
+// Format writes an amount in the currency's minor units as a decimal
+// followed by the currency code, such as "12.50 USD".
+func (c Currency) Format(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if c.MinorUnits == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, c.Code)
+	}
+	scale := int64(1)
+	for range c.MinorUnits {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, c.MinorUnits, amount%scale, c.Code)
+}
@@ -0,0 +1,107 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in a currency's minor units.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ParseRate parses an exchange rate written as a decimal, such as "0.9215".
+// Rates are kept as exact fractions so converting never accumulates
+// floating point error.
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, errors.New("rate must be a positive decimal number")
+	}
+	return rate, nil
+}
+
+// FormatRate writes a rate as a decimal without trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	text := rate.FloatString(12)
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
+
+// Converter turns amounts in the base currency into another currency.
+type Converter struct {
+	from Currency
+	to   Currency
+	rate *big.Rat
+}
+
+// NewConverter converts from one currency to another, where one unit of
+// from is worth rate units of to.
+func NewConverter(from, to Currency, rate *big.Rat) Converter {
+	return Converter{from: from, to: to, rate: rate}
+}
+
+// Identity is a converter that leaves amounts in currency unchanged.
+func Identity(currency Currency) Converter {
+	return Converter{from: currency, to: currency, rate: big.NewRat(1, 1)}
+}
+
+func (c Converter) Currency() Currency {
+	return c.to
+}
+
+// Rate returns the exchange rate as a decimal string.
+func (c Converter) Rate() string {
+	return FormatRate(c.rate)
+}
+
+// Convert converts an amount in the source currency's minor units to the
+// target currency. Results are rounded to the target's nearest minor unit,
+// with halves rounded away from zero.
+func (c Converter) Convert(amount int64) Money {
+	return Money{Amount: c.convert(amount), Currency: c.to.Code}
+}
+
+// ConvertPtr converts amount when it is set.
+func (c Converter) ConvertPtr(amount *int64) *int64 {
+	if amount == nil {
+		return nil
+	}
+	converted := c.convert(*amount)
+	return &converted
+}
+
+func (c Converter) convert(amount int64) int64 {
+	value := new(big.Rat).Mul(big.NewRat(amount, 1), c.rate)
+	shift := c.to.MinorUnits - c.from.MinorUnits
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+	if shift >= 0 {
+		value.Mul(value, new(big.Rat).SetInt(scale))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+	return roundHalfAwayFromZero(value)
+}
+
+func roundHalfAwayFromZero(value *big.Rat) int64 {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
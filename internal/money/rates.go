@@ -0,0 +1,57 @@
+package money
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Rate is one line of an exchange rate file: one unit of the base currency
+// is worth Rate units of Currency.
+type Rate struct {
+	Currency string
+	Rate     string
+}
+
+// DecodeRates reads a CSV file with a currency,rate header. Every line is
+// checked; the first invalid one is reported with its line number.
+func DecodeRates(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty rates file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(header[0]) != "currency" || strings.ToLower(header[1]) != "rate" {
+		return nil, errors.New("rates file must start with a currency,rate header")
+	}
+
+	var rates []Rate
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		currency, err := LookupCurrency(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, err := ParseRate(row[1]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, Rate{Currency: currency.Code, Rate: strings.TrimSpace(row[1])})
+	}
+	return rates, nil
+}
@@ -0,0 +1,91 @@
+// Package notification delivers messages to ops and customers.
+package notification
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Kind string
+
+const (
+	KindLowStock    Kind = "low_stock"
+	KindBackInStock Kind = "back_in_stock"
+)
+
+// OpsRecipient addresses messages meant for the operations team rather
+// than a customer.
+const OpsRecipient = "ops"
+
+// Message is addressed to OpsRecipient or to a customer's user name.
+type Message struct {
+	Kind      Kind
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Channel delivers messages. A message that fails to send is retried the
+// next time notifications are evaluated.
+type Channel interface {
+	Send(message Message) error
+}
+
+// Outbox stores messages in the outbox table for another process to relay.
+type Outbox struct {
+	db *gorm.DB
+}
+
+func NewOutbox(db *gorm.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+func (o *Outbox) Send(message Message) error {
+	return o.db.Create(&domain.OutboxMessage{
+		Kind:      string(message.Kind),
+		Recipient: message.Recipient,
+		Subject:   message.Subject,
+		Body:      message.Body,
+	}).Error
+}
+
+// File appends messages to a file as JSON lines.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Send(message Message) error {
+	line, err := json.Marshal(struct {
+		Kind      Kind      `json:"kind"`
+		Recipient string    `json:"recipient"`
+		Subject   string    `json:"subject"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+	}{message.Kind, message.Recipient, message.Subject, message.Body, time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
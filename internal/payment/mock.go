@@ -0,0 +1,192 @@
+package payment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Test card numbers understood by Mock. Any other number is declined.
+const (
+	CardSucceeds          = "4242424242424242"
+	CardDeclined          = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+	// CardFailsOnCapture is authorized, but its capture fails.
+	CardFailsOnCapture = "4000000000000341"
+)
+
+// Mock is a payment provider for development and tests. Outcomes depend
+// only on the card number, and payments are kept in memory, so they are
+// forgotten on restart. After a capture it posts a signed webhook to its
+// webhook URL, if it has one.
+type Mock struct {
+	webhookURL string
+	secret     []byte
+	client     *http.Client
+
+	mu       sync.Mutex
+	payments map[string]*mockPayment
+}
+
+type mockPayment struct {
+	card     string
+	amount   int64
+	captured int64
+	refunded int64
+	voided   bool
+}
+
+func NewMock(webhookURL string, secret []byte) *Mock {
+	return &Mock{
+		webhookURL: webhookURL,
+		secret:     secret,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		payments:   make(map[string]*mockPayment),
+	}
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func (m *Mock) Authorize(req AuthorizeRequest) (string, error) {
+	switch req.Card.Number {
+	case CardSucceeds, CardFailsOnCapture:
+	case CardDeclined:
+		return "", fmt.Errorf("%w: card declined", ErrDeclined)
+	case CardInsufficientFunds:
+		return "", fmt.Errorf("%w: insufficient funds", ErrDeclined)
+	default:
+		return "", fmt.Errorf("%w: unknown test card", ErrDeclined)
+	}
+	if req.AmountCents <= 0 {
+		return "", fmt.Errorf("%w: amount must be positive", ErrInvalidRequest)
+	}
+
+	reference := "mock_" + randomID()
+	m.mu.Lock()
+	m.payments[reference] = &mockPayment{card: req.Card.Number, amount: req.AmountCents}
+	m.mu.Unlock()
+	return reference, nil
+}
+
+func (m *Mock) Capture(reference string, amountCents int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, err := m.payment(reference)
+	if err != nil {
+		return err
+	}
+	if p.voided || p.captured > 0 {
+		return fmt.Errorf("%w: payment can't be captured", ErrInvalidRequest)
+	}
+	if amountCents <= 0 || amountCents > p.amount {
+		return fmt.Errorf("%w: capture must be between 1 and the authorized amount", ErrInvalidRequest)
+	}
+
+	event := Event{Type: EventPaymentSucceeded, Reference: reference}
+	if p.card == CardFailsOnCapture {
+		event.Type = EventPaymentFailed
+		event.Reason = "capture failed"
+	} else {
+		p.captured = amountCents
+	}
+	m.deliver(event)
+	return nil
+}
+
+func (m *Mock) Void(reference string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, err := m.payment(reference)
+	if err != nil {
+		return err
+	}
+	if p.captured > 0 {
+		return fmt.Errorf("%w: captured payments are refunded, not voided", ErrInvalidRequest)
+	}
+	p.voided = true
+	return nil
+}
+
+func (m *Mock) Refund(reference string, amountCents int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, err := m.payment(reference)
+	if err != nil {
+		return err
+	}
+	if amountCents <= 0 || amountCents > p.captured-p.refunded {
+		return fmt.Errorf("%w: refund must be between 1 and the amount not yet refunded", ErrInvalidRequest)
+	}
+	p.refunded += amountCents
+	return nil
+}
+
+// VerifyWebhook checks a webhook against the secret the Mock signs its
+// own with.
+func (m *Mock) VerifyWebhook(header string, body []byte, now time.Time) error {
+	if len(m.secret) == 0 {
+		return ErrWebhooksNotConfigured
+	}
+	return Verify(m.secret, header, body, now)
+}
+
+// payment must be called with mu held.
+func (m *Mock) payment(reference string) (*mockPayment, error) {
+	p, ok := m.payments[reference]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown payment %s", ErrInvalidRequest, reference)
+	}
+	return p, nil
+}
+
+// deliver posts the event in the background, as a real provider would
+// after the call that caused it has returned.
+func (m *Mock) deliver(event Event) {
+	if m.webhookURL == "" {
+		return
+	}
+	event.ID = "evt_" + randomID()
+	event.CreatedAt = time.Now().UTC()
+
+	go func() {
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("failed to encode payment webhook: %v", err)
+			return
+		}
+		req, err := http.NewRequest(http.MethodPost, m.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("failed to send payment webhook: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(SignatureHeader, Sign(m.secret, time.Now(), body))
+
+		resp, err := m.client.Do(req)
+		if err != nil {
+			log.Printf("failed to send payment webhook: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("payment webhook %s was rejected with %d", event.ID, resp.StatusCode)
+		}
+	}()
+}
+
+func randomID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
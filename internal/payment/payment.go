@@ -0,0 +1,117 @@
+// Package payment talks to payment service providers.
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDeclined is wrapped with the provider's reason when it refuses a
+// charge.
+var ErrDeclined = errors.New("payment declined")
+
+// ErrInvalidRequest is wrapped when an operation doesn't fit the state of
+// the payment, such as capturing more than was authorized.
+var ErrInvalidRequest = errors.New("invalid payment request")
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrWebhooksNotConfigured is returned by a Gateway that has no secret to
+// verify webhooks with.
+var ErrWebhooksNotConfigured = errors.New("payment webhooks are not configured")
+
+type Card struct {
+	Number string
+}
+
+// AuthorizeRequest.AmountCents is in Currency's minor units.
+type AuthorizeRequest struct {
+	Card        Card
+	AmountCents int64
+	Currency    string
+}
+
+// Gateway is a payment service provider. Payments are identified by the
+// reference Authorize returns. Whether a capture went through is reported
+// later, through a webhook Event, whose SignatureHeader value
+// VerifyWebhook checks.
+type Gateway interface {
+	Name() string
+	Authorize(req AuthorizeRequest) (string, error)
+	Capture(reference string, amountCents int64) error
+	Void(reference string) error
+	Refund(reference string, amountCents int64) error
+	VerifyWebhook(header string, body []byte, now time.Time) error
+}
+
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+)
+
+// Event is the body of a webhook. ID is unique per event, so a webhook
+// that is delivered again can be recognised.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	Reference string    `json:"reference"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SignatureHeader carries a webhook's signature, in the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+const SignatureHeader = "Payment-Signature"
+
+// SignatureTolerance is how old a webhook's signature can be before it is
+// rejected as a replay.
+const SignatureTolerance = 5 * time.Minute
+
+// Sign returns the SignatureHeader value for a webhook body sent at.
+func Sign(secret []byte, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, signature(secret, timestamp, body))
+}
+
+// Verify checks a SignatureHeader value against the webhook body, and that
+// it was signed no more than SignatureTolerance before now.
+func Verify(secret []byte, header string, body []byte, now time.Time) error {
+	var timestamp, signed string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signed = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signed == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signed), []byte(signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+	return nil
+}
+
+func signature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
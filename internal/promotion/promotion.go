@@ -0,0 +1,194 @@
+// Package promotion works out the discounts that coupon rules give.
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/money"
+)
+
+type Kind string
+
+const (
+	KindPercentage   Kind = "percentage"
+	KindFixedAmount  Kind = "fixed_amount"
+	KindFreeShipping Kind = "free_shipping"
+	// KindBuyXGetY gives GetQuantity units free for every BuyQuantity
+	// bought, of the same product.
+	KindBuyXGetY Kind = "buy_x_get_y"
+)
+
+// ErrNotApplicable is wrapped with an explanation when a rule gives no
+// discount.
+var ErrNotApplicable = errors.New("coupon does not apply")
+
+var ErrInvalidRule = errors.New("invalid promotion rule")
+
+// Rule is a coupon's terms. Amounts are in the basket's currency. A rule
+// with a ProductID only discounts that product's lines. StartsAt and
+// EndsAt bound when it can be used, if set.
+type Rule struct {
+	Code             string
+	Kind             Kind
+	PercentOff       int
+	AmountOffCents   int64
+	BuyQuantity      int
+	GetQuantity      int
+	ProductID        *uint
+	MinSubtotalCents int64
+	StartsAt         *time.Time
+	EndsAt           *time.Time
+}
+
+func (r Rule) Validate() error {
+	switch r.Kind {
+	case KindPercentage:
+		if r.PercentOff < 1 || r.PercentOff > 100 {
+			return fmt.Errorf("%w: percent_off must be between 1 and 100", ErrInvalidRule)
+		}
+	case KindFixedAmount:
+		if r.AmountOffCents <= 0 {
+			return fmt.Errorf("%w: amount_off_cents must be positive", ErrInvalidRule)
+		}
+	case KindFreeShipping:
+	case KindBuyXGetY:
+		if r.BuyQuantity < 1 || r.GetQuantity < 1 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be positive", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidRule, r.Kind)
+	}
+
+	if r.MinSubtotalCents < 0 {
+		return fmt.Errorf("%w: min_subtotal_cents can't be negative", ErrInvalidRule)
+	}
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidRule)
+	}
+	return nil
+}
+
+type Line struct {
+	ProductID      uint
+	Name           string
+	Quantity       int
+	UnitPriceCents int64
+}
+
+// Basket is what a rule is evaluated against, as of At.
+type Basket struct {
+	Currency      money.Currency
+	Lines         []Line
+	ShippingCents int64
+	At            time.Time
+}
+
+func (b Basket) SubtotalCents() int64 {
+	var total int64
+	for _, line := range b.Lines {
+		total += line.UnitPriceCents * int64(line.Quantity)
+	}
+	return total
+}
+
+// Discount is one line of the discount a rule gives, with a description of
+// what it is for.
+type Discount struct {
+	Code        string
+	Kind        Kind
+	ProductID   *uint
+	Description string
+	AmountCents int64
+}
+
+// Evaluate returns the discount lines the rule gives the basket, or an
+// error wrapping ErrNotApplicable that explains why it gives none. The
+// discounts never add up to more than the basket's subtotal, plus its
+// shipping for free shipping.
+func Evaluate(rule Rule, basket Basket) ([]Discount, error) {
+	if rule.StartsAt != nil && basket.At.Before(*rule.StartsAt) {
+		return nil, notApplicable("coupon %s can't be used until %s", rule.Code, rule.StartsAt.UTC().Format(time.RFC3339))
+	}
+	if rule.EndsAt != nil && !basket.At.Before(*rule.EndsAt) {
+		return nil, notApplicable("coupon %s expired at %s", rule.Code, rule.EndsAt.UTC().Format(time.RFC3339))
+	}
+	if len(basket.Lines) == 0 {
+		return nil, notApplicable("the cart is empty")
+	}
+	if subtotal := basket.SubtotalCents(); subtotal < rule.MinSubtotalCents {
+		return nil, notApplicable("coupon %s needs a subtotal of at least %s", rule.Code, basket.Currency.Format(rule.MinSubtotalCents))
+	}
+
+	lines := basket.Lines
+	target := "the order"
+	if rule.ProductID != nil {
+		lines = nil
+		for _, line := range basket.Lines {
+			if line.ProductID == *rule.ProductID {
+				lines = append(lines, line)
+				target = line.Name
+			}
+		}
+		if len(lines) == 0 {
+			return nil, notApplicable("coupon %s only applies to product %d", rule.Code, *rule.ProductID)
+		}
+	}
+	eligible := Basket{Lines: lines}.SubtotalCents()
+
+	discount := Discount{Code: rule.Code, Kind: rule.Kind, ProductID: rule.ProductID}
+	switch rule.Kind {
+	case KindPercentage:
+		discount.Description = fmt.Sprintf("%d%% off %s", rule.PercentOff, target)
+		discount.AmountCents = eligible * int64(rule.PercentOff) / 100
+	case KindFixedAmount:
+		discount.Description = fmt.Sprintf("%s off %s", basket.Currency.Format(rule.AmountOffCents), target)
+		discount.AmountCents = min(rule.AmountOffCents, eligible)
+	case KindFreeShipping:
+		discount.Description = "free shipping"
+		discount.AmountCents = basket.ShippingCents
+	case KindBuyXGetY:
+		return buyXGetY(rule, lines)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidRule, rule.Kind)
+	}
+	return []Discount{discount}, nil
+}
+
+// buyXGetY gives a discount line for each product bought in a large
+// enough quantity.
+func buyXGetY(rule Rule, lines []Line) ([]Discount, error) {
+	var discounts []Discount
+	for _, line := range lines {
+		free := line.Quantity / (rule.BuyQuantity + rule.GetQuantity) * rule.GetQuantity
+		if free == 0 {
+			continue
+		}
+		productID := line.ProductID
+		discounts = append(discounts, Discount{
+			Code:        rule.Code,
+			Kind:        rule.Kind,
+			ProductID:   &productID,
+			Description: fmt.Sprintf("buy %d %s, get %d free: %d free", rule.BuyQuantity, line.Name, rule.GetQuantity, free),
+			AmountCents: line.UnitPriceCents * int64(free),
+		})
+	}
+	if len(discounts) == 0 {
+		return nil, notApplicable("coupon %s needs %d of a product in the cart", rule.Code, rule.BuyQuantity+rule.GetQuantity)
+	}
+	return discounts, nil
+}
+
+// Total adds up the discount lines.
+func Total(discounts []Discount) int64 {
+	var total int64
+	for _, discount := range discounts {
+		total += discount.AmountCents
+	}
+	return total
+}
+
+func notApplicable(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrNotApplicable, fmt.Sprintf(format, args...))
+}
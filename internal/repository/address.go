@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repository) GetAddresses(userID uint) ([]domain.Address, error) {
+	var result []domain.Address
+
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAddress returns the address only if it belongs to the user.
+func (r *Repository) GetAddress(userID, id uint) (*domain.Address, error) {
+	return findAddress(r.db, userID, id)
+}
+
+func (r *Repository) CreateAddress(userID uint, data domain.Address) (*domain.Address, error) {
+	address := domain.Address{
+		UserID:        userID,
+		PostalAddress: data.PostalAddress,
+		Latitude:      data.Latitude,
+		Longitude:     data.Longitude,
+	}
+
+	if err := r.db.Create(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// UpdateAddress changes one of the user's addresses. Orders already
+// shipped to it keep their copy.
+func (r *Repository) UpdateAddress(userID, id uint, data domain.Address) (*domain.Address, error) {
+	var address *domain.Address
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if address, err = findAddress(tx, userID, id); err != nil {
+			return err
+		}
+
+		address.PostalAddress = data.PostalAddress
+		address.Latitude = data.Latitude
+		address.Longitude = data.Longitude
+		return tx.Save(address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (r *Repository) DeleteAddress(userID, id uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&domain.Address{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func findAddress(tx *gorm.DB, userID, id uint) (*domain.Address, error) {
+	var address domain.Address
+	if err := tx.Where("user_id = ?", userID).First(&address, id).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
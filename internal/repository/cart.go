@@ -0,0 +1,447 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CartOwner identifies a cart: the user's own when UserID is set, and
+// otherwise the guest cart with the given Token.
+type CartOwner struct {
+	UserID uint
+	Token  string
+}
+
+// where restricts a query joined with carts to the owner's cart.
+func (o CartOwner) where(query *gorm.DB) *gorm.DB {
+	if o.UserID != 0 {
+		return query.Where("carts.user_id = ?", o.UserID)
+	}
+	return query.Where("carts.token = ?", o.Token)
+}
+
+// CartLine is a cart item with the product as it is now. Products that
+// are no longer sold are not Available and only have their name loaded.
+type CartLine struct {
+	Item      domain.CartItem
+	Product   domain.Product
+	Available bool
+}
+
+// CartContents is a cart's lines and the coupon applied to it, if any,
+// with the discounts the coupon gives in the base currency, or
+// CouponError explaining why it gives none right now.
+type CartContents struct {
+	Lines       []CartLine
+	Coupon      *domain.Coupon
+	Discounts   []promotion.Discount
+	CouponError error
+}
+
+// GetCart returns the cart's items in the order they were added, with the
+// products' current prices and their names in the first of the locales
+// that has them. A user without a cart gets an empty one; an unknown guest
+// cart is ErrCartNotFound.
+func (r *Repository) GetCart(owner CartOwner, locales []string) (*CartContents, error) {
+	cart, err := findCart(r.db, owner)
+	if err != nil {
+		if errors.Is(err, ErrCartNotFound) && owner.UserID != 0 {
+			return &CartContents{}, nil
+		}
+		return nil, err
+	}
+
+	lines, err := cartLines(r.db, cart.ID, locales)
+	if err != nil {
+		return nil, err
+	}
+	contents := &CartContents{Lines: lines}
+	if cart.CouponID == nil {
+		return contents, nil
+	}
+
+	var coupon domain.Coupon
+	if err := r.db.First(&coupon, *cart.CouponID).Error; err != nil {
+		return nil, err
+	}
+	contents.Coupon = &coupon
+	contents.Discounts, contents.CouponError = promotion.Evaluate(couponRule(coupon), r.cartBasket(lines))
+	return contents, nil
+}
+
+func cartLines(tx *gorm.DB, cartID uint, locales []string) ([]CartLine, error) {
+	var items []domain.CartItem
+	if err := tx.Where("cart_id = ?", cartID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]uint, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	now := time.Now().UTC()
+	var products []domain.Product
+	err := tx.Table("(?) AS products", localizedProducts(tx, now, locales)).
+		Model(&domain.Product{}).
+		Where("products.id IN ?", productIDs).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	var unavailable []domain.Product
+	if len(products) < len(productIDs) {
+		err := tx.Unscoped().Select("id", "name").
+			Where("id IN ? AND deleted_at IS NOT NULL", productIDs).
+			Find(&unavailable).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[uint]CartLine, len(productIDs))
+	for _, product := range products {
+		byID[product.ID] = CartLine{Product: product, Available: true}
+	}
+	for _, product := range unavailable {
+		product.LocalizedName = product.Name
+		byID[product.ID] = CartLine{Product: product}
+	}
+
+	lines := make([]CartLine, len(items))
+	for i, item := range items {
+		lines[i] = byID[item.ProductID]
+		lines[i].Item = item
+	}
+	return lines, nil
+}
+
+// cartBasket is what a coupon is evaluated against for a cart: the lines
+// that can still be ordered, at current prices in the base currency.
+func (r *Repository) cartBasket(lines []CartLine) promotion.Basket {
+	basket := promotion.Basket{Currency: r.baseCurrency, At: time.Now().UTC()}
+	for _, line := range lines {
+		if line.Available {
+			basket.Lines = append(basket.Lines, promotion.Line{
+				ProductID:      line.Product.ID,
+				Name:           line.Product.LocalizedName,
+				Quantity:       line.Item.Quantity,
+				UnitPriceCents: line.Product.CurrentPriceCents,
+			})
+		}
+	}
+	return basket
+}
+
+// ApplyCartCoupon applies the coupon with the code to the cart, replacing
+// any other. It fails with ErrCouponNotFound for an unknown code, and with
+// an error wrapping promotion.ErrNotApplicable explaining why when the
+// coupon gives the cart no discount or has reached its usage limits.
+func (r *Repository) ApplyCartCoupon(owner CartOwner, code string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		coupon, err := findCoupon(tx, code)
+		if err != nil {
+			return err
+		}
+		cartID, err := ownerCart(tx, owner)
+		if err != nil {
+			return err
+		}
+		if err := checkCouponLimits(tx, *coupon, owner.UserID); err != nil {
+			return err
+		}
+
+		lines, err := cartLines(tx, cartID, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := promotion.Evaluate(couponRule(*coupon), r.cartBasket(lines)); err != nil {
+			return err
+		}
+		return tx.Model(&domain.Cart{}).Where("id = ?", cartID).Update("coupon_id", coupon.ID).Error
+	})
+}
+
+func (r *Repository) RemoveCartCoupon(owner CartOwner) error {
+	cart, err := findCart(r.db, owner)
+	if err != nil {
+		return err
+	}
+	if cart.CouponID == nil {
+		return gorm.ErrRecordNotFound
+	}
+	return r.db.Model(&domain.Cart{}).Where("id = ?", cart.ID).Update("coupon_id", nil).Error
+}
+
+// AddCartItem adds quantity of the product to the cart, on top of any
+// already there.
+func (r *Repository) AddCartItem(owner CartOwner, productID uint, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		price, err := currentPrice(tx, productID)
+		if err != nil {
+			return err
+		}
+		cartID, err := ownerCart(tx, owner)
+		if err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"quantity":         gorm.Expr("cart_items.quantity + excluded.quantity"),
+				"unit_price_cents": gorm.Expr("excluded.unit_price_cents"),
+				"updated_at":       gorm.Expr("excluded.updated_at"),
+			}),
+		}).Create(&domain.CartItem{
+			CartID:         cartID,
+			ProductID:      productID,
+			Quantity:       quantity,
+			UnitPriceCents: price,
+		}).Error
+	})
+}
+
+// SetCartItemQuantity changes the quantity of a product already in the
+// cart.
+func (r *Repository) SetCartItemQuantity(owner CartOwner, productID uint, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		item, err := findCartItem(tx, owner, productID)
+		if err != nil {
+			return err
+		}
+
+		// The price the user is looking at is refreshed with the change;
+		// a product that is no longer sold keeps its old price.
+		price, err := currentPrice(tx, productID)
+		switch {
+		case err == nil:
+			item.UnitPriceCents = price
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		item.Quantity = quantity
+		return tx.Save(item).Error
+	})
+}
+
+func (r *Repository) RemoveCartItem(owner CartOwner, productID uint) error {
+	result := r.db.Where("product_id = ? AND cart_id IN (?)", productID,
+		owner.where(r.db.Model(&domain.Cart{}).Select("id"))).
+		Delete(&domain.CartItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CheckoutCart turns the user's cart into an order, with the cart's
+// coupon in place of opts.CouponCode, and empties the cart, all or
+// nothing. It fails with ErrCartEmpty when there is nothing to order, and
+// like CreateOrder when a product is no longer sold or is out of stock, or
+// the coupon no longer applies.
+func (r *Repository) CheckoutCart(userID uint, prices money.Converter, opts OrderOptions) (*domain.Order, error) {
+	var order *domain.Order
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the cart stops the same cart being checked out twice.
+		var cart domain.Cart
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Where("user_id = ?", userID).
+			Limit(1).
+			Find(&cart).Error
+		if err != nil {
+			return err
+		}
+		if len(cart.Items) == 0 {
+			return ErrCartEmpty
+		}
+
+		items := make([]OrderItemInput, len(cart.Items))
+		for i, item := range cart.Items {
+			items[i] = OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity}
+		}
+		var coupon *domain.Coupon
+		if cart.CouponID != nil {
+			coupon = &domain.Coupon{}
+			if err := tx.First(coupon, *cart.CouponID).Error; err != nil {
+				return err
+			}
+		}
+		order, err = r.createOrder(tx, userID, items, prices, opts, coupon)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&cart).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("cart_id = ?", cart.ID).Delete(&domain.CartItem{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CreateGuestCart starts an empty cart for a shopper who isn't logged in
+// and returns the token that identifies it.
+func (r *Repository) CreateGuestCart() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := r.db.Create(&domain.Cart{Token: &token}).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// MergeGuestCart moves the items in the guest cart into the user's cart
+// and deletes the guest cart. Quantities of a product in both carts are
+// added together, but capped at the stock available, and products that are
+// no longer sold or have no stock left are dropped. An unknown token is
+// ignored, since the guest cart may already have been merged.
+func (r *Repository) MergeGuestCart(userID uint, token string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var guest domain.Cart
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Where("token = ?", token).
+			Limit(1).
+			Find(&guest).Error
+		if err != nil || guest.ID == 0 {
+			return err
+		}
+
+		cartID, err := ownerCart(tx, CartOwner{UserID: userID})
+		if err != nil {
+			return err
+		}
+		for _, item := range guest.Items {
+			if err := mergeCartItem(tx, cartID, item); err != nil {
+				return err
+			}
+		}
+		// A coupon the guest applied is kept unless the user has one.
+		if guest.CouponID != nil {
+			err := tx.Model(&domain.Cart{}).
+				Where("id = ? AND coupon_id IS NULL", cartID).
+				Update("coupon_id", *guest.CouponID).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("cart_id = ?", guest.ID).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&guest).Error
+	})
+}
+
+func mergeCartItem(tx *gorm.DB, cartID uint, guestItem domain.CartItem) error {
+	if _, err := currentPrice(tx, guestItem.ProductID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	available, err := availableQuantity(tx, guestItem.ProductID)
+	if err != nil {
+		return err
+	}
+
+	var existing []domain.CartItem
+	err = tx.Where("cart_id = ? AND product_id = ?", cartID, guestItem.ProductID).
+		Limit(1).
+		Find(&existing).Error
+	if err != nil {
+		return err
+	}
+
+	item := domain.CartItem{
+		CartID:         cartID,
+		ProductID:      guestItem.ProductID,
+		UnitPriceCents: guestItem.UnitPriceCents,
+	}
+	if len(existing) > 0 {
+		item = existing[0]
+	}
+	quantity := item.Quantity + guestItem.Quantity
+	// The cap never takes away what the user already had in their cart.
+	if available != nil && quantity > *available {
+		quantity = max(*available, item.Quantity)
+	}
+	if quantity <= 0 {
+		return nil
+	}
+	item.Quantity = quantity
+	return tx.Save(&item).Error
+}
+
+// ownerCart returns the ID of the owner's cart. A user's cart is created
+// if needed; a guest cart must already exist.
+func ownerCart(tx *gorm.DB, owner CartOwner) (uint, error) {
+	if owner.UserID != 0 {
+		cart := domain.Cart{UserID: &owner.UserID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&cart).Error; err != nil {
+			return 0, err
+		}
+	}
+	cart, err := findCart(tx, owner)
+	if err != nil {
+		return 0, err
+	}
+	return cart.ID, nil
+}
+
+func findCart(tx *gorm.DB, owner CartOwner) (*domain.Cart, error) {
+	var carts []domain.Cart
+	if err := owner.where(tx.Table("carts")).Limit(1).Find(&carts).Error; err != nil {
+		return nil, err
+	}
+	if len(carts) == 0 {
+		return nil, ErrCartNotFound
+	}
+	return &carts[0], nil
+}
+
+func findCartItem(tx *gorm.DB, owner CartOwner, productID uint) (*domain.CartItem, error) {
+	var item domain.CartItem
+	err := owner.where(tx.Joins("JOIN carts ON carts.id = cart_items.cart_id")).
+		Where("cart_items.product_id = ?", productID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// currentPrice returns the price a product sells for now, in the base
+// currency.
+func currentPrice(tx *gorm.DB, productID uint) (int64, error) {
+	var product domain.Product
+	err := pricedProducts(tx, time.Now().UTC()).
+		Where("products.id = ?", productID).
+		First(&product).Error
+	if err != nil {
+		return 0, err
+	}
+	return product.CurrentPriceCents, nil
+}
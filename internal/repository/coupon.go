@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NormalizeCouponCode is how coupon codes are stored and looked up, so
+// they can be typed in any case.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponsWithUsage selects coupons with TimesUsed.
+func couponsWithUsage(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&domain.Coupon{}).
+		Select("coupons.*, (SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_redemptions.coupon_id = coupons.id) AS times_used")
+}
+
+func (r *Repository) GetCoupons() ([]domain.Coupon, error) {
+	var result []domain.Coupon
+
+	if err := couponsWithUsage(r.db).Order("coupons.code").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) GetCoupon(id uint) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+
+	if err := couponsWithUsage(r.db).Where("coupons.id = ?", id).First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *Repository) CreateCoupon(data domain.Coupon) (*domain.Coupon, error) {
+	coupon := couponFields(data)
+
+	if err := r.db.Create(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrCouponAlreadyExists
+		}
+		return nil, err
+	}
+	return r.GetCoupon(coupon.ID)
+}
+
+func (r *Repository) UpdateCoupon(id uint, data domain.Coupon) (*domain.Coupon, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var coupon domain.Coupon
+		if err := tx.First(&coupon, id).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&coupon).Select(
+			"Code", "Description", "Kind", "PercentOff", "AmountOffCents", "BuyQuantity", "GetQuantity",
+			"ProductID", "MinSubtotalCents", "MaxUses", "MaxUsesPerUser", "StartsAt", "EndsAt",
+		).Updates(couponFields(data)).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrCouponAlreadyExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetCoupon(id)
+}
+
+// DeleteCoupon deletes the coupon and takes it off carts. Orders that used
+// it keep their discounts.
+func (r *Repository) DeleteCoupon(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Coupon{}, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Cart{}).Where("coupon_id = ?", id).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.OrderDiscount{}).Where("coupon_id = ?", id).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("coupon_id = ?", id).Delete(&domain.CouponRedemption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Coupon{}, id).Error
+	})
+}
+
+func couponFields(data domain.Coupon) domain.Coupon {
+	return domain.Coupon{
+		Code:             NormalizeCouponCode(data.Code),
+		Description:      data.Description,
+		Kind:             data.Kind,
+		PercentOff:       data.PercentOff,
+		AmountOffCents:   data.AmountOffCents,
+		BuyQuantity:      data.BuyQuantity,
+		GetQuantity:      data.GetQuantity,
+		ProductID:        data.ProductID,
+		MinSubtotalCents: data.MinSubtotalCents,
+		MaxUses:          data.MaxUses,
+		MaxUsesPerUser:   data.MaxUsesPerUser,
+		StartsAt:         data.StartsAt,
+		EndsAt:           data.EndsAt,
+	}
+}
+
+func findCoupon(tx *gorm.DB, code string) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := tx.Where("code = ?", NormalizeCouponCode(code)).First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func couponRule(coupon domain.Coupon) promotion.Rule {
+	return promotion.Rule{
+		Code:             coupon.Code,
+		Kind:             promotion.Kind(coupon.Kind),
+		PercentOff:       coupon.PercentOff,
+		AmountOffCents:   coupon.AmountOffCents,
+		BuyQuantity:      coupon.BuyQuantity,
+		GetQuantity:      coupon.GetQuantity,
+		ProductID:        coupon.ProductID,
+		MinSubtotalCents: coupon.MinSubtotalCents,
+		StartsAt:         coupon.StartsAt,
+		EndsAt:           coupon.EndsAt,
+	}
+}
+
+// checkCouponLimits fails with an error wrapping promotion.ErrNotApplicable
+// when the coupon has been used as many times as it can be, in all or by
+// the user. Guests, with no userID, are only held to the overall limit.
+func checkCouponLimits(tx *gorm.DB, coupon domain.Coupon, userID uint) error {
+	if coupon.MaxUses != nil {
+		var used int64
+		if err := tx.Model(&domain.CouponRedemption{}).Where("coupon_id = ?", coupon.ID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(*coupon.MaxUses) {
+			return fmt.Errorf("%w: coupon %s has been used up", promotion.ErrNotApplicable, coupon.Code)
+		}
+	}
+
+	if coupon.MaxUsesPerUser != nil && userID != 0 {
+		var used int64
+		err := tx.Model(&domain.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, userID).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if used >= int64(*coupon.MaxUsesPerUser) {
+			return fmt.Errorf("%w: you have already used coupon %s", promotion.ErrNotApplicable, coupon.Code)
+		}
+	}
+	return nil
+}
+
+// applyCoupon works out the coupon's discounts on the order from its
+// items, which are the products given, and its shipping, which costs
+// shippingCents in the base currency. The rules are evaluated at base
+// prices and the discounts converted with prices, like the items. The
+// coupon row is locked so that orders racing for its last use can't both
+// get it.
+func (r *Repository) applyCoupon(tx *gorm.DB, order *domain.Order, coupon domain.Coupon, products map[uint]domain.Product, shippingCents int64, prices money.Converter) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, coupon.ID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCouponNotFound
+		}
+		return err
+	}
+	if err := checkCouponLimits(tx, coupon, order.UserID); err != nil {
+		return err
+	}
+
+	basket := promotion.Basket{Currency: r.baseCurrency, ShippingCents: shippingCents, At: time.Now().UTC()}
+	for _, item := range order.Items {
+		basket.Lines = append(basket.Lines, promotion.Line{
+			ProductID:      item.ProductID,
+			Name:           item.ProductName,
+			Quantity:       item.Quantity,
+			UnitPriceCents: products[item.ProductID].CurrentPriceCents,
+		})
+	}
+	discounts, err := promotion.Evaluate(couponRule(coupon), basket)
+	if err != nil {
+		return err
+	}
+
+	for _, discount := range discounts {
+		line := domain.OrderDiscount{
+			CouponID:    &coupon.ID,
+			Code:        coupon.Code,
+			Kind:        string(discount.Kind),
+			ProductID:   discount.ProductID,
+			Description: discount.Description,
+			AmountCents: prices.Convert(discount.AmountCents).Amount,
+		}
+		order.Discounts = append(order.Discounts, line)
+		order.DiscountCents += line.AmountCents
+	}
+	// Rounding each converted line can't take the total below zero.
+	order.DiscountCents = min(order.DiscountCents, order.SubtotalCents+order.ShippingCents)
+	return nil
+}
+
+// releaseCouponRedemption gives a cancelled order's coupon use back.
+func releaseCouponRedemption(tx *gorm.DB, order *domain.Order, _ domain.OrderStatus) error {
+	return tx.Where("order_id = ?", order.ID).Delete(&domain.CouponRedemption{}).Error
+}
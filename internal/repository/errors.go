@@ -4,3 +4,43 @@ import "errors"
 
 var ErrUserAlreadyExists = errors.New("user already exists")
 var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrProductAlreadyExists = errors.New("product already exists")
+var ErrProductTypeAlreadyExists = errors.New("product type already exists")
+var ErrProductTypeNotFound = errors.New("product type not found")
+var ErrAttributeTypeConflict = errors.New("attribute is defined with a different type")
+var ErrUnknownAttribute = errors.New("unknown attribute")
+var ErrInvalidAttributeFilter = errors.New("invalid attribute filter")
+var ErrProductNotFound = errors.New("product not found")
+var ErrReviewAlreadyExists = errors.New("review already exists")
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+var ErrExchangeRateNotFound = errors.New("no exchange rate for currency")
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+var ErrBaseCurrencyRate = errors.New("the base currency has no exchange rate")
+var ErrInsufficientStock = errors.New("insufficient stock")
+var ErrStockBelowReserved = errors.New("stock can't go below the reserved quantity")
+var ErrInvalidOrderTransition = errors.New("order can't move")
+var ErrWarehouseAlreadyExists = errors.New("warehouse already exists")
+var ErrWarehouseNotFound = errors.New("warehouse not found")
+var ErrProductInStock = errors.New("product is in stock")
+var ErrProductInUse = errors.New("product is still held for open orders or used by coupons")
+var ErrCartEmpty = errors.New("cart is empty")
+var ErrCartNotFound = errors.New("cart not found")
+var ErrPaymentInProgress = errors.New("a payment for the order is in progress")
+var ErrPaymentEventReplayed = errors.New("payment event was already handled")
+var ErrOrderNotRefundable = errors.New("order can't be refunded")
+var ErrInvalidRefund = errors.New("invalid refund")
+var ErrNothingToRefund = errors.New("nothing to refund")
+var ErrRefundTooLarge = errors.New("refund is more than is left to refund")
+var ErrRefundByRefundOnly = errors.New("orders are only refunded by refunding their payments")
+var ErrRefundFailed = errors.New("payment provider refused the refund")
+var ErrCouponAlreadyExists = errors.New("coupon already exists")
+var ErrCouponNotFound = errors.New("coupon not found")
+var ErrTaxRateAlreadyExists = errors.New("a tax rate for the jurisdiction and category already exists")
+var ErrTaxUnavailable = errors.New("tax could not be calculated")
+var ErrTaxAddressRequired = errors.New("an address is needed to tax the order")
+var ErrTaxAddressMismatch = errors.New("the tax address doesn't match the shipping address")
+var ErrAddressNotFound = errors.New("address not found")
+var ErrShippingAddressRequired = errors.New("a shipping method needs an address to ship to")
+var ErrShippingMethodRequired = errors.New("a shipping method is needed to ship to the address")
+var ErrShippingZoneAlreadyExists = errors.New("shipping zone already exists")
+var ErrShippingZoneNotFound = errors.New("shipping zone not found")
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BaseCurrency is the currency product prices are stored in.
+func (r *Repository) BaseCurrency() money.Currency {
+	return r.baseCurrency
+}
+
+// SetBaseCurrency changes the currency product prices are stored in. It
+// only relabels prices, so it must match the data already in the database.
+func (r *Repository) SetBaseCurrency(code string) error {
+	currency, err := money.LookupCurrency(code)
+	if err != nil {
+		return err
+	}
+	r.baseCurrency = currency
+	return nil
+}
+
+func (r *Repository) GetExchangeRates() ([]domain.ExchangeRate, error) {
+	var result []domain.ExchangeRate
+
+	if err := r.db.Order("currency").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetExchangeRates creates or replaces the given rates in one transaction.
+func (r *Repository) SetExchangeRates(rates []money.Rate) error {
+	rows := make([]domain.ExchangeRate, len(rates))
+	for i, rate := range rates {
+		currency, err := money.LookupCurrency(rate.Currency)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, rate.Currency)
+		}
+		if currency == r.baseCurrency {
+			return ErrBaseCurrencyRate
+		}
+		parsed, err := money.ParseRate(rate.Rate)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidExchangeRate, rate.Currency)
+		}
+		rows[i] = domain.ExchangeRate{Currency: currency.Code, Rate: money.FormatRate(parsed)}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+		}).Create(&rows).Error
+	})
+}
+
+func (r *Repository) DeleteExchangeRate(code string) error {
+	result := r.db.Delete(&domain.ExchangeRate{}, "currency = ?", code)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Converter returns a converter from the base currency to the currency
+// with the given code, using the stored exchange rate.
+func (r *Repository) Converter(code string) (money.Converter, error) {
+	currency, err := money.LookupCurrency(code)
+	if err != nil {
+		return money.Converter{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	if currency == r.baseCurrency {
+		return money.Identity(currency), nil
+	}
+
+	var row domain.ExchangeRate
+	if err := r.db.First(&row, "currency = ?", currency.Code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return money.Converter{}, fmt.Errorf("%w: %s", ErrExchangeRateNotFound, currency.Code)
+		}
+		return money.Converter{}, err
+	}
+
+	rate, err := money.ParseRate(row.Rate)
+	if err != nil {
+		return money.Converter{}, err
+	}
+	return money.NewConverter(r.baseCurrency, currency, rate), nil
+}
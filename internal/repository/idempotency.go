@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ClaimIdempotencyKey records the key for a request about to be handled,
+// until expiresAt. When the key is already recorded and hasn't expired it
+// returns the record and false instead, so the caller can replay its
+// response or reject the request. An expired record is replaced.
+func (r *Repository) ClaimIdempotencyKey(scope, key, fingerprint string, now, expiresAt time.Time) (*domain.IdempotencyKey, bool, error) {
+	record := domain.IdempotencyKey{Scope: scope, Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+
+	var existing *domain.IdempotencyKey
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("scope = ? AND key = ? AND expires_at <= ?", scope, key, now).
+			Delete(&domain.IdempotencyKey{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&record).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		existing = &domain.IdempotencyKey{}
+		err = r.db.Where("scope = ? AND key = ?", scope, key).First(existing).Error
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	return &record, true, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request the key was
+// claimed for.
+func (r *Repository) CompleteIdempotencyKey(id uint, statusCode int, contentType string, body []byte) error {
+	return r.db.Model(&domain.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]any{
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"body":         body,
+	}).Error
+}
+
+// ReleaseIdempotencyKey forgets a claimed key, so the request can be tried
+// again with it.
+func (r *Repository) ReleaseIdempotencyKey(id uint) error {
+	return r.db.Delete(&domain.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpiredIdempotencyKeys deletes keys that expired before now and
+// reports how many there were.
+func (r *Repository) DeleteExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/allocation"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReservationTTL is how long checkout holds stock before it is released.
+const ReservationTTL = 15 * time.Minute
+
+// Stock changes are made with conditional updates so that concurrent
+// requests can never take the available quantity below zero, without
+// holding locks across queries.
+
+// GetStock returns the product's stock in each warehouse that holds it,
+// in warehouse priority order. It fails with gorm.ErrRecordNotFound when
+// the product's stock isn't tracked.
+func (r *Repository) GetStock(productID uint) ([]domain.StockLevel, error) {
+	if err := r.db.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var result []domain.StockLevel
+	err := r.db.Joins("Warehouse").
+		Where("stock_levels.product_id = ?", productID).
+		Order("Warehouse.priority, stock_levels.warehouse_id").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return result, nil
+}
+
+// SetStock sets the on-hand quantity of a product in a warehouse, starting
+// to track its stock if it wasn't already. It fails with
+// ErrStockBelowReserved rather than take back stock that is held by
+// reservations.
+func (r *Repository) SetStock(productID, warehouseID uint, onHand int) (*domain.StockLevel, error) {
+	var stock domain.StockLevel
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id").First(&domain.Warehouse{}, warehouseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWarehouseNotFound
+			}
+			return err
+		}
+
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.StockLevel{ProductID: productID, WarehouseID: warehouseID}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.StockLevel{}).
+			Where("product_id = ? AND warehouse_id = ? AND reserved <= ?", productID, warehouseID, onHand).
+			Updates(map[string]any{"on_hand": onHand, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStockBelowReserved
+		}
+		return tx.Joins("Warehouse").
+			Where("stock_levels.product_id = ? AND stock_levels.warehouse_id = ?", productID, warehouseID).
+			First(&stock).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+// AdjustStock adds delta, which may be negative, to the on-hand quantity of
+// a product in a warehouse that already holds it.
+func (r *Repository) AdjustStock(productID, warehouseID uint, delta int) (*domain.StockLevel, error) {
+	var stock domain.StockLevel
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.StockLevel{}).
+			Where("product_id = ? AND warehouse_id = ? AND on_hand + ? >= reserved", productID, warehouseID, delta).
+			Updates(map[string]any{"on_hand": gorm.Expr("on_hand + ?", delta), "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := tx.Joins("Warehouse").
+			Where("stock_levels.product_id = ? AND stock_levels.warehouse_id = ?", productID, warehouseID).
+			First(&stock).Error
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return ErrStockBelowReserved
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+// reserveAttempts bounds how often a line is re-allocated when another
+// checkout takes the stock between reading and reserving it.
+const reserveAttempts = 3
+
+// errStockChanged rolls back a line's partial reservation so it can be
+// allocated again.
+var errStockChanged = errors.New("stock changed")
+
+// reserveStock holds stock for every tracked product in quantities and
+// records the reservation against the order. The strategy picks the
+// warehouses for each line. Products are reserved in ID order so
+// concurrent checkouts lock rows in the same order.
+func reserveStock(tx *gorm.DB, strategy allocation.Strategy, orderID uint, quantities map[uint]int, destination *allocation.Location) error {
+	reservation := domain.StockReservation{
+		OrderID:   orderID,
+		Status:    domain.ReservationActive,
+		ExpiresAt: time.Now().Add(ReservationTTL),
+	}
+
+	productIDs := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		productIDs = append(productIDs, id)
+	}
+	slices.Sort(productIDs)
+
+	for _, id := range productIDs {
+		items, err := reserveLine(tx, strategy, id, quantities[id], destination)
+		if err != nil {
+			return err
+		}
+		reservation.Items = append(reservation.Items, items...)
+	}
+
+	if len(reservation.Items) == 0 {
+		return nil
+	}
+	return tx.Create(&reservation).Error
+}
+
+// reserveLine reserves one product's quantity, returning no items when the
+// product's stock isn't tracked.
+func reserveLine(tx *gorm.DB, strategy allocation.Strategy, productID uint, quantity int, destination *allocation.Location) ([]domain.StockReservationItem, error) {
+	for range reserveAttempts {
+		var levels []domain.StockLevel
+		if err := tx.Joins("Warehouse").Where("stock_levels.product_id = ?", productID).Find(&levels).Error; err != nil {
+			return nil, err
+		}
+		if len(levels) == 0 {
+			return nil, nil
+		}
+
+		stock := make([]allocation.Stock, len(levels))
+		for i, level := range levels {
+			stock[i] = allocation.Stock{
+				WarehouseID: level.WarehouseID,
+				Available:   level.Available(),
+				Priority:    level.Warehouse.Priority,
+				Location:    allocation.Location{Latitude: level.Warehouse.Latitude, Longitude: level.Warehouse.Longitude},
+			}
+		}
+		allocations, ok := strategy.Allocate(quantity, stock, destination)
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrInsufficientStock, productID)
+		}
+
+		var items []domain.StockReservationItem
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			for _, allocated := range allocations {
+				result := tx.Model(&domain.StockLevel{}).
+					Where("product_id = ? AND warehouse_id = ? AND on_hand - reserved >= ?", productID, allocated.WarehouseID, allocated.Quantity).
+					Update("reserved", gorm.Expr("reserved + ?", allocated.Quantity))
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return errStockChanged
+				}
+				items = append(items, domain.StockReservationItem{
+					ProductID:   productID,
+					WarehouseID: allocated.WarehouseID,
+					Quantity:    allocated.Quantity,
+				})
+			}
+			return nil
+		})
+		if errors.Is(err, errStockChanged) {
+			continue
+		}
+		return items, err
+	}
+	return nil, fmt.Errorf("%w: %d", ErrInsufficientStock, productID)
+}
+
+// finishReservations ends the order's active reservations with the given
+// status. Committing takes the stock off hand; any other status returns it.
+// A reservation only ever finishes once, even when callers race.
+func finishReservations(tx *gorm.DB, orderID uint, status domain.ReservationStatus) error {
+	var reservations []domain.StockReservation
+	err := tx.Where("order_id = ? AND status = ?", orderID, domain.ReservationActive).
+		Find(&reservations).Error
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if _, err := finishReservation(tx, reservation.ID, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishReservation reports false when the reservation had already ended.
+func finishReservation(tx *gorm.DB, id uint, status domain.ReservationStatus) (bool, error) {
+	result := tx.Model(&domain.StockReservation{}).
+		Where("id = ? AND status = ?", id, domain.ReservationActive).
+		Update("status", status)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var items []domain.StockReservationItem
+	if err := tx.Where("stock_reservation_id = ?", id).Order("product_id").Find(&items).Error; err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		updates := map[string]any{"reserved": gorm.Expr("reserved - ?", item.Quantity)}
+		if status == domain.ReservationCommitted {
+			updates["on_hand"] = gorm.Expr("on_hand - ?", item.Quantity)
+		}
+		err := tx.Model(&domain.StockLevel{}).
+			Where("product_id = ? AND warehouse_id = ?", item.ProductID, item.WarehouseID).
+			Updates(updates).Error
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// CommitReservations takes the stock held for the order off hand, and does
+// nothing if that already happened. If the reservation expired the stock
+// is reserved again first, failing with ErrInsufficientStock if it has
+// been sold to someone else since.
+func (r *Repository) CommitReservations(orderID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the order serializes commits of the same order.
+		var order domain.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&order, orderID).Error
+		if err != nil {
+			return err
+		}
+		return r.commitReservations(tx, &order, order.Status)
+	})
+}
+
+// commitReservations commits the reservations of an order whose row is
+// locked and whose items are loaded. It has the shape of an OrderHook.
+func (r *Repository) commitReservations(tx *gorm.DB, order *domain.Order, _ domain.OrderStatus) error {
+	var statuses []domain.ReservationStatus
+	err := tx.Model(&domain.StockReservation{}).
+		Where("order_id = ?", order.ID).
+		Pluck("status", &statuses).Error
+	if err != nil {
+		return err
+	}
+	if slices.Contains(statuses, domain.ReservationCommitted) {
+		return nil
+	}
+	if !slices.Contains(statuses, domain.ReservationActive) {
+		quantities := make(map[uint]int, len(order.Items))
+		for _, item := range order.Items {
+			quantities[item.ProductID] += item.Quantity
+		}
+		if err := reserveStock(tx, r.allocator, order.ID, quantities, nil); err != nil {
+			return err
+		}
+	}
+
+	return finishReservations(tx, order.ID, domain.ReservationCommitted)
+}
+
+// releaseReservations returns the stock held for an order. It has the
+// shape of an OrderHook.
+func releaseReservations(tx *gorm.DB, order *domain.Order, _ domain.OrderStatus) error {
+	return finishReservations(tx, order.ID, domain.ReservationReleased)
+}
+
+// ReleaseExpiredReservations returns the stock of reservations that
+// expired before now and reports how many were released.
+func (r *Repository) ReleaseExpiredReservations(now time.Time) (int, error) {
+	var ids []uint
+	err := r.db.Model(&domain.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", domain.ReservationActive, now).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		var ok bool
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			ok, err = finishReservation(tx, id, domain.ReservationExpired)
+			return err
+		})
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+	return released, nil
+}
@@ -0,0 +1,325 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/allocation"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupRepository opens a file-backed database so that goroutines get
+// their own connections. Transactions take the write lock up front and
+// wait for it, as concurrent writers would on Postgres.
+func setupRepository(t *testing.T) (*repository.Repository, *gorm.DB) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=10000&_txlock=immediate&_journal_mode=WAL"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite db: %v", err)
+	}
+
+	repo := repository.NewRepository(db)
+	if err := repo.Migrate(); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	return repo, db
+}
+
+// seedStockedProduct creates a product with onHand units in each of the
+// given warehouses, creating the warehouses if needed.
+func seedStockedProduct(t *testing.T, repo *repository.Repository, onHand ...int) uint {
+	t.Helper()
+
+	product, err := repo.CreateProduct(domain.Product{Name: "apple", PriceCents: 100}, 0)
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	for i, quantity := range onHand {
+		warehouseID := seedWarehouse(t, repo, domain.Warehouse{Name: fmt.Sprintf("warehouse %d", i+1), Priority: i})
+		if _, err := repo.SetStock(product.ID, warehouseID, quantity); err != nil {
+			t.Fatalf("failed to set stock: %v", err)
+		}
+	}
+	return product.ID
+}
+
+// seedWarehouse returns the ID of the warehouse with the given name,
+// creating it if it doesn't exist yet.
+func seedWarehouse(t *testing.T, repo *repository.Repository, warehouse domain.Warehouse) uint {
+	t.Helper()
+
+	created, err := repo.CreateWarehouse(warehouse)
+	if errors.Is(err, repository.ErrWarehouseAlreadyExists) {
+		warehouses, err := repo.GetWarehouses()
+		if err != nil {
+			t.Fatalf("failed to get warehouses: %v", err)
+		}
+		for _, existing := range warehouses {
+			if existing.Name == warehouse.Name {
+				return existing.ID
+			}
+		}
+	}
+	if err != nil {
+		t.Fatalf("failed to create warehouse: %v", err)
+	}
+	return created.ID
+}
+
+// stockOf totals a product's stock over all warehouses.
+func stockOf(t *testing.T, repo *repository.Repository, productID uint) domain.StockLevel {
+	t.Helper()
+
+	levels, err := repo.GetStock(productID)
+	if err != nil {
+		t.Fatalf("failed to get stock: %v", err)
+	}
+	total := domain.StockLevel{ProductID: productID}
+	for _, level := range levels {
+		total.OnHand += level.OnHand
+		total.Reserved += level.Reserved
+	}
+	return total
+}
+
+// runConcurrently calls fn from n goroutines at once and returns how many
+// calls succeeded. Any error other than expected fails the test.
+func runConcurrently(t *testing.T, n int, expected error, fn func(i int) error) int {
+	t.Helper()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		start     = make(chan struct{})
+	)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			err := fn(i)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, expected):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return succeeded
+}
+
+func TestCreateOrder_ConcurrentReservationsNeverOversell(t *testing.T) {
+	repo, _ := setupRepository(t)
+	productID := seedStockedProduct(t, repo, 10)
+	usd := money.Identity(repo.BaseCurrency())
+
+	succeeded := runConcurrently(t, 25, repository.ErrInsufficientStock, func(i int) error {
+		_, err := repo.CreateOrder(uint(i+1), []repository.OrderItemInput{{ProductID: productID, Quantity: 1}}, usd, repository.OrderOptions{})
+		return err
+	})
+
+	if succeeded != 10 {
+		t.Fatalf("expected 10 orders to reserve stock, got %d", succeeded)
+	}
+	if stock := stockOf(t, repo, productID); stock.OnHand != 10 || stock.Reserved != 10 {
+		t.Fatalf("expected 10 on hand and 10 reserved, got %+v", stock)
+	}
+}
+
+func TestReservations_ReleasedOnceWhenCancelAndExpiryRace(t *testing.T) {
+	repo, _ := setupRepository(t)
+	productID := seedStockedProduct(t, repo, 10)
+	usd := money.Identity(repo.BaseCurrency())
+
+	var orderIDs []uint
+	for userID := uint(1); userID <= 5; userID++ {
+		order, err := repo.CreateOrder(userID, []repository.OrderItemInput{{ProductID: productID, Quantity: 2}}, usd, repository.OrderOptions{})
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	// Each order is cancelled while the sweeper expires everything, so each
+	// reservation is ended by whichever gets there first.
+	expiry := time.Now().Add(repository.ReservationTTL + time.Minute)
+	runConcurrently(t, len(orderIDs)*2, repository.ErrInvalidOrderTransition, func(i int) error {
+		if i%2 == 0 {
+			_, err := repo.ReleaseExpiredReservations(expiry)
+			return err
+		}
+		_, err := repo.CancelOrder(uint(i/2+1), orderIDs[i/2])
+		return err
+	})
+
+	if stock := stockOf(t, repo, productID); stock.OnHand != 10 || stock.Reserved != 0 {
+		t.Fatalf("expected all stock to be returned exactly once, got %+v", stock)
+	}
+}
+
+func TestCommitReservations_ConcurrentCommitsDecrementStock(t *testing.T) {
+	repo, _ := setupRepository(t)
+	productID := seedStockedProduct(t, repo, 10)
+	usd := money.Identity(repo.BaseCurrency())
+
+	var orderIDs []uint
+	for userID := uint(1); userID <= 4; userID++ {
+		order, err := repo.CreateOrder(userID, []repository.OrderItemInput{{ProductID: productID, Quantity: 2}}, usd, repository.OrderOptions{})
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	// Every order is committed twice at once; only the first commit counts.
+	succeeded := runConcurrently(t, len(orderIDs)*2, nil, func(i int) error {
+		return repo.CommitReservations(orderIDs[i/2])
+	})
+
+	if succeeded != len(orderIDs)*2 {
+		t.Fatalf("expected every commit to succeed, got %d", succeeded)
+	}
+	if stock := stockOf(t, repo, productID); stock.OnHand != 2 || stock.Reserved != 0 {
+		t.Fatalf("expected 2 on hand and nothing reserved, got %+v", stock)
+	}
+}
+
+func TestAdjustStock_ConcurrentDecrementsStopAtReserved(t *testing.T) {
+	repo, _ := setupRepository(t)
+	productID := seedStockedProduct(t, repo, 10)
+	warehouseID := stockLevels(t, repo, productID)[0].WarehouseID
+
+	_, err := repo.CreateOrder(1, []repository.OrderItemInput{{ProductID: productID, Quantity: 3}}, money.Identity(repo.BaseCurrency()), repository.OrderOptions{})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	succeeded := runConcurrently(t, 20, repository.ErrStockBelowReserved, func(int) error {
+		_, err := repo.AdjustStock(productID, warehouseID, -1)
+		return err
+	})
+
+	if succeeded != 7 {
+		t.Fatalf("expected 7 decrements to succeed, got %d", succeeded)
+	}
+	if stock := stockOf(t, repo, productID); stock.OnHand != 3 || stock.Reserved != 3 {
+		t.Fatalf("expected 3 on hand and 3 reserved, got %+v", stock)
+	}
+}
+
+func TestCreateOrder_ConcurrentReservationsAcrossWarehousesNeverOversell(t *testing.T) {
+	repo, _ := setupRepository(t)
+	productID := seedStockedProduct(t, repo, 3, 4, 5)
+	usd := money.Identity(repo.BaseCurrency())
+
+	succeeded := runConcurrently(t, 12, repository.ErrInsufficientStock, func(i int) error {
+		_, err := repo.CreateOrder(uint(i+1), []repository.OrderItemInput{{ProductID: productID, Quantity: 2}}, usd, repository.OrderOptions{})
+		return err
+	})
+
+	// 12 units can fill at most 6 orders of 2, split across warehouses.
+	if succeeded != 6 {
+		t.Fatalf("expected 6 orders to reserve stock, got %d", succeeded)
+	}
+	for _, level := range stockLevels(t, repo, productID) {
+		if level.Reserved > level.OnHand {
+			t.Fatalf("warehouse %d is oversold: %+v", level.WarehouseID, level)
+		}
+	}
+	if stock := stockOf(t, repo, productID); stock.Reserved != 12 {
+		t.Fatalf("expected 12 reserved, got %+v", stock)
+	}
+}
+
+func TestCreateOrder_AllocationStrategies(t *testing.T) {
+	berlin := allocation.Location{Latitude: 52.52, Longitude: 13.40}
+
+	tests := []struct {
+		name     string
+		strategy string
+		shipTo   *allocation.Location
+		quantity int
+		// expected reserved units per warehouse: Lisbon, Warsaw, Paris.
+		expected []int
+	}{
+		{name: "priority", strategy: allocation.StrategyPriority, quantity: 4, expected: []int{2, 2, 0}},
+		{name: "closest", strategy: allocation.StrategyClosest, shipTo: &berlin, quantity: 4, expected: []int{0, 2, 2}},
+		{name: "closest without destination", strategy: allocation.StrategyClosest, quantity: 4, expected: []int{2, 2, 0}},
+		{name: "fewest splits", strategy: allocation.StrategyFewestSplits, quantity: 4, expected: []int{0, 0, 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, _ := setupRepository(t)
+			if err := repo.SetAllocationStrategy(test.strategy); err != nil {
+				t.Fatalf("failed to set strategy: %v", err)
+			}
+
+			product, err := repo.CreateProduct(domain.Product{Name: "apple", PriceCents: 100}, 0)
+			if err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+			warehouses := []struct {
+				warehouse domain.Warehouse
+				onHand    int
+			}{
+				{domain.Warehouse{Name: "Lisbon", Latitude: 38.72, Longitude: -9.14, Priority: 1}, 2},
+				{domain.Warehouse{Name: "Warsaw", Latitude: 52.23, Longitude: 21.01, Priority: 2}, 2},
+				{domain.Warehouse{Name: "Paris", Latitude: 48.86, Longitude: 2.35, Priority: 3}, 10},
+			}
+			ids := make([]uint, len(warehouses))
+			for i, w := range warehouses {
+				ids[i] = seedWarehouse(t, repo, w.warehouse)
+				if _, err := repo.SetStock(product.ID, ids[i], w.onHand); err != nil {
+					t.Fatalf("failed to set stock: %v", err)
+				}
+			}
+
+			items := []repository.OrderItemInput{{ProductID: product.ID, Quantity: test.quantity}}
+			if _, err := repo.CreateOrder(1, items, money.Identity(repo.BaseCurrency()), repository.OrderOptions{ShipTo: test.shipTo}); err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+
+			reserved := make(map[uint]int)
+			for _, level := range stockLevels(t, repo, product.ID) {
+				reserved[level.WarehouseID] = level.Reserved
+			}
+			for i, id := range ids {
+				if reserved[id] != test.expected[i] {
+					t.Fatalf("expected %v reserved, got %v", test.expected, reserved)
+				}
+			}
+		})
+	}
+}
+
+func stockLevels(t *testing.T, repo *repository.Repository, productID uint) []domain.StockLevel {
+	t.Helper()
+
+	levels, err := repo.GetStock(productID)
+	if err != nil {
+		t.Fatalf("failed to get stock: %v", err)
+	}
+	return levels
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetSeller sets who invoices are issued by from now on. Invoices already
+// issued keep the seller they were issued with.
+func (r *Repository) SetSeller(seller domain.Seller) {
+	r.seller = seller
+}
+
+// issueInvoice is an OrderHook that issues the paid order its invoice. The
+// number is taken from the year's sequence inside the transaction that
+// pays the order, with the sequence row locked, so concurrent payments
+// queue for it and a payment that rolls back gives its number back.
+func (r *Repository) issueInvoice(tx *gorm.DB, order *domain.Order, _ domain.OrderStatus) error {
+	var existing int64
+	if err := tx.Model(&domain.Invoice{}).Where("order_id = ?", order.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	issuedAt := time.Now().UTC()
+	sequence, err := nextInvoiceSequence(tx, issuedAt.Year())
+	if err != nil {
+		return err
+	}
+
+	var user domain.User
+	if err := tx.Select("id", "user_name").First(&user, order.UserID).Error; err != nil {
+		return err
+	}
+	billTo := []string{user.UserName}
+	if address := order.ShippingAddress; address.Country != "" {
+		billTo = []string{address.Name, address.Street}
+		city := strings.TrimSpace(strings.Join([]string{address.PostalCode, address.City, address.Region}, " "))
+		billTo = append(billTo, city, address.Country)
+	}
+
+	invoice := domain.Invoice{
+		OrderID:       order.ID,
+		Number:        fmt.Sprintf("%d-%06d", issuedAt.Year(), sequence),
+		Year:          issuedAt.Year(),
+		Sequence:      sequence,
+		IssuedAt:      issuedAt,
+		SellerName:    r.seller.Name,
+		SellerAddress: strings.Join(r.seller.Address, "\n"),
+		SellerTaxID:   r.seller.TaxID,
+		SellerEmail:   r.seller.Email,
+		BillTo:        strings.Join(billTo, "\n"),
+	}
+	if err := tx.Create(&invoice).Error; err != nil {
+		return err
+	}
+	order.Invoice = &invoice
+	return nil
+}
+
+// nextInvoiceSequence takes the next number in the year's sequence.
+func nextInvoiceSequence(tx *gorm.DB, year int) (int, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.InvoiceSequence{Year: year}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var sequence domain.InvoiceSequence
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("year = ?", year).
+		First(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+	sequence.Last++
+	if err := tx.Model(&sequence).Where("year = ?", year).Update("last", sequence.Last).Error; err != nil {
+		return 0, err
+	}
+	return sequence.Last, nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/allocation"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+	"gorm.io/gorm"
+)
+
+type OrderItemInput struct {
+	ProductID uint
+	Quantity  int
+}
+
+// OrderOptions are the optional parts of an order.
+type OrderOptions struct {
+	// ShipTo is where the order is shipped, for picking warehouses.
+	ShipTo *allocation.Location
+	// TaxAddress is where the order is taxed. Orders without one can only
+	// be placed while there are no taxes to charge; see applyTax.
+	TaxAddress *tax.Address
+	// CouponCode names a coupon to discount the order with; see
+	// applyCoupon.
+	CouponCode string
+	// AddressID picks one of the user's addresses to ship to. Its
+	// coordinates stand in for ShipTo when that isn't given, and the order
+	// is always taxed for its jurisdiction; a TaxAddress that disagrees is
+	// ErrTaxAddressMismatch.
+	AddressID *uint
+	// ShippingMethodID picks how the order is shipped, and needs an
+	// address. It is required when shipping zones are set up; see
+	// applyShipping.
+	ShippingMethodID *uint
+}
+
+// CreateOrder prices the items at the products' current prices, sales
+// included, converted with prices, and saves the order. Lines for the same
+// product are merged. Stock is reserved for the order until it is paid,
+// cancelled or the reservation expires, from the warehouses the allocation
+// strategy picks for shipping to opts.ShipTo.
+func (r *Repository) CreateOrder(userID uint, items []OrderItemInput, prices money.Converter, opts OrderOptions) (*domain.Order, error) {
+	var order *domain.Order
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var coupon *domain.Coupon
+		if opts.CouponCode != "" {
+			var err error
+			if coupon, err = findCoupon(tx, opts.CouponCode); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		order, err = r.createOrder(tx, userID, items, prices, opts, coupon)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *Repository) createOrder(tx *gorm.DB, userID uint, items []OrderItemInput, prices money.Converter, opts OrderOptions, coupon *domain.Coupon) (*domain.Order, error) {
+	order := domain.Order{
+		UserID:           userID,
+		Status:           domain.OrderPending,
+		Currency:         prices.Currency().Code,
+		ExchangeRate:     prices.Rate(),
+		PricesIncludeTax: r.pricesIncludeTax,
+	}
+
+	quantities := make(map[uint]int, len(items))
+	var productIDs []uint
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	var products []domain.Product
+	err := pricedProducts(tx, time.Now().UTC()).
+		Where("products.id IN ?", productIDs).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]domain.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	for _, id := range productIDs {
+		product, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrProductNotFound, id)
+		}
+
+		item := domain.OrderItem{
+			ProductID:      product.ID,
+			ProductName:    product.Name,
+			UnitPriceCents: prices.Convert(product.CurrentPriceCents).Amount,
+			Quantity:       quantities[id],
+		}
+		item.LineTotalCents = item.UnitPriceCents * int64(item.Quantity)
+		order.SubtotalCents += item.LineTotalCents
+		order.Items = append(order.Items, item)
+	}
+
+	var shippingCents int64
+	if opts.AddressID != nil {
+		address, err := findAddress(tx, userID, *opts.AddressID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAddressNotFound
+			}
+			return nil, err
+		}
+		if shippingCents, err = applyShipping(tx, &order, *address, opts.ShippingMethodID, byID, prices); err != nil {
+			return nil, err
+		}
+		if opts.ShipTo == nil && address.Latitude != nil && address.Longitude != nil {
+			opts.ShipTo = &allocation.Location{Latitude: *address.Latitude, Longitude: *address.Longitude}
+		}
+		jurisdiction := tax.Address{Country: address.Country, Region: address.Region}
+		if opts.TaxAddress != nil && *opts.TaxAddress != jurisdiction {
+			return nil, ErrTaxAddressMismatch
+		}
+		opts.TaxAddress = &jurisdiction
+	} else if opts.ShippingMethodID != nil {
+		return nil, ErrShippingAddressRequired
+	}
+
+	if coupon != nil {
+		if err := r.applyCoupon(tx, &order, *coupon, byID, shippingCents, prices); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.applyTax(tx, &order, byID, opts.TaxAddress); err != nil {
+		return nil, err
+	}
+	order.TotalCents = order.SubtotalCents - order.DiscountCents + order.ShippingCents
+	if !order.PricesIncludeTax {
+		order.TotalCents += order.TaxCents
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+	if coupon != nil {
+		redemption := domain.CouponRedemption{CouponID: coupon.ID, UserID: userID, OrderID: order.ID}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := reserveStock(tx, r.allocator, order.ID, quantities, opts.ShipTo); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *Repository) GetOrders(userID uint) ([]domain.Order, error) {
+	var result []domain.Order
+
+	err := r.db.Preload("Items").
+		Preload("Discounts").
+		Preload("Taxes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds.Items").
+		Preload("Invoice").
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetOrder returns the order only if it belongs to the user.
+func (r *Repository) GetOrder(userID, orderID uint) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Discounts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Taxes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds.Items").
+		Preload("Invoice").
+		Where("user_id = ?", userID).
+		First(&order, orderID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// hasPurchased reports whether the user has paid for an order of the
+// product. Orders that were never paid, or were refunded, don't count.
+func hasPurchased(tx *gorm.DB, userID, productID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&domain.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND order_items.product_id = ?", userID, productID).
+		Where("orders.status IN ?", domain.PaidOrderStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderHook runs when an order enters a status, inside the transaction
+// that moves it there, so an error aborts the transition. The order's row
+// is locked and its items are loaded.
+type OrderHook func(tx *gorm.DB, order *domain.Order, from domain.OrderStatus) error
+
+// OnOrderTransition registers a hook to run whenever an order enters the
+// status. Hooks run in the order they were registered.
+func (r *Repository) OnOrderTransition(to domain.OrderStatus, hook OrderHook) {
+	r.orderHooks[to] = append(r.orderHooks[to], hook)
+}
+
+// TransitionOrder moves an order to another status, failing with
+// ErrInvalidOrderTransition when the order's current status doesn't allow
+// it. Orders are only refunded through RefundOrder, so moving one to
+// refunded fails with ErrRefundByRefundOnly. actorID is the user making the
+// change, or 0 for the system.
+func (r *Repository) TransitionOrder(orderID uint, to domain.OrderStatus, actorID uint) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&order, orderID).Error
+		if err != nil {
+			return err
+		}
+		if to == domain.OrderRefunded {
+			return ErrRefundByRefundOnly
+		}
+		return r.transitionOrder(tx, &order, to, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CancelOrder cancels one of the user's orders that hasn't been paid yet
+// and releases the stock held for it.
+func (r *Repository) CancelOrder(userID, orderID uint) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Where("user_id = ?", userID).
+			First(&order, orderID).Error
+		if err != nil {
+			return err
+		}
+		return r.transitionOrder(tx, &order, domain.OrderCancelled, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *Repository) GetOrderTransitions(orderID uint) ([]domain.OrderTransition, error) {
+	if err := r.db.Select("id").First(&domain.Order{}, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	var result []domain.OrderTransition
+	if err := r.db.Where("order_id = ?", orderID).Order("id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// transitionOrder moves a locked order to another status, records the
+// transition and runs the hooks for the new status.
+func (r *Repository) transitionOrder(tx *gorm.DB, order *domain.Order, to domain.OrderStatus, actorID uint) error {
+	from := order.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidOrderTransition, from, to)
+	}
+
+	// The status check guards against databases that ignore the row lock.
+	result := tx.Model(&domain.Order{}).
+		Where("id = ? AND status = ?", order.ID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidOrderTransition, from, to)
+	}
+	order.Status = to
+
+	transition := domain.OrderTransition{OrderID: order.ID, From: from, To: to}
+	if actorID != 0 {
+		transition.ActorID = &actorID
+	}
+	if err := tx.Create(&transition).Error; err != nil {
+		return err
+	}
+
+	for _, hook := range r.orderHooks[to] {
+		if err := hook(tx, order, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PayOrder charges the card for one of the user's orders through the
+// gateway. The order awaits payment while the charge is authorized and
+// captured, and is paid, or its payment fails, when the provider confirms
+// the capture through HandlePaymentEvent. When the charge is refused
+// straight away the payment and the order fail at once, and the error
+// wraps payment.ErrDeclined. An order whose payment failed can be paid
+// again. An order with nothing to pay is paid at once, without the card
+// being charged.
+func (r *Repository) PayOrder(userID, orderID uint, gateway payment.Gateway, card payment.Card) (*domain.Payment, error) {
+	p, err := r.startPayment(userID, orderID, gateway.Name())
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == domain.PaymentSucceeded {
+		return p, nil
+	}
+
+	reference, err := gateway.Authorize(payment.AuthorizeRequest{
+		Card:        card,
+		AmountCents: p.AmountCents,
+		Currency:    p.Currency,
+	})
+	if err != nil {
+		return p, r.failPayment(p, err)
+	}
+	p.Reference = &reference
+	p.Status = domain.PaymentAuthorized
+	err = r.db.Model(p).Updates(map[string]any{"reference": reference, "status": p.Status}).Error
+	if err != nil {
+		return nil, errors.Join(err, gateway.Void(reference))
+	}
+
+	if err := gateway.Capture(reference, p.AmountCents); err != nil {
+		if voidErr := gateway.Void(reference); voidErr != nil {
+			err = errors.Join(err, voidErr)
+		}
+		return p, r.failPayment(p, err)
+	}
+
+	// The provider may have confirmed the capture already, so only an
+	// authorized payment moves on.
+	err = r.db.Model(p).
+		Where("status = ?", domain.PaymentAuthorized).
+		Update("status", domain.PaymentProcessing).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.First(p, p.ID).Error; err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// startPayment moves the order to awaiting payment and records a pending
+// payment for its total. When the total is zero, the payment succeeds and
+// the order is paid straight away.
+func (r *Repository) startPayment(userID, orderID uint, provider string) (*domain.Payment, error) {
+	var p domain.Payment
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			Where("user_id = ?", userID).
+			First(&order, orderID).Error
+		if err != nil {
+			return err
+		}
+
+		if order.Status == domain.OrderAwaitingPayment {
+			if err := rejectWhilePaying(tx, &order, order.Status); err != nil {
+				return err
+			}
+		} else if err := r.transitionOrder(tx, &order, domain.OrderAwaitingPayment, userID); err != nil {
+			return err
+		}
+
+		p = domain.Payment{
+			OrderID:     order.ID,
+			Provider:    provider,
+			Status:      domain.PaymentPending,
+			AmountCents: order.TotalCents,
+			Currency:    order.Currency,
+		}
+		if order.TotalCents > 0 {
+			return tx.Create(&p).Error
+		}
+
+		p.Status = domain.PaymentSucceeded
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+		return r.transitionOrder(tx, &order, domain.OrderPaid, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// failPayment marks the payment and its order as failed and returns cause.
+func (r *Repository) failPayment(p *domain.Payment, cause error) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&order, p.OrderID).Error
+		if err != nil {
+			return err
+		}
+		return r.settlePayment(tx, &order, p, domain.PaymentFailed, cause.Error())
+	})
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// HandlePaymentEvent applies a webhook from the provider to the payment it
+// is about and to the payment's order. An event that was handled before is
+// ErrPaymentEventReplayed, and events about payments that have already
+// succeeded or failed change nothing.
+func (r *Repository) HandlePaymentEvent(provider string, event payment.Event) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&domain.PaymentEvent{
+			Provider:  provider,
+			EventID:   event.ID,
+			Type:      string(event.Type),
+			Reference: event.Reference,
+		}).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrPaymentEventReplayed
+			}
+			return err
+		}
+
+		var p domain.Payment
+		err = tx.Where("provider = ? AND reference = ?", provider, event.Reference).
+			First(&p).Error
+		if err != nil {
+			return err
+		}
+		var order domain.Order
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&order, p.OrderID).Error
+		if err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case payment.EventPaymentSucceeded:
+			return r.settlePayment(tx, &order, &p, domain.PaymentSucceeded, "")
+		case payment.EventPaymentFailed:
+			return r.settlePayment(tx, &order, &p, domain.PaymentFailed, event.Reason)
+		}
+		return nil
+	})
+}
+
+// settlePayment records the outcome of an in-flight payment and moves its
+// locked order to paid or payment failed to match. A payment that
+// succeeded after the order's stock ran out can't be undone here, so the
+// order is put on hold instead of paid.
+func (r *Repository) settlePayment(tx *gorm.DB, order *domain.Order, p *domain.Payment, status domain.PaymentStatus, reason string) error {
+	result := tx.Model(p).
+		Where("status IN ?", domain.InFlightPaymentStatuses).
+		Updates(map[string]any{"status": status, "failure_reason": reason})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	p.Status = status
+	p.FailureReason = reason
+
+	if status == domain.PaymentSucceeded {
+		from := order.Status
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return r.transitionOrder(tx, order, domain.OrderPaid, 0)
+		})
+		if !errors.Is(err, ErrInsufficientStock) {
+			return err
+		}
+		order.Status = from
+		return r.transitionOrder(tx, order, domain.OrderOnHold, 0)
+	}
+	return r.transitionOrder(tx, order, domain.OrderPaymentFailed, 0)
+}
+
+// GetPayments returns the payments for one of the user's orders, oldest
+// first.
+func (r *Repository) GetPayments(userID, orderID uint) ([]domain.Payment, error) {
+	err := r.db.Select("id").Where("user_id = ?", userID).First(&domain.Order{}, orderID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var result []domain.Payment
+	if err := r.db.Where("order_id = ?", orderID).Order("id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rejectWhilePaying fails with ErrPaymentInProgress while a payment for the
+// order is in flight. It has the shape of an OrderHook, and stops an order
+// being cancelled while it may yet be paid.
+func rejectWhilePaying(tx *gorm.DB, order *domain.Order, _ domain.OrderStatus) error {
+	var count int64
+	err := tx.Model(&domain.Payment{}).
+		Where("order_id = ? AND status IN ?", order.ID, domain.InFlightPaymentStatuses).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPaymentInProgress
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// lowestPriceWindow is how far before a discount the reference price is
+// looked up, as required for EU price reduction announcements.
+const lowestPriceWindow = 30 * 24 * time.Hour
+
+// recordPriceChanges brings the product's price history up to date after
+// its base price or schedule changed. The price in effect now is appended
+// if it differs from the last recorded one, and future entries are rebuilt
+// from the schedule. A product without any history gets one starting from
+// its creation.
+func recordPriceChanges(tx *gorm.DB, productID uint) error {
+	now := time.Now().UTC()
+
+	var product domain.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	var schedule []domain.ScheduledPrice
+	if err := tx.Where("product_id = ?", productID).Find(&schedule).Error; err != nil {
+		return err
+	}
+
+	err := tx.Where("product_id = ? AND effective_from > ?", productID, now).
+		Delete(&domain.PriceChange{}).Error
+	if err != nil {
+		return err
+	}
+
+	var last domain.PriceChange
+	hasLast := true
+	err = tx.Where("product_id = ?", productID).
+		Order("effective_from desc, id desc").
+		Take(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hasLast = false
+	} else if err != nil {
+		return err
+	}
+
+	start := now
+	if !hasLast {
+		start = product.CreatedAt.UTC()
+	}
+	boundaries := []time.Time{start, now}
+	for _, price := range schedule {
+		for _, boundary := range []*time.Time{&price.StartsAt, price.EndsAt} {
+			if boundary != nil && boundary.After(start) {
+				boundaries = append(boundaries, boundary.UTC())
+			}
+		}
+	}
+	slices.SortFunc(boundaries, time.Time.Compare)
+	boundaries = slices.CompactFunc(boundaries, time.Time.Equal)
+
+	for _, boundary := range boundaries {
+		regular, current := domain.ResolvePrices(product.PriceCents, schedule, boundary)
+		if hasLast && last.PriceCents == current && last.RegularPriceCents == regular {
+			continue
+		}
+
+		last = domain.PriceChange{
+			ProductID:         productID,
+			PriceCents:        current,
+			RegularPriceCents: regular,
+			EffectiveFrom:     boundary,
+		}
+		if err := tx.Create(&last).Error; err != nil {
+			return err
+		}
+		hasLast = true
+	}
+	return nil
+}
+
+// GetPriceHistory returns the product's price changes up to now, oldest
+// first. Scheduled future changes are left out.
+func (r *Repository) GetPriceHistory(productID uint) ([]domain.PriceChange, error) {
+	if err := r.db.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var result []domain.PriceChange
+	err := r.db.Where("product_id = ? AND effective_from <= ?", productID, time.Now().UTC()).
+		Order("effective_from, id").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadLowestPrices sets LowestPrice30dCents on the products that are on
+// sale at the given time: the lowest price in effect during the 30 days
+// before the sale started. It is left nil when there is no history for
+// that period.
+func loadLowestPrices(db *gorm.DB, products []domain.Product, at time.Time) error {
+	for i := range products {
+		product := &products[i]
+		if product.CurrentPriceCents >= product.RegularPriceCents {
+			continue
+		}
+
+		var sale domain.ScheduledPrice
+		err := activeScheduledPrice(db, product.ID, domain.PriceSale, at).Take(&sale).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		saleStart := sale.StartsAt.UTC()
+		windowStart := saleStart.Add(-lowestPriceWindow)
+		inEffectAtWindowStart := db.Model(&domain.PriceChange{}).
+			Select("MAX(effective_from)").
+			Where("product_id = ? AND effective_from <= ?", product.ID, windowStart)
+
+		var lowest sql.NullInt64
+		err = db.Model(&domain.PriceChange{}).
+			Select("MIN(price_cents)").
+			Where("product_id = ? AND effective_from < ?", product.ID, saleStart).
+			Where("effective_from >= COALESCE((?), ?)", inEffectAtWindowStart, windowStart).
+			Scan(&lowest).Error
+		if err != nil {
+			return err
+		}
+		if lowest.Valid {
+			product.LowestPrice30dCents = &lowest.Int64
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+)
+
+const (
+	FacetPrice    = "price"
+	FacetCategory = "category"
+	// FacetAttributePrefix is followed by an attribute name, as in "attr.fuel".
+	FacetAttributePrefix = "attr."
+)
+
+// DefaultPriceBuckets are the bucket boundaries, in cents, used when the
+// caller doesn't configure its own.
+var DefaultPriceBuckets = []int64{1000000, 2500000, 5000000, 10000000}
+
+type GetProductFacetsInput struct {
+	Facets []string
+	// PriceBuckets are ascending boundaries; n boundaries make n+1 buckets
+	// with the first starting at 0 and the last left open.
+	PriceBuckets []int64
+}
+
+// FacetBucket is one row of a facet. Value is set for value facets such as
+// category; From and To are set for range facets such as price, with To
+// nil on the last, open-ended bucket.
+type FacetBucket struct {
+	Value string
+	From  *int64
+	To    *int64
+	Count int64
+}
+
+func IsValidFacet(name string) bool {
+	if attribute, ok := strings.CutPrefix(name, FacetAttributePrefix); ok {
+		return domain.IsValidAttributeName(attribute)
+	}
+	return name == FacetPrice || name == FacetCategory
+}
+
+func attributeFacet(name string) string {
+	return FacetAttributePrefix + name
+}
+
+// GetProductFacets counts the products matching inputs for each requested
+// facet. Each facet is counted without its own filter so a client can offer
+// the other values of that facet as alternatives.
+func (r *Repository) GetProductFacets(inputs GetProductsInput, facets GetProductFacetsInput) (map[string][]FacetBucket, error) {
+	conditions, err := r.resolveAttributeFilters(inputs.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []string
+	for _, facet := range facets.Facets {
+		if attribute, ok := strings.CutPrefix(facet, FacetAttributePrefix); ok {
+			attributes = append(attributes, attribute)
+		}
+	}
+	if _, err := r.attributeTypes(attributes); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]FacetBucket, len(facets.Facets))
+	for _, facet := range facets.Facets {
+		var buckets []FacetBucket
+		var err error
+
+		switch facet {
+		case FacetPrice:
+			buckets, err = r.priceFacet(inputs, conditions, facets.PriceBuckets)
+		case FacetCategory:
+			buckets, err = r.valueFacet(inputs, conditions, FacetCategory, "category")
+		default:
+			attribute, ok := strings.CutPrefix(facet, FacetAttributePrefix)
+			if !ok {
+				return nil, fmt.Errorf("unknown facet %q", facet)
+			}
+			buckets, err = r.valueFacet(inputs, conditions, facet, r.attributeTextExpr(attribute))
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[facet] = buckets
+	}
+
+	return result, nil
+}
+
+func (r *Repository) priceFacet(inputs GetProductsInput, conditions []attributeCondition, boundaries []int64) ([]FacetBucket, error) {
+	if len(boundaries) == 0 {
+		boundaries = DefaultPriceBuckets
+	}
+
+	var expr strings.Builder
+	args := make([]any, 0, len(boundaries))
+	expr.WriteString("CASE")
+	for i, boundary := range boundaries {
+		fmt.Fprintf(&expr, " WHEN current_price_cents < ? THEN %d", i)
+		args = append(args, boundary)
+	}
+	fmt.Fprintf(&expr, " ELSE %d END AS bucket, COUNT(*) AS count", len(boundaries))
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err := r.filterProducts(inputs, conditions, FacetPrice).
+		Select(expr.String(), args...).
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+
+	buckets := make([]FacetBucket, len(boundaries)+1)
+	var from int64
+	for i := range buckets {
+		lower := from
+		buckets[i] = FacetBucket{From: &lower, Count: counts[i]}
+		if i < len(boundaries) {
+			upper := boundaries[i]
+			buckets[i].To = &upper
+			from = upper
+		}
+	}
+	return buckets, nil
+}
+
+// valueFacet counts products per distinct value of expr, skipping missing
+// and empty values. Buckets are ordered by count, then value.
+func (r *Repository) valueFacet(inputs GetProductsInput, conditions []attributeCondition, facet, expr string) ([]FacetBucket, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := r.filterProducts(inputs, conditions, facet).
+		Select(expr + " AS value, COUNT(*) AS count").
+		Where(expr + " IS NOT NULL").
+		Where(expr + " <> ''").
+		Group(expr).
+		Order("count desc, value asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]FacetBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = FacetBucket{Value: row.Value, Count: row.Count}
+	}
+	return buckets, nil
+}
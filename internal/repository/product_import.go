@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Hiroki111/go-backend-example/internal/catalog"
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 500
+
+// errRollback is returned from a transaction callback to discard its
+// changes without reporting a failure to the caller.
+var errRollback = errors.New("rollback")
+
+type ImportProductsResult struct {
+	Created int
+	Updated int
+	Errors  []catalog.RecordError
+}
+
+// ImportProducts upserts the given records inside a single transaction.
+// A record is matched to an existing product by SKU when it has one and by
+// name otherwise, and two records can't match the same product. If any
+// record is rejected nothing is written, and with
+// dryRun nothing is written either; in both cases Created and Updated
+// describe what the import would have done.
+func (r *Repository) ImportProducts(records []catalog.Record, dryRun bool, actorID uint) (*ImportProductsResult, error) {
+	result := &ImportProductsResult{}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		seenSKUs := make(map[string]int)
+		seenNames := make(map[string]int)
+		// Records are matched against the database as it was before the
+		// import, so a record that matches a product an earlier one
+		// renames would silently undo the rename.
+		seenIDs := make(map[uint]int)
+		products := make([]domain.Product, 0, len(records))
+
+		for _, record := range records {
+			if line, ok := seenSKUs[record.SKU]; ok && record.SKU != "" {
+				result.Errors = append(result.Errors, catalog.RecordError{Line: record.Line, Message: duplicateMessage("sku", line)})
+				continue
+			}
+			if line, ok := seenNames[record.Name]; ok {
+				result.Errors = append(result.Errors, catalog.RecordError{Line: record.Line, Message: duplicateMessage("name", line)})
+				continue
+			}
+			seenSKUs[record.SKU] = record.Line
+			seenNames[record.Name] = record.Line
+
+			product, message, err := resolveImportRecord(tx, record)
+			if err != nil {
+				return err
+			}
+			if message != "" {
+				result.Errors = append(result.Errors, catalog.RecordError{Line: record.Line, Message: message})
+				continue
+			}
+
+			if line, ok := seenIDs[product.ID]; ok && product.ID != 0 {
+				result.Errors = append(result.Errors, catalog.RecordError{
+					Line:    record.Line,
+					Message: fmt.Sprintf("matches the same product as line %d", line),
+				})
+				continue
+			}
+			seenIDs[product.ID] = record.Line
+
+			if product.ID == 0 {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+			products = append(products, *product)
+		}
+
+		if dryRun || len(result.Errors) > 0 {
+			return errRollback
+		}
+
+		for i := range products {
+			action := domain.RevisionUpdate
+			if products[i].ID == 0 {
+				action = domain.RevisionCreate
+			}
+			if err := assignSlug(tx, &products[i]); err != nil {
+				return err
+			}
+			if err := tx.Save(&products[i]).Error; err != nil {
+				return err
+			}
+			if err := recordRevision(tx, products[i], action, actorID); err != nil {
+				return err
+			}
+			if err := recordPriceChanges(tx, products[i].ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveImportRecord returns the product the record should be saved as,
+// or a message explaining why the record conflicts with existing data.
+func resolveImportRecord(tx *gorm.DB, record catalog.Record) (*domain.Product, string, error) {
+	byName, err := findProduct(tx, "name = ?", record.Name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	product := byName
+	if record.SKU != "" {
+		bySKU, err := findProduct(tx, "sku = ?", record.SKU)
+		if err != nil {
+			return nil, "", err
+		}
+		if bySKU != nil {
+			if byName != nil && byName.ID != bySKU.ID {
+				return nil, "name is already used by another product", nil
+			}
+			product = bySKU
+		} else if byName != nil && byName.SKU != nil {
+			return nil, "name is already used by a product with a different sku", nil
+		}
+	}
+
+	if product == nil {
+		product = &domain.Product{}
+	}
+	product.Name = record.Name
+	product.PriceCents = record.PriceCents
+	if record.Category != nil {
+		product.Category = *record.Category
+	}
+	if record.SKU != "" {
+		sku := record.SKU
+		product.SKU = &sku
+	}
+	return product, "", nil
+}
+
+func findProduct(tx *gorm.DB, query string, args ...any) (*domain.Product, error) {
+	var products []domain.Product
+	if err := tx.Where(query, args...).Limit(1).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+	return &products[0], nil
+}
+
+func duplicateMessage(field string, line int) string {
+	return fmt.Sprintf("duplicate %s (first seen on line %d)", field, line)
+}
+
+// EachProduct calls fn for every product in ID order, loading them in
+// batches so the whole catalog is never held in memory at once.
+func (r *Repository) EachProduct(fn func(domain.Product) error) error {
+	var batch []domain.Product
+	return r.db.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, product := range batch {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
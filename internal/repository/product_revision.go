@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordRevision appends a revision holding the product's current state.
+// Updates that leave every tracked field unchanged are not recorded.
+func recordRevision(tx *gorm.DB, product domain.Product, action domain.RevisionAction, actorID uint) error {
+	// Locking the product serializes revisions of it, so that concurrent
+	// changes don't both take the next version.
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&domain.Product{}, product.ID).Error
+	if err != nil {
+		return err
+	}
+
+	var latest []domain.ProductRevision
+	err = tx.Where("product_id = ?", product.ID).
+		Order("version desc").
+		Limit(1).
+		Find(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	snapshot := domain.NewProductSnapshot(product)
+	version := 1
+	if len(latest) > 0 {
+		if action == domain.RevisionUpdate && reflect.DeepEqual(latest[0].Snapshot, snapshot) {
+			return nil
+		}
+		version = latest[0].Version + 1
+	}
+
+	revision := domain.ProductRevision{
+		ProductID: product.ID,
+		Version:   version,
+		Action:    action,
+		Snapshot:  snapshot,
+	}
+	if actorID != 0 {
+		revision.ActorID = &actorID
+	}
+	return tx.Create(&revision).Error
+}
+
+func (r *Repository) GetProductRevisions(productID uint) ([]domain.ProductRevision, error) {
+	var result []domain.ProductRevision
+
+	err := r.db.Where("product_id = ?", productID).
+		Order("version desc").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) GetProductRevision(productID uint, version int) (*domain.ProductRevision, error) {
+	var revision domain.ProductRevision
+
+	err := r.db.Where("product_id = ? AND version = ?", productID, version).
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// RollbackProduct restores the fields captured in an earlier revision. The
+// rollback itself is recorded as a new revision, so history is never lost.
+func (r *Repository) RollbackProduct(productID uint, version int, actorID uint) (*domain.Product, error) {
+	var product domain.Product
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		var revision domain.ProductRevision
+		err := tx.Where("product_id = ? AND version = ?", productID, version).
+			First(&revision).Error
+		if err != nil {
+			return err
+		}
+
+		revision.Snapshot.Apply(&product)
+		if err := assignSlug(tx, &product); err != nil {
+			return err
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrProductAlreadyExists
+			}
+			return err
+		}
+		if err := recordRevision(tx, product, domain.RevisionRollback, actorID); err != nil {
+			return err
+		}
+		if err := recordPriceChanges(tx, product.ID); err != nil {
+			return err
+		}
+		return loadPrices(tx, &product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// GetProductBySlug returns the product with the given slug, or the product
+// that had it before being renamed; compare the product's Slug to tell the
+// two apart.
+func (r *Repository) GetProductBySlug(slug string, locales []string) (*domain.Product, error) {
+	return r.getProduct(locales, "products.slug = ? OR products.id = (?)", slug,
+		r.db.Model(&domain.ProductSlug{}).Select("product_id").Where("slug = ?", slug))
+}
+
+// assignSlug gives the product a slug derived from its name, keeping its
+// current slug when the name still produces it. A slug that is replaced is
+// kept so links to it can be redirected.
+func assignSlug(tx *gorm.DB, product *domain.Product) error {
+	base := domain.Slugify(product.Name)
+	if product.Slug != "" && slugHasBase(product.Slug, base) {
+		return nil
+	}
+
+	slug, err := uniqueSlug(tx, base, product.ID)
+	if err != nil {
+		return err
+	}
+
+	if product.Slug != "" {
+		err := tx.Create(&domain.ProductSlug{Slug: product.Slug, ProductID: product.ID}).Error
+		if err != nil {
+			return err
+		}
+	}
+	// A product renamed back to an earlier name takes its old slug back.
+	if err := tx.Where("slug = ?", slug).Delete(&domain.ProductSlug{}).Error; err != nil {
+		return err
+	}
+	product.Slug = slug
+	return nil
+}
+
+// slugHasBase reports whether slug is base, possibly with a collision
+// suffix.
+func slugHasBase(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// uniqueSlug returns base, or base with the lowest numeric suffix that no
+// other product uses, now or as an old slug.
+func uniqueSlug(tx *gorm.DB, base string, productID uint) (string, error) {
+	for n := 1; ; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		var taken int64
+		err := tx.Unscoped().Model(&domain.Product{}).
+			Where("slug = ? AND id <> ?", slug, productID).
+			Count(&taken).Error
+		if err != nil {
+			return "", err
+		}
+		if taken > 0 {
+			continue
+		}
+		err = tx.Model(&domain.ProductSlug{}).
+			Where("slug = ? AND product_id <> ?", slug, productID).
+			Count(&taken).Error
+		if err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return slug, nil
+		}
+	}
+}
+
+// migrateProductSlugs gives products created before slugs existed one, so
+// the unique index can be added.
+func (r *Repository) migrateProductSlugs() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&domain.Product{}) || migrator.HasColumn(&domain.Product{}, "Slug") {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(&domain.ProductSlug{}); err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE products ADD COLUMN slug text").Error; err != nil {
+			return err
+		}
+
+		var products []domain.Product
+		if err := tx.Unscoped().Select("id", "name").Order("id").Find(&products).Error; err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := assignSlug(tx, &product); err != nil {
+				return err
+			}
+			err := tx.Unscoped().Model(&domain.Product{}).
+				Where("id = ?", product.ID).
+				UpdateColumn("slug", product.Slug).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
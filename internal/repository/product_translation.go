@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// localizedProducts is pricedProducts with localized_name and
+// localized_description resolved for the locales, which are tried in
+// order before falling back to the product's own name and description.
+// It also selects available_quantity, which is NULL for untracked stock.
+func localizedProducts(db *gorm.DB, at time.Time, locales []string) *gorm.DB {
+	name, nameArgs := localizedColumn("name", locales)
+	description, descriptionArgs := localizedColumn("description", locales)
+
+	return db.Session(&gorm.Session{NewDB: true}).
+		Table("(?) AS products", pricedProducts(db, at)).
+		Select(
+			"products.*, "+name+" AS localized_name, "+description+" AS localized_description, "+
+				"(SELECT SUM(stock_levels.on_hand - stock_levels.reserved) FROM stock_levels WHERE stock_levels.product_id = products.id) AS available_quantity",
+			append(nameArgs, descriptionArgs...)...,
+		)
+}
+
+func localizedColumn(column string, locales []string) (string, []any) {
+	if len(locales) == 0 {
+		return "products." + column, nil
+	}
+
+	var expr strings.Builder
+	args := make([]any, len(locales))
+	expr.WriteString("COALESCE(")
+	for i, locale := range locales {
+		fmt.Fprintf(&expr,
+			"(SELECT NULLIF(product_translations.%[1]s, '') FROM product_translations WHERE product_translations.product_id = products.id AND product_translations.locale = ?), ",
+			column,
+		)
+		args[i] = locale
+	}
+	expr.WriteString("products." + column + ")")
+	return expr.String(), args
+}
+
+// SetProductTranslation creates or replaces the product's translation for
+// the translation's locale.
+func (r *Repository) SetProductTranslation(data domain.ProductTranslation) (*domain.ProductTranslation, error) {
+	translation := domain.ProductTranslation{
+		ProductID:   data.ProductID,
+		Locale:      data.Locale,
+		Name:        data.Name,
+		Description: data.Description,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Product{}, data.ProductID).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description"}),
+		}).Create(&translation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (r *Repository) GetProductTranslations(productID uint) ([]domain.ProductTranslation, error) {
+	if err := r.db.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var result []domain.ProductTranslation
+	if err := r.db.Where("product_id = ?", productID).Order("locale").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) DeleteProductTranslation(productID uint, locale string) error {
+	result := r.db.Where("product_id = ? AND locale = ?", productID, locale).Delete(&domain.ProductTranslation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repository) GetDeletedProducts() ([]domain.Product, error) {
+	var result []domain.Product
+
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at desc").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RestoreProduct brings a soft-deleted product back. It fails with
+// ErrProductAlreadyExists when an active product has taken its name or SKU
+// in the meantime.
+func (r *Repository) RestoreProduct(id uint, actorID uint) (*domain.Product, error) {
+	var product domain.Product
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&product, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrProductAlreadyExists
+			}
+			return err
+		}
+		if err := recordRevision(tx, product, domain.RevisionRestore, actorID); err != nil {
+			return err
+		}
+		return loadPrices(tx, &product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// PurgeProduct permanently removes a product that is already in the trash,
+// along with the rows that only make sense while it exists. A product that
+// stock is still reserved for or that a coupon applies to is
+// ErrProductInUse.
+func (r *Repository) PurgeProduct(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product domain.Product
+		if err := tx.Unscoped().Select("id").Where("deleted_at IS NOT NULL").First(&product, id).Error; err != nil {
+			return err
+		}
+		inUse, err := productsInUse(tx, []uint{id})
+		if err != nil {
+			return err
+		}
+		if len(inUse) > 0 {
+			return ErrProductInUse
+		}
+		return purgeProducts(tx, []uint{id})
+	})
+}
+
+// EmptyProductTrash permanently removes every soft-deleted product that
+// isn't in use, as PurgeProduct would, and returns how many were removed.
+// Products in use stay in the trash.
+func (r *Repository) EmptyProductTrash() (int64, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&domain.Product{}).
+			Where("deleted_at IS NOT NULL").
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		inUse, err := productsInUse(tx, ids)
+		if err != nil {
+			return err
+		}
+		ids = slices.DeleteFunc(ids, func(id uint) bool { return slices.Contains(inUse, id) })
+		return purgeProducts(tx, ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// productsInUse returns those of the products that active stock
+// reservations hold or coupons apply to.
+func productsInUse(tx *gorm.DB, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var reserved []uint
+	err := tx.Model(&domain.StockReservationItem{}).
+		Joins("JOIN stock_reservations ON stock_reservations.id = stock_reservation_items.stock_reservation_id").
+		Where("stock_reservations.status = ? AND stock_reservation_items.product_id IN ?", domain.ReservationActive, ids).
+		Distinct().
+		Pluck("stock_reservation_items.product_id", &reserved).Error
+	if err != nil {
+		return nil, err
+	}
+	var discounted []uint
+	err = tx.Model(&domain.Coupon{}).
+		Where("product_id IN ?", ids).
+		Distinct().
+		Pluck("product_id", &discounted).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(reserved, discounted...), nil
+}
+
+// purgeProducts hard-deletes the products and everything keyed by them.
+// Order lines, refunds, reviews and revisions are history and stay.
+func purgeProducts(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, model := range []any{
+		&domain.StockLevel{},
+		&domain.StockThreshold{},
+		&domain.StockSubscription{},
+		&domain.ScheduledPrice{},
+		&domain.PriceChange{},
+		&domain.ProductTranslation{},
+		&domain.ProductSlug{},
+		&domain.CartItem{},
+	} {
+		if err := tx.Where("product_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	err := tx.Where("product_id IN ? OR related_product_id IN ?", ids, ids).
+		Delete(&domain.ProductAffinity{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&domain.Product{}, ids).Error
+}
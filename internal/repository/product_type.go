@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// CreateProductType saves a new attribute schema. An attribute name must
+// have the same type in every product type so it can be filtered on across
+// the whole catalog.
+func (r *Repository) CreateProductType(data domain.ProductType) (*domain.ProductType, error) {
+	productType := domain.ProductType{Name: data.Name, Attributes: data.Attributes}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, attribute := range productType.Attributes {
+			var conflicts int64
+			err := tx.Model(&domain.AttributeDefinition{}).
+				Where("name = ? AND type <> ?", attribute.Name, attribute.Type).
+				Count(&conflicts).Error
+			if err != nil {
+				return err
+			}
+			if conflicts > 0 {
+				return fmt.Errorf("%w: %s", ErrAttributeTypeConflict, attribute.Name)
+			}
+		}
+
+		if err := tx.Create(&productType).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrProductTypeAlreadyExists
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &productType, nil
+}
+
+func (r *Repository) GetProductTypes() ([]domain.ProductType, error) {
+	var result []domain.ProductType
+
+	err := r.db.Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("name").Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateProductAttributes checks the product's attribute values against
+// its product type. Products without a type can't have attributes.
+func validateProductAttributes(tx *gorm.DB, product domain.Product) error {
+	if product.ProductTypeID == nil {
+		for name := range product.Attributes {
+			return &domain.AttributeError{Attribute: name, Message: "requires a product type"}
+		}
+		return nil
+	}
+
+	var productType domain.ProductType
+	if err := tx.Preload("Attributes").First(&productType, *product.ProductTypeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductTypeNotFound
+		}
+		return err
+	}
+	return productType.Validate(product.Attributes)
+}
+
+// AttributeFilter narrows products down by one attribute. Values match any
+// of the given values; Min and Max are inclusive bounds for numbers.
+type AttributeFilter struct {
+	Name   string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
+
+// attributeCondition is an AttributeFilter with its values converted to
+// the attribute's type.
+type attributeCondition struct {
+	name     string
+	attrType domain.AttributeType
+	values   []any
+	min      *float64
+	max      *float64
+}
+
+func (r *Repository) attributeTypes(names []string) (map[string]domain.AttributeType, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var rows []domain.AttributeDefinition
+	if err := r.db.Select("DISTINCT name, type").Where("name IN ?", names).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]domain.AttributeType, len(rows))
+	for _, row := range rows {
+		types[row.Name] = row.Type
+	}
+	for _, name := range names {
+		if _, ok := types[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttribute, name)
+		}
+	}
+	return types, nil
+}
+
+func (r *Repository) resolveAttributeFilters(filters []AttributeFilter) ([]attributeCondition, error) {
+	names := make([]string, len(filters))
+	for i, filter := range filters {
+		names[i] = filter.Name
+	}
+	types, err := r.attributeTypes(names)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]attributeCondition, len(filters))
+	for i, filter := range filters {
+		condition := attributeCondition{
+			name:     filter.Name,
+			attrType: types[filter.Name],
+			min:      filter.Min,
+			max:      filter.Max,
+		}
+		if (filter.Min != nil || filter.Max != nil) && condition.attrType != domain.AttributeNumber {
+			return nil, fmt.Errorf("%w: %s is not a number", ErrInvalidAttributeFilter, filter.Name)
+		}
+
+		for _, value := range filter.Values {
+			var converted any = value
+			switch condition.attrType {
+			case domain.AttributeNumber:
+				converted, err = strconv.ParseFloat(value, 64)
+			case domain.AttributeBool:
+				converted, err = strconv.ParseBool(value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidAttributeFilter, filter.Name)
+			}
+			condition.values = append(condition.values, converted)
+		}
+		conditions[i] = condition
+	}
+	return conditions, nil
+}
+
+// attributeExpr extracts an attribute as a value comparable with Go values
+// of its type. Attribute names are validated on write, so inlining them is
+// safe.
+func (r *Repository) attributeExpr(name string, attrType domain.AttributeType) string {
+	if r.db.Dialector.Name() == "postgres" {
+		switch attrType {
+		case domain.AttributeNumber:
+			return fmt.Sprintf("(attributes->>'%s')::numeric", name)
+		case domain.AttributeBool:
+			return fmt.Sprintf("(attributes->>'%s')::boolean", name)
+		}
+		return fmt.Sprintf("(attributes->>'%s')", name)
+	}
+	return fmt.Sprintf("json_extract(attributes, '$.%s')", name)
+}
+
+// attributeTextExpr extracts an attribute as text, rendering booleans as
+// "true" and "false" on every database.
+func (r *Repository) attributeTextExpr(name string) string {
+	if r.db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("(attributes->>'%s')", name)
+	}
+	return fmt.Sprintf(
+		"(CASE json_type(attributes, '$.%[1]s') WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE json_extract(attributes, '$.%[1]s') END)",
+		name,
+	)
+}
+
+func (r *Repository) applyAttributeCondition(query *gorm.DB, condition attributeCondition) *gorm.DB {
+	expr := r.attributeExpr(condition.name, condition.attrType)
+
+	if len(condition.values) > 0 {
+		query = query.Where(expr+" IN ?", condition.values)
+	}
+	if condition.min != nil {
+		query = query.Where(expr+" >= ?", *condition.min)
+	}
+	if condition.max != nil {
+		query = query.Where(expr+" <= ?", *condition.max)
+	}
+	return query
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// affinityJob is the JobCursor name for UpdateProductAffinities.
+const affinityJob = "product_affinities"
+
+// affinityBatchSize is how many orders are counted per transaction.
+const affinityBatchSize = 500
+
+type RelatedReason string
+
+const (
+	// RelatedBoughtTogether products were bought in the same orders.
+	RelatedBoughtTogether RelatedReason = "bought_together"
+	// RelatedSimilar products share the category and are close in price.
+	RelatedSimilar RelatedReason = "similar"
+)
+
+type RelatedProduct struct {
+	Product domain.Product
+	Reason  RelatedReason
+}
+
+// UpdateProductAffinities adds the orders placed since the last run to the
+// co-purchase counts and returns how many orders it went through. Orders
+// are left to settle for ReservationTTL first and only count if they have
+// been paid by then; refunds and cancellations after that are not taken
+// back out.
+func (r *Repository) UpdateProductAffinities(now time.Time) (int, error) {
+	cutoff := now.Add(-ReservationTTL)
+	counted := 0
+
+	for {
+		processed, err := r.countAffinityBatch(cutoff)
+		counted += processed
+		if err != nil || processed < affinityBatchSize {
+			return counted, err
+		}
+	}
+}
+
+// countAffinityBatch counts the next batch of orders placed before cutoff
+// and returns how many orders it went through. The batch ends at the first
+// order that is still settling, so the cursor never moves past it.
+func (r *Repository) countAffinityBatch(cutoff time.Time) (int, error) {
+	var orders []domain.Order
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		cursor := domain.JobCursor{Name: affinityJob}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&cursor).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cursor, "name = ?", affinityJob).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Preload("Items").
+			Where("id > ?", cursor.Position).
+			Order("id").
+			Limit(affinityBatchSize).
+			Find(&orders).Error
+		if err != nil {
+			return err
+		}
+		for i, order := range orders {
+			if order.CreatedAt.After(cutoff) {
+				orders = orders[:i]
+				break
+			}
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		counts := make(map[[2]uint]int)
+		for _, order := range orders {
+			if order.DeletedAt.Valid || !slices.Contains(domain.PaidOrderStatuses, order.Status) {
+				continue
+			}
+
+			seen := make(map[uint]bool, len(order.Items))
+			var productIDs []uint
+			for _, item := range order.Items {
+				if !seen[item.ProductID] {
+					seen[item.ProductID] = true
+					productIDs = append(productIDs, item.ProductID)
+				}
+			}
+			for i, a := range productIDs {
+				for _, b := range productIDs[i+1:] {
+					counts[[2]uint{a, b}]++
+					counts[[2]uint{b, a}]++
+				}
+			}
+		}
+
+		if len(counts) > 0 {
+			affinities := make([]domain.ProductAffinity, 0, len(counts))
+			for pair, count := range counts {
+				affinities = append(affinities, domain.ProductAffinity{ProductID: pair[0], RelatedProductID: pair[1], Count: count})
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "product_id"}, {Name: "related_product_id"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"count": gorm.Expr("product_affinities.count + excluded.count"),
+				}),
+			}).CreateInBatches(affinities, 100).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&cursor).Update("position", orders[len(orders)-1].ID).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(orders), nil
+}
+
+// GetRelatedProducts returns up to limit products to recommend alongside
+// the given one: those most often bought with it, topped up with products
+// from the same category that are closest in price.
+func (r *Repository) GetRelatedProducts(id uint, limit int, locales []string) ([]RelatedProduct, error) {
+	var product domain.Product
+	if err := r.db.Select("id", "category").First(&product, id).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	products := func() *gorm.DB {
+		return r.db.Table("(?) AS products", localizedProducts(r.db, now, locales)).Model(&domain.Product{})
+	}
+
+	var boughtTogether []domain.Product
+	err := products().
+		Joins("JOIN product_affinities ON product_affinities.related_product_id = products.id").
+		Where("product_affinities.product_id = ?", id).
+		Order("product_affinities.count desc, products.id").
+		Limit(limit).
+		Find(&boughtTogether).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var similar []domain.Product
+	if len(boughtTogether) < limit && product.Category != "" {
+		exclude := []uint{id}
+		for _, related := range boughtTogether {
+			exclude = append(exclude, related.ID)
+		}
+
+		current := products().Select("current_price_cents").Where("products.id = ?", id)
+		err := products().
+			Where("products.category = ? AND products.id NOT IN ?", product.Category, exclude).
+			Order(clause.Expr{SQL: "ABS(products.current_price_cents - (?)), products.id", Vars: []any{current}}).
+			Limit(limit - len(boughtTogether)).
+			Find(&similar).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	all := append(boughtTogether, similar...)
+	if err := loadLowestPrices(r.db, all, now); err != nil {
+		return nil, err
+	}
+
+	result := make([]RelatedProduct, len(all))
+	for i, related := range all {
+		result[i] = RelatedProduct{Product: related, Reason: RelatedSimilar}
+		if i < len(boughtTogether) {
+			result[i].Reason = RelatedBoughtTogether
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refundableStatuses are the statuses of orders that have been paid for
+// and not yet fully refunded.
+var refundableStatuses = []domain.OrderStatus{
+	domain.OrderPaid,
+	domain.OrderOnHold,
+	domain.OrderFulfilled,
+	domain.OrderShipped,
+	domain.OrderDelivered,
+}
+
+type RefundItemInput struct {
+	ProductID uint
+	Quantity  int
+}
+
+// RefundInput describes a refund. With Items, those units are refunded at
+// what they sold for once the order's discounts were taken off, with their
+// tax when it was charged on top. Without, AmountCents is refunded, and
+// when that is zero too, everything not yet refunded is. Restock puts the
+// refunded units back in stock, so it needs units to refund.
+type RefundInput struct {
+	Items       []RefundItemInput
+	AmountCents int64
+	Reason      string
+	Restock     bool
+}
+
+// RefundOrder gives money back through the gateway that took the order's
+// payment. Refunds can't add up to more than was captured, nor refund more
+// units of a line than were ordered. Items that come to nothing once
+// discounted are ErrNothingToRefund. The refund that brings the total up
+// to what was captured moves the order to refunded. actorID is the user
+// making the refund.
+//
+// The refund is committed as pending before the provider is asked, and
+// succeeds or fails with its answer, so money that was given back is never
+// lost with a rolled-back transaction. A refund that stays pending because
+// the answer couldn't be recorded keeps holding its amount and units.
+func (r *Repository) RefundOrder(orderID uint, input RefundInput, gateway payment.Gateway, actorID uint) (*domain.Refund, error) {
+	refund, reference, err := r.createRefund(orderID, input, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gateway.Refund(reference, refund.AmountCents); err != nil {
+		failed := r.db.Model(&domain.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, domain.RefundPending).
+			Updates(map[string]any{"status": domain.RefundFailed, "failure_reason": err.Error()}).Error
+		return nil, errors.Join(fmt.Errorf("%w: %w", ErrRefundFailed, err), failed)
+	}
+
+	if err := r.completeRefund(refund, actorID); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// createRefund checks the refund against what is left of the order and
+// records it as pending. It returns the refund and the provider's
+// reference for the payment it gives back.
+func (r *Repository) createRefund(orderID uint, input RefundInput, actorID uint) (*domain.Refund, string, error) {
+	var refund domain.Refund
+	var reference string
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the order serializes refunds of the same order.
+		var order domain.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Preload("Discounts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Preload("Taxes").
+			First(&order, orderID).Error
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(refundableStatuses, order.Status) {
+			return fmt.Errorf("%w: the order is %s", ErrOrderNotRefundable, order.Status)
+		}
+
+		var payments []domain.Payment
+		err = tx.Where("order_id = ? AND status = ?", order.ID, domain.PaymentSucceeded).
+			Order("id desc").
+			Limit(1).
+			Find(&payments).Error
+		if err != nil {
+			return err
+		}
+		if len(payments) == 0 || payments[0].Reference == nil {
+			return fmt.Errorf("%w: no payment was captured for it", ErrOrderNotRefundable)
+		}
+		captured := payments[0]
+		reference = *captured.Reference
+
+		// Pending refunds count, so that refunds racing each other can't
+		// add up to more than was captured.
+		var refunded int64
+		err = tx.Model(&domain.Refund{}).
+			Select("COALESCE(SUM(amount_cents), 0)").
+			Where("order_id = ? AND status <> ?", order.ID, domain.RefundFailed).
+			Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		left := captured.AmountCents - refunded
+
+		refund = domain.Refund{
+			OrderID:   order.ID,
+			PaymentID: captured.ID,
+			Status:    domain.RefundPending,
+			Reason:    input.Reason,
+			Restocked: input.Restock,
+		}
+		if actorID != 0 {
+			refund.ActorID = &actorID
+		}
+
+		switch {
+		case len(input.Items) > 0:
+			if err := refundItems(tx, &refund, order, input.Items); err != nil {
+				return err
+			}
+			if refund.AmountCents == 0 {
+				return fmt.Errorf("%w: the items were free once discounted", ErrNothingToRefund)
+			}
+		case input.AmountCents > 0:
+			if input.Restock {
+				return fmt.Errorf("%w: only refunds of items can restock", ErrInvalidRefund)
+			}
+			refund.AmountCents = input.AmountCents
+		default:
+			if err := refundRemainingItems(tx, &refund, order); err != nil {
+				return err
+			}
+			refund.AmountCents = left
+		}
+		if refund.AmountCents <= 0 || refund.AmountCents > left {
+			return fmt.Errorf("%w: %d of %d captured is left", ErrRefundTooLarge, max(left, 0), captured.AmountCents)
+		}
+
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &refund, reference, nil
+}
+
+// completeRefund marks a pending refund the provider has made as
+// succeeded, restocks its units if asked to, and moves the order to
+// refunded once the refunds add up to what was captured.
+func (r *Repository) completeRefund(refund *domain.Refund, actorID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, refund.OrderID).Error; err != nil {
+			return err
+		}
+
+		if refund.Restocked {
+			if err := restockItems(tx, order.ID, refund.Items); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&domain.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, domain.RefundPending).
+			Update("status", domain.RefundSucceeded)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("refund %d is no longer pending", refund.ID)
+		}
+		refund.Status = domain.RefundSucceeded
+
+		var captured domain.Payment
+		if err := tx.Select("amount_cents").First(&captured, refund.PaymentID).Error; err != nil {
+			return err
+		}
+		var refunded int64
+		err := tx.Model(&domain.Refund{}).
+			Select("COALESCE(SUM(amount_cents), 0)").
+			Where("order_id = ? AND status = ?", order.ID, domain.RefundSucceeded).
+			Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		if refunded < captured.AmountCents {
+			return nil
+		}
+		return r.transitionOrder(tx, &order, domain.OrderRefunded, actorID)
+	})
+}
+
+// refundItems adds the units to the refund, at their share of what their
+// line sold for. Each unit is priced so that refunding a line bit by bit
+// adds up to the same as refunding it at once.
+func refundItems(tx *gorm.DB, refund *domain.Refund, order domain.Order, items []RefundItemInput) error {
+	left, err := unrefundedQuantities(tx, order)
+	if err != nil {
+		return err
+	}
+	amounts := discountedLineAmounts(order)
+	if !order.PricesIncludeTax {
+		for i, item := range order.Items {
+			for _, line := range order.Taxes {
+				if line.ProductID == item.ProductID {
+					amounts[i] += line.TaxCents
+				}
+			}
+		}
+	}
+
+	quantities := make(map[uint]int, len(items))
+	var productIDs []uint
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	for _, id := range productIDs {
+		index := slices.IndexFunc(order.Items, func(item domain.OrderItem) bool { return item.ProductID == id })
+		if index < 0 {
+			return fmt.Errorf("%w: product %d is not in the order", ErrInvalidRefund, id)
+		}
+		ordered := order.Items[index]
+		if quantities[id] > left[ordered.ID] {
+			return fmt.Errorf("%w: %d of product %d is left", ErrRefundTooLarge, left[ordered.ID], id)
+		}
+
+		refund.Items = append(refund.Items, domain.RefundItem{
+			OrderItemID: ordered.ID,
+			ProductID:   id,
+			Quantity:    quantities[id],
+		})
+		refunded := ordered.Quantity - left[ordered.ID]
+		refund.AmountCents += lineShare(amounts[index], ordered.Quantity, refunded+quantities[id]) -
+			lineShare(amounts[index], ordered.Quantity, refunded)
+	}
+	return nil
+}
+
+// lineShare returns the part of a line's amount that n of its quantity
+// units make up, rounded down.
+func lineShare(amount int64, quantity, n int) int64 {
+	return amount * int64(n) / int64(quantity)
+}
+
+// refundRemainingItems adds every unit not yet refunded to the refund.
+func refundRemainingItems(tx *gorm.DB, refund *domain.Refund, order domain.Order) error {
+	left, err := unrefundedQuantities(tx, order)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		if left[item.ID] > 0 {
+			refund.Items = append(refund.Items, domain.RefundItem{
+				OrderItemID: item.ID,
+				ProductID:   item.ProductID,
+				Quantity:    left[item.ID],
+			})
+		}
+	}
+	return nil
+}
+
+// unrefundedQuantities returns how many units of each order item, by ID,
+// haven't been refunded or aren't being refunded.
+func unrefundedQuantities(tx *gorm.DB, order domain.Order) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := tx.Model(&domain.RefundItem{}).
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ? AND refunds.status <> ?", order.ID, domain.RefundFailed).
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	left := make(map[uint]int, len(order.Items))
+	for _, item := range order.Items {
+		left[item.ID] = item.Quantity
+	}
+	for _, row := range rows {
+		left[row.OrderItemID] -= row.Quantity
+	}
+	return left, nil
+}
+
+// restockItems puts refunded units back in the warehouses they were taken
+// from, skipping units that earlier refunds already put back. Units of
+// products whose stock isn't tracked are not restocked.
+func restockItems(tx *gorm.DB, orderID uint, items []domain.RefundItem) error {
+	for _, item := range items {
+		var restocked int
+		err := tx.Model(&domain.RefundItem{}).
+			Select("COALESCE(SUM(refund_items.quantity), 0)").
+			Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+			Where("refunds.order_id = ? AND refunds.status = ? AND refunds.restocked", orderID, domain.RefundSucceeded).
+			Where("refund_items.product_id = ?", item.ProductID).
+			Scan(&restocked).Error
+		if err != nil {
+			return err
+		}
+
+		var taken []domain.StockReservationItem
+		err = tx.Joins("JOIN stock_reservations ON stock_reservations.id = stock_reservation_items.stock_reservation_id").
+			Where("stock_reservations.order_id = ? AND stock_reservations.status = ?", orderID, domain.ReservationCommitted).
+			Where("stock_reservation_items.product_id = ?", item.ProductID).
+			Order("stock_reservation_items.id").
+			Find(&taken).Error
+		if err != nil {
+			return err
+		}
+
+		quantity := item.Quantity
+		for _, from := range taken {
+			skipped := min(restocked, from.Quantity)
+			restocked -= skipped
+			n := min(quantity, from.Quantity-skipped)
+			if n <= 0 {
+				continue
+			}
+			err := tx.Model(&domain.StockLevel{}).
+				Where("product_id = ? AND warehouse_id = ?", from.ProductID, from.WarehouseID).
+				Update("on_hand", gorm.Expr("on_hand + ?", n)).Error
+			if err != nil {
+				return err
+			}
+			quantity -= n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/payment"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+	"gorm.io/gorm"
+)
+
+// refundGateway refunds whatever it is asked to, unless err is set.
+type refundGateway struct {
+	*payment.Mock
+	err      error
+	refunded int64
+}
+
+func (g *refundGateway) Refund(reference string, amountCents int64) error {
+	if g.err != nil {
+		return g.err
+	}
+	g.refunded += amountCents
+	return nil
+}
+
+// seedPaidOrder places an order of 3 apples at 100 and records a payment
+// of its total as captured.
+func seedPaidOrder(t *testing.T, repo *repository.Repository, db *gorm.DB, opts repository.OrderOptions) *domain.Order {
+	t.Helper()
+
+	if err := repo.CreateUser(domain.User{UserName: "customer", Password: "password"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	product, err := repo.CreateProduct(domain.Product{Name: "apple", PriceCents: 100}, 0)
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	order, err := repo.CreateOrder(1, []repository.OrderItemInput{{ProductID: product.ID, Quantity: 3}}, money.Identity(repo.BaseCurrency()), opts)
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	for _, status := range []domain.OrderStatus{domain.OrderAwaitingPayment, domain.OrderPaid} {
+		if _, err := repo.TransitionOrder(order.ID, status, 0); err != nil {
+			t.Fatalf("failed to move the order to %s: %v", status, err)
+		}
+	}
+	reference := "ref_1"
+	err = db.Create(&domain.Payment{
+		OrderID:     order.ID,
+		Provider:    "stub",
+		Reference:   &reference,
+		Status:      domain.PaymentSucceeded,
+		AmountCents: order.TotalCents,
+		Currency:    order.Currency,
+	}).Error
+	if err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	return order
+}
+
+func TestRefundOrder_GatewayFailure(t *testing.T) {
+	repo, db := setupRepository(t)
+	order := seedPaidOrder(t, repo, db, repository.OrderOptions{})
+
+	gateway := &refundGateway{Mock: payment.NewMock("", nil), err: payment.ErrInvalidRequest}
+	if _, err := repo.RefundOrder(order.ID, repository.RefundInput{}, gateway, 0); !errors.Is(err, repository.ErrRefundFailed) {
+		t.Fatalf("expected ErrRefundFailed, got %v", err)
+	}
+	var refunds []domain.Refund
+	db.Find(&refunds)
+	if len(refunds) != 1 || refunds[0].Status != domain.RefundFailed || refunds[0].FailureReason == "" {
+		t.Fatalf("expected the refund to be recorded as failed, got %+v", refunds)
+	}
+
+	// The failed refund doesn't count against what is left.
+	gateway.err = nil
+	refund, err := repo.RefundOrder(order.ID, repository.RefundInput{}, gateway, 0)
+	if err != nil {
+		t.Fatalf("failed to refund: %v", err)
+	}
+	if refund.Status != domain.RefundSucceeded || refund.AmountCents != order.TotalCents || gateway.refunded != order.TotalCents {
+		t.Fatalf("expected the whole order to be refunded, got %+v", refund)
+	}
+	var refunded domain.Order
+	db.First(&refunded, order.ID)
+	if refunded.Status != domain.OrderRefunded {
+		t.Fatalf("expected the order to be refunded, got %s", refunded.Status)
+	}
+}
+
+func TestRefundOrder_Tax(t *testing.T) {
+	repo, db := setupRepository(t)
+	if _, err := repo.CreateTaxRate(domain.TaxRate{Country: "DE", Name: "VAT", Rate: "0.19"}); err != nil {
+		t.Fatalf("failed to create tax rate: %v", err)
+	}
+	// 300 with 57 of tax on top.
+	order := seedPaidOrder(t, repo, db, repository.OrderOptions{TaxAddress: &tax.Address{Country: "DE"}})
+
+	gateway := &refundGateway{Mock: payment.NewMock("", nil)}
+	var amounts []int64
+	for _, quantity := range []int{1, 2} {
+		input := repository.RefundInput{Items: []repository.RefundItemInput{{ProductID: 1, Quantity: quantity}}}
+		refund, err := repo.RefundOrder(order.ID, input, gateway, 0)
+		if err != nil {
+			t.Fatalf("failed to refund: %v", err)
+		}
+		amounts = append(amounts, refund.AmountCents)
+	}
+	if !reflect.DeepEqual(amounts, []int64{119, 238}) {
+		t.Fatalf("expected refunds of 119 and 238, got %v", amounts)
+	}
+}
@@ -3,22 +3,190 @@ package repository
 import (
 	"errors"
 	"strings"
+	"time"
 
+	"github.com/Hiroki111/go-backend-example/internal/allocation"
 	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type Repository struct {
-	db *gorm.DB
+	db               *gorm.DB
+	baseCurrency     money.Currency
+	allocator        allocation.Strategy
+	taxCalculator    tax.Calculator
+	pricesIncludeTax bool
+	taxRounding      tax.Rounding
+	seller           domain.Seller
+	orderHooks       map[domain.OrderStatus][]OrderHook
 }
 
 func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+	base, _ := money.LookupCurrency(money.DefaultBaseCurrency)
+	r := &Repository{
+		db:           db,
+		baseCurrency: base,
+		allocator:    allocation.Priority{},
+		taxRounding:  tax.RoundHalfUp,
+		orderHooks:   make(map[domain.OrderStatus][]OrderHook),
+	}
+	r.OnOrderTransition(domain.OrderCancelled, rejectWhilePaying)
+	r.OnOrderTransition(domain.OrderCancelled, releaseReservations)
+	r.OnOrderTransition(domain.OrderCancelled, releaseCouponRedemption)
+	r.OnOrderTransition(domain.OrderPaid, r.commitReservations)
+	r.OnOrderTransition(domain.OrderPaid, r.issueInvoice)
+	return r
+}
+
+// SetAllocationStrategy picks how order lines are split across warehouses.
+func (r *Repository) SetAllocationStrategy(name string) error {
+	strategy, err := allocation.New(name)
+	if err != nil {
+		return err
+	}
+	r.allocator = strategy
+	return nil
+}
+
+// SetTaxCalculator replaces the tax rates kept in the database with
+// another way of working out tax, such as an external tax service.
+func (r *Repository) SetTaxCalculator(calculator tax.Calculator) {
+	r.taxCalculator = calculator
+}
+
+// SetPricesIncludeTax says whether product prices already include tax,
+// which is then worked out of them, rather than added on top.
+func (r *Repository) SetPricesIncludeTax(include bool) {
+	r.pricesIncludeTax = include
+}
+
+// SetTaxRounding picks how the tax on each order line is rounded.
+func (r *Repository) SetTaxRounding(name string) error {
+	rounding, err := tax.ParseRounding(name)
+	if err != nil {
+		return err
+	}
+	r.taxRounding = rounding
+	return nil
 }
 
 func (r *Repository) Migrate() error {
-	return r.db.AutoMigrate(&domain.User{}, &domain.Product{})
+	// Older schemas enforced uniqueness across soft-deleted products too.
+	for _, index := range []string{"idx_products_name", "idx_products_sku"} {
+		if r.db.Migrator().HasIndex(&domain.Product{}, index) {
+			if err := r.db.Migrator().DropIndex(&domain.Product{}, index); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.migrateProductSlugs(); err != nil {
+		return err
+	}
+	if err := r.migrateStockToWarehouses(); err != nil {
+		return err
+	}
+
+	err := r.db.AutoMigrate(
+		&domain.User{},
+		&domain.ProductType{},
+		&domain.AttributeDefinition{},
+		&domain.Product{},
+		&domain.ProductSlug{},
+		&domain.ProductRevision{},
+		&domain.Order{},
+		&domain.OrderItem{},
+		&domain.OrderTransition{},
+		&domain.Payment{},
+		&domain.PaymentEvent{},
+		&domain.Refund{},
+		&domain.RefundItem{},
+		&domain.Coupon{},
+		&domain.CouponRedemption{},
+		&domain.OrderDiscount{},
+		&domain.TaxRate{},
+		&domain.OrderTax{},
+		&domain.Invoice{},
+		&domain.InvoiceSequence{},
+		&domain.Address{},
+		&domain.ShippingZone{},
+		&domain.ShippingZoneLocation{},
+		&domain.ShippingMethod{},
+		&domain.ShippingRate{},
+		&domain.Review{},
+		&domain.ScheduledPrice{},
+		&domain.PriceChange{},
+		&domain.ExchangeRate{},
+		&domain.ProductTranslation{},
+		&domain.Warehouse{},
+		&domain.StockLevel{},
+		&domain.StockReservation{},
+		&domain.StockReservationItem{},
+		&domain.StockThreshold{},
+		&domain.StockSubscription{},
+		&domain.OutboxMessage{},
+		&domain.IdempotencyKey{},
+		&domain.Cart{},
+		&domain.CartItem{},
+		&domain.ProductAffinity{},
+		&domain.JobCursor{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Orders placed before multi-currency support are in the base currency.
+	err = r.db.Model(&domain.Order{}).
+		Where("currency = ''").
+		Update("currency", r.baseCurrency.Code).Error
+	if err != nil {
+		return err
+	}
+
+	// Orders placed before coupons had no discount.
+	return r.db.Model(&domain.Order{}).
+		Where("subtotal_cents = 0").
+		Update("subtotal_cents", gorm.Expr("total_cents")).Error
+}
+
+// migrateStockToWarehouses moves stock that was tracked per product, before
+// there were warehouses, into a "main" warehouse.
+func (r *Repository) migrateStockToWarehouses() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&domain.StockLevel{}) || migrator.HasColumn(&domain.StockLevel{}, "WarehouseID") {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(&domain.Warehouse{}); err != nil {
+			return err
+		}
+		warehouse := domain.Warehouse{Name: "main"}
+		if err := tx.FirstOrCreate(&warehouse, domain.Warehouse{Name: warehouse.Name}).Error; err != nil {
+			return err
+		}
+
+		if tx.Migrator().HasIndex(&domain.StockLevel{}, "idx_stock_levels_product_id") {
+			if err := tx.Migrator().DropIndex(&domain.StockLevel{}, "idx_stock_levels_product_id"); err != nil {
+				return err
+			}
+		}
+		for _, table := range []string{"stock_levels", "stock_reservation_items"} {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+			if err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN warehouse_id bigint").Error; err != nil {
+				return err
+			}
+			if err := tx.Table(table).Where("1 = 1").Update("warehouse_id", warehouse.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *Repository) Init() error {
@@ -30,7 +198,9 @@ func (r *Repository) Init() error {
 		UserName: "admin",
 		Password: string(hashed),
 	}
-	result := r.db.Where(domain.User{UserName: "admin"}).FirstOrCreate(&adminUser)
+	result := r.db.Where(domain.User{UserName: "admin"}).
+		Assign(domain.User{IsAdmin: true}).
+		FirstOrCreate(&adminUser)
 	return result.Error
 }
 
@@ -70,21 +240,38 @@ func (r *Repository) GetUserByCredentials(userName, password string) (*domain.Us
 	return &user, nil
 }
 
+func (r *Repository) GetUserByID(id uint) (*domain.User, error) {
+	var user domain.User
+
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 type GetProductsInput struct {
-	OrderBy  string
-	SortIn   string
-	Name     string
-	MinPrice int64
-	MaxPrice int64
+	OrderBy    string
+	SortIn     string
+	Name       string
+	MinPrice   int64
+	MaxPrice   int64
+	Categories []string
+	Attributes []AttributeFilter
+	// At is the time prices are resolved for; zero means now.
+	At time.Time
+	// Locales are tried in order for the name and description, which fall
+	// back to the product's own. Name search and sorting use the result.
+	Locales []string
 }
 
 func (r *Repository) GetProducts(inputs GetProductsInput) ([]domain.Product, error) {
 	var result []domain.Product
 
-	query := r.db.Model(&domain.Product{})
-	query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(inputs.Name)+"%").
-		Where("price_cents >= ?", inputs.MinPrice).
-		Where("price_cents <= ?", inputs.MaxPrice)
+	conditions, err := r.resolveAttributeFilters(inputs.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	query := r.filterProducts(inputs, conditions, "")
 
 	sortIn := "asc"
 	if inputs.SortIn == "desc" {
@@ -93,9 +280,11 @@ func (r *Repository) GetProducts(inputs GetProductsInput) ([]domain.Product, err
 
 	switch inputs.OrderBy {
 	case "name":
-		query = query.Order("name " + sortIn)
+		query = query.Order("localized_name " + sortIn)
 	case "price_cents":
-		query = query.Order("price_cents " + sortIn)
+		query = query.Order("current_price_cents " + sortIn)
+	case "rating":
+		query = query.Order("rating_average " + sortIn).Order("review_count desc")
 	default:
 		query = query.Order("created_at " + sortIn)
 	}
@@ -103,6 +292,169 @@ func (r *Repository) GetProducts(inputs GetProductsInput) ([]domain.Product, err
 	if err := query.Find(&result).Error; err != nil {
 		return nil, err
 	}
+	if err := loadLowestPrices(r.db, result, pricesAt(inputs.At)); err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
+
+// filterProducts applies every filter in inputs except the one belonging to
+// the facet named by exclude, which is how multi-select facets are counted.
+// Price filters apply to the current price as of inputs.At.
+func (r *Repository) filterProducts(inputs GetProductsInput, conditions []attributeCondition, exclude string) *gorm.DB {
+	products := localizedProducts(r.db, pricesAt(inputs.At), inputs.Locales)
+	query := r.db.Table("(?) AS products", products).Model(&domain.Product{})
+	query = query.Where("LOWER(localized_name) LIKE ?", "%"+strings.ToLower(inputs.Name)+"%")
+
+	if exclude != FacetPrice {
+		query = query.Where("current_price_cents >= ?", inputs.MinPrice).
+			Where("current_price_cents <= ?", inputs.MaxPrice)
+	}
+
+	if exclude != FacetCategory && len(inputs.Categories) > 0 {
+		query = query.Where("category IN ?", inputs.Categories)
+	}
+
+	for _, condition := range conditions {
+		if exclude != attributeFacet(condition.name) {
+			query = r.applyAttributeCondition(query, condition)
+		}
+	}
+
+	return query
+}
+
+// pricesAt returns the time prices are resolved for, with zero meaning now.
+func pricesAt(at time.Time) time.Time {
+	if at.IsZero() {
+		return time.Now().UTC()
+	}
+	return at.UTC()
+}
+
+// GetProduct returns the product with its current prices, and its name
+// and description in the first of the locales that has them.
+func (r *Repository) GetProduct(id uint, locales []string) (*domain.Product, error) {
+	return r.getProduct(locales, "products.id = ?", id)
+}
+
+func (r *Repository) getProduct(locales []string, query string, args ...any) (*domain.Product, error) {
+	now := time.Now().UTC()
+
+	var products []domain.Product
+	err := r.db.Table("(?) AS products", localizedProducts(r.db, now, locales)).
+		Model(&domain.Product{}).
+		Where(query, args...).
+		Limit(1).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := loadLowestPrices(r.db, products, now); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// CreateProduct saves a new product. actorID is the user making the change,
+// or 0 for changes that don't come from a user.
+func (r *Repository) CreateProduct(data domain.Product, actorID uint) (*domain.Product, error) {
+	product := domain.Product{
+		SKU:           data.SKU,
+		Name:          data.Name,
+		Description:   data.Description,
+		Category:      data.Category,
+		PriceCents:    data.PriceCents,
+		ProductTypeID: data.ProductTypeID,
+		Attributes:    data.Attributes,
+		TaxCategory:   data.TaxCategory,
+		WeightGrams:   data.WeightGrams,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := validateProductAttributes(tx, product); err != nil {
+			return err
+		}
+		if err := assignSlug(tx, &product); err != nil {
+			return err
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrProductAlreadyExists
+			}
+			return err
+		}
+		if err := recordRevision(tx, product, domain.RevisionCreate, actorID); err != nil {
+			return err
+		}
+		if err := recordPriceChanges(tx, product.ID); err != nil {
+			return err
+		}
+		return loadPrices(tx, &product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *Repository) UpdateProduct(id uint, data domain.Product, actorID uint) (*domain.Product, error) {
+	var product domain.Product
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, id).Error; err != nil {
+			return err
+		}
+
+		product.SKU = data.SKU
+		product.Name = data.Name
+		product.Description = data.Description
+		product.Category = data.Category
+		product.PriceCents = data.PriceCents
+		product.ProductTypeID = data.ProductTypeID
+		product.Attributes = data.Attributes
+		product.TaxCategory = data.TaxCategory
+		product.WeightGrams = data.WeightGrams
+		if err := validateProductAttributes(tx, product); err != nil {
+			return err
+		}
+		if err := assignSlug(tx, &product); err != nil {
+			return err
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrProductAlreadyExists
+			}
+			return err
+		}
+		if err := recordRevision(tx, product, domain.RevisionUpdate, actorID); err != nil {
+			return err
+		}
+		if err := recordPriceChanges(tx, product.ID); err != nil {
+			return err
+		}
+		return loadPrices(tx, &product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// DeleteProduct soft-deletes the product; it can be restored from the trash.
+func (r *Repository) DeleteProduct(id uint, actorID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product domain.Product
+		if err := tx.First(&product, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, product, domain.RevisionDelete, actorID)
+	})
+}
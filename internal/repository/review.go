@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// CreateReview saves a pending review. Whether it is a verified purchase is
+// decided here from the user's orders, never by the client.
+func (r *Repository) CreateReview(data domain.Review) (*domain.Review, error) {
+	review := domain.Review{
+		ProductID: data.ProductID,
+		UserID:    data.UserID,
+		Rating:    data.Rating,
+		Body:      data.Body,
+		Status:    domain.ReviewPending,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&domain.Product{}, review.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		verified, err := hasPurchased(tx, review.UserID, review.ProductID)
+		if err != nil {
+			return err
+		}
+		review.VerifiedPurchase = verified
+
+		if err := tx.Create(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrReviewAlreadyExists
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetProductReviews lists the approved reviews of a product, newest first.
+func (r *Repository) GetProductReviews(productID uint) ([]domain.Review, error) {
+	var result []domain.Review
+
+	err := r.db.Preload("User").
+		Where("product_id = ? AND status = ?", productID, domain.ReviewApproved).
+		Order("created_at desc, id desc").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetReviewsByStatus returns reviews in moderation order, oldest first.
+func (r *Repository) GetReviewsByStatus(status domain.ReviewStatus) ([]domain.Review, error) {
+	var result []domain.Review
+
+	err := r.db.Preload("User").
+		Where("status = ?", status).
+		Order("created_at asc, id asc").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ModerateReview approves or rejects a review and refreshes the product's
+// rating summary in the same transaction.
+func (r *Repository) ModerateReview(id uint, status domain.ReviewStatus, moderatorID uint) (*domain.Review, error) {
+	var review domain.Review
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").First(&review, id).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err := tx.Model(&review).Updates(map[string]any{
+			"status":       status,
+			"moderated_by": moderatorID,
+			"moderated_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return refreshProductRating(tx, review.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func refreshProductRating(tx *gorm.DB, productID uint) error {
+	var summary struct {
+		Average float64
+		Count   int
+	}
+	err := tx.Model(&domain.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, domain.ReviewApproved).
+		Scan(&summary).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&domain.Product{}).
+		Where("id = ?", productID).
+		UpdateColumns(map[string]any{
+			"rating_average": summary.Average,
+			"review_count":   summary.Count,
+		}).Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+// pricedProducts selects products together with their regular and current
+// prices as of at. A product's regular price is its latest-starting active
+// regular entry, falling back to PriceCents; its current price is the
+// latest-starting active sale entry, falling back to the regular price.
+func pricedProducts(db *gorm.DB, at time.Time) *gorm.DB {
+	regular := activePrice(db, domain.PriceRegular, at)
+	sale := activePrice(db, domain.PriceSale, at)
+
+	return db.Model(&domain.Product{}).Select(
+		"products.*, COALESCE((?), products.price_cents) AS regular_price_cents, COALESCE((?), (?), products.price_cents) AS current_price_cents",
+		regular, sale, regular,
+	)
+}
+
+func activePrice(db *gorm.DB, kind domain.PriceKind, at time.Time) *gorm.DB {
+	return activeScheduledPrice(db, gorm.Expr("products.id"), kind, at).Select("price_cents")
+}
+
+// activeScheduledPrice selects the entry of the given kind that is in
+// effect for productID at the given time.
+func activeScheduledPrice(db *gorm.DB, productID any, kind domain.PriceKind, at time.Time) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&domain.ScheduledPrice{}).
+		Where("scheduled_prices.product_id = ? AND scheduled_prices.kind = ?", productID, kind).
+		Where("scheduled_prices.starts_at <= ?", at).
+		Where("scheduled_prices.ends_at IS NULL OR scheduled_prices.ends_at > ?", at).
+		Order("scheduled_prices.starts_at desc, scheduled_prices.id desc").
+		Limit(1)
+}
+
+// loadPrices fills in the product's resolved prices as of now, and its
+// name and description in the default locale.
+func loadPrices(tx *gorm.DB, product *domain.Product) error {
+	now := time.Now().UTC()
+	err := localizedProducts(tx, now, nil).
+		Where("products.id = ?", product.ID).
+		Take(product).Error
+	if err != nil {
+		return err
+	}
+
+	products := []domain.Product{*product}
+	if err := loadLowestPrices(tx, products, now); err != nil {
+		return err
+	}
+	*product = products[0]
+	return nil
+}
+
+// CreateScheduledPrice adds a price entry to an existing product.
+func (r *Repository) CreateScheduledPrice(data domain.ScheduledPrice) (*domain.ScheduledPrice, error) {
+	price := domain.ScheduledPrice{
+		ProductID:  data.ProductID,
+		Kind:       data.Kind,
+		PriceCents: data.PriceCents,
+		StartsAt:   data.StartsAt.UTC(),
+	}
+	if data.EndsAt != nil {
+		endsAt := data.EndsAt.UTC()
+		price.EndsAt = &endsAt
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Product{}, data.ProductID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&price).Error; err != nil {
+			return err
+		}
+		return recordPriceChanges(tx, price.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// GetScheduledPrices returns every price entry of the product, past ones
+// included, ordered by start time.
+func (r *Repository) GetScheduledPrices(productID uint) ([]domain.ScheduledPrice, error) {
+	if err := r.db.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var result []domain.ScheduledPrice
+	err := r.db.Where("product_id = ?", productID).
+		Order("starts_at, id").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) DeleteScheduledPrice(productID, priceID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("product_id = ?", productID).Delete(&domain.ScheduledPrice{}, priceID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordPriceChanges(tx, productID)
+	})
+}
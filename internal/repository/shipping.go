@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/shipping"
+	"gorm.io/gorm"
+)
+
+// preloadShippingZones loads zones with their locations and their methods'
+// rate tables, each in the order they were added.
+func preloadShippingZones(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Locations", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Methods", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Methods.Rates", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+}
+
+func (r *Repository) GetShippingZones() ([]domain.ShippingZone, error) {
+	var result []domain.ShippingZone
+
+	if err := preloadShippingZones(r.db).Order("id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) CreateShippingZone(data domain.ShippingZone) (*domain.ShippingZone, error) {
+	zone := domain.ShippingZone{Name: data.Name, Locations: zoneLocations(data.Locations)}
+
+	if err := r.db.Create(&zone).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrShippingZoneAlreadyExists
+		}
+		return nil, err
+	}
+	return r.getShippingZone(zone.ID)
+}
+
+// UpdateShippingZone renames the zone and replaces its locations. Its
+// methods are kept.
+func (r *Repository) UpdateShippingZone(id uint, data domain.ShippingZone) (*domain.ShippingZone, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var zone domain.ShippingZone
+		if err := tx.First(&zone, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&zone).Update("name", data.Name).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrShippingZoneAlreadyExists
+			}
+			return err
+		}
+		if err := tx.Where("zone_id = ?", id).Delete(&domain.ShippingZoneLocation{}).Error; err != nil {
+			return err
+		}
+		locations := zoneLocations(data.Locations)
+		for i := range locations {
+			locations[i].ZoneID = id
+		}
+		if len(locations) == 0 {
+			return nil
+		}
+		return tx.Create(&locations).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.getShippingZone(id)
+}
+
+// DeleteShippingZone deletes the zone and its methods.
+func (r *Repository) DeleteShippingZone(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.ShippingZone{}, id).Error; err != nil {
+			return err
+		}
+
+		var methodIDs []uint
+		if err := tx.Model(&domain.ShippingMethod{}).Where("zone_id = ?", id).Pluck("id", &methodIDs).Error; err != nil {
+			return err
+		}
+		if err := deleteShippingMethods(tx, methodIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("zone_id = ?", id).Delete(&domain.ShippingZoneLocation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.ShippingZone{}, id).Error
+	})
+}
+
+func (r *Repository) getShippingZone(id uint) (*domain.ShippingZone, error) {
+	var zone domain.ShippingZone
+	if err := preloadShippingZones(r.db).First(&zone, id).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func zoneLocations(data []domain.ShippingZoneLocation) []domain.ShippingZoneLocation {
+	locations := make([]domain.ShippingZoneLocation, len(data))
+	for i, location := range data {
+		locations[i] = domain.ShippingZoneLocation{Country: location.Country, Region: location.Region}
+	}
+	return locations
+}
+
+// CreateShippingMethod adds a method to the zone. It fails with
+// ErrShippingZoneNotFound for an unknown zone.
+func (r *Repository) CreateShippingMethod(zoneID uint, data domain.ShippingMethod) (*domain.ShippingMethod, error) {
+	method := domain.ShippingMethod{
+		ZoneID: zoneID,
+		Name:   data.Name,
+		Basis:  data.Basis,
+		Rates:  methodRates(data.Rates),
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.ShippingZone{}, zoneID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShippingZoneNotFound
+			}
+			return err
+		}
+		return tx.Create(&method).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// UpdateShippingMethod changes the method and replaces its rate table.
+func (r *Repository) UpdateShippingMethod(id uint, data domain.ShippingMethod) (*domain.ShippingMethod, error) {
+	var method domain.ShippingMethod
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&method, id).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&method).Select("Name", "Basis").Updates(domain.ShippingMethod{
+			Name:  data.Name,
+			Basis: data.Basis,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("method_id = ?", id).Delete(&domain.ShippingRate{}).Error; err != nil {
+			return err
+		}
+		method.Rates = methodRates(data.Rates)
+		for i := range method.Rates {
+			method.Rates[i].MethodID = id
+		}
+		return tx.Create(&method.Rates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// DeleteShippingMethod deletes the method. Orders shipped with it keep its
+// name and cost.
+func (r *Repository) DeleteShippingMethod(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.ShippingMethod{}, id).Error; err != nil {
+			return err
+		}
+		return deleteShippingMethods(tx, []uint{id})
+	})
+}
+
+func deleteShippingMethods(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := tx.Model(&domain.Order{}).
+		Where("shipping_method_id IN ?", ids).
+		Update("shipping_method_id", nil).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("method_id IN ?", ids).Delete(&domain.ShippingRate{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&domain.ShippingMethod{}, ids).Error
+}
+
+func methodRates(data []domain.ShippingRate) []domain.ShippingRate {
+	rates := make([]domain.ShippingRate, len(data))
+	for i, rate := range data {
+		rates[i] = domain.ShippingRate{Position: i, UpTo: rate.UpTo, CostCents: rate.CostCents}
+	}
+	return rates
+}
+
+// shippingZones loads every zone for working out shipping.
+func shippingZones(tx *gorm.DB) ([]shipping.Zone, error) {
+	var zones []domain.ShippingZone
+	if err := preloadShippingZones(tx).Order("id").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]shipping.Zone, len(zones))
+	for i, zone := range zones {
+		result[i] = shipping.Zone{ID: zone.ID, Name: zone.Name}
+		for _, location := range zone.Locations {
+			result[i].Locations = append(result[i].Locations, shipping.Location{Country: location.Country, Region: location.Region})
+		}
+		for _, method := range zone.Methods {
+			converted := shipping.Method{ID: method.ID, Name: method.Name, Basis: shipping.Basis(method.Basis)}
+			for _, rate := range method.Rates {
+				converted.Rates = append(converted.Rates, shipping.Rate{UpTo: rate.UpTo, CostCents: rate.CostCents})
+			}
+			result[i].Methods = append(result[i].Methods, converted)
+		}
+	}
+	return result, nil
+}
+
+// GetShippingQuotes lists the shipping methods that can take what is in
+// the user's cart to one of their addresses, with what each costs in the
+// base currency. It fails with ErrCartEmpty when there is nothing to ship.
+func (r *Repository) GetShippingQuotes(userID, addressID uint) ([]shipping.Quote, error) {
+	address, err := findAddress(r.db, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := findCart(r.db, CartOwner{UserID: userID})
+	if err != nil {
+		if errors.Is(err, ErrCartNotFound) {
+			return nil, ErrCartEmpty
+		}
+		return nil, err
+	}
+	lines, err := cartLines(r.db, cart.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	var parcel shipping.Parcel
+	for _, line := range lines {
+		if line.Available {
+			parcel.WeightGrams += line.Product.WeightGrams * int64(line.Item.Quantity)
+			parcel.SubtotalCents += line.Product.CurrentPriceCents * int64(line.Item.Quantity)
+		}
+	}
+
+	zones, err := shippingZones(r.db)
+	if err != nil {
+		return nil, err
+	}
+	return shipping.Quotes(zones, address.Country, address.Region, parcel), nil
+}
+
+// applyShipping copies the address onto the order and prices the method
+// for the order's items. It returns the cost in the base currency, for
+// coupons that take it off. Once shipping zones are set up, an address no
+// method ships to is shipping.ErrUnavailable, and leaving the method out
+// is ErrShippingMethodRequired.
+func applyShipping(tx *gorm.DB, order *domain.Order, address domain.Address, methodID *uint, products map[uint]domain.Product, prices money.Converter) (int64, error) {
+	order.ShippingAddress = address.PostalAddress
+
+	zones, err := shippingZones(tx)
+	if err != nil {
+		return 0, err
+	}
+	if len(zones) == 0 && methodID == nil {
+		return 0, nil
+	}
+
+	var parcel shipping.Parcel
+	for _, item := range order.Items {
+		product := products[item.ProductID]
+		parcel.WeightGrams += product.WeightGrams * int64(item.Quantity)
+		parcel.SubtotalCents += product.CurrentPriceCents * int64(item.Quantity)
+	}
+
+	if methodID == nil {
+		if len(shipping.Quotes(zones, address.Country, address.Region, parcel)) > 0 {
+			return 0, ErrShippingMethodRequired
+		}
+		return 0, fmt.Errorf("%w: no method ships this order to %s", shipping.ErrUnavailable, address.Country)
+	}
+	quote, err := shipping.Find(zones, address.Country, address.Region, parcel, *methodID)
+	if err != nil {
+		return 0, err
+	}
+
+	order.ShippingMethodID = &quote.Method.ID
+	order.ShippingMethodName = quote.Method.Name
+	order.ShippingCents = prices.Convert(quote.CostCents).Amount
+	return quote.CostCents, nil
+}
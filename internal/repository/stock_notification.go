@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/notification"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetReorderThreshold creates or replaces the product's reorder threshold.
+// The product is evaluated afresh against the new threshold.
+func (r *Repository) SetReorderThreshold(productID uint, threshold int) (*domain.StockThreshold, error) {
+	stockThreshold := domain.StockThreshold{ProductID: productID, Threshold: threshold}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"threshold", "alerted", "updated_at"}),
+		}).Create(&stockThreshold).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stockThreshold, nil
+}
+
+func (r *Repository) GetReorderThreshold(productID uint) (*domain.StockThreshold, error) {
+	var result domain.StockThreshold
+
+	if err := r.db.First(&result, productID).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *Repository) DeleteReorderThreshold(productID uint) error {
+	result := r.db.Delete(&domain.StockThreshold{}, productID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SubscribeToStock asks for the user to be notified when the product is
+// back in stock. Subscribing again after a notification renews the
+// subscription. It fails with ErrProductInStock when there is nothing to
+// wait for.
+func (r *Repository) SubscribeToStock(productID, userID uint) (*domain.StockSubscription, error) {
+	subscription := domain.StockSubscription{ProductID: productID, UserID: userID}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&domain.Product{}, productID).Error; err != nil {
+			return err
+		}
+
+		available, err := availableQuantity(tx, productID)
+		if err != nil {
+			return err
+		}
+		if available == nil || *available > 0 {
+			return ErrProductInStock
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{"notified_at": nil}),
+		}).Create(&subscription).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("product_id = ? AND user_id = ?", productID, userID).First(&subscription).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *Repository) UnsubscribeFromStock(productID, userID uint) error {
+	result := r.db.Where("product_id = ? AND user_id = ?", productID, userID).Delete(&domain.StockSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetOutboxMessages returns the most recent messages in the outbox.
+func (r *Repository) GetOutboxMessages(limit int) ([]domain.OutboxMessage, error) {
+	var result []domain.OutboxMessage
+
+	if err := r.db.Order("id desc").Limit(limit).Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// availableQuantity returns the product's stock available across all
+// warehouses, or nil when its stock isn't tracked.
+func availableQuantity(tx *gorm.DB, productID uint) (*int, error) {
+	var available sql.NullInt64
+
+	err := tx.Model(&domain.StockLevel{}).
+		Select("SUM(on_hand - reserved)").
+		Where("product_id = ?", productID).
+		Scan(&available).Error
+	if err != nil || !available.Valid {
+		return nil, err
+	}
+	quantity := int(available.Int64)
+	return &quantity, nil
+}
+
+// EvaluateStockNotifications alerts ops about products that have dropped
+// below their reorder threshold and tells subscribers about products that
+// are back in stock. It returns how many messages were sent. Each product
+// or subscription is marked only after its message is sent, so a failed
+// send is retried on the next evaluation.
+func (r *Repository) EvaluateStockNotifications(channel notification.Channel) (int, error) {
+	lowStock, err := r.notifyLowStock(channel)
+	if err != nil {
+		return lowStock, err
+	}
+	backInStock, err := r.notifyBackInStock(channel)
+	return lowStock + backInStock, err
+}
+
+func (r *Repository) notifyLowStock(channel notification.Channel) (int, error) {
+	var rows []struct {
+		ProductID uint
+		Name      string
+		Threshold int
+		Alerted   bool
+		Available int
+	}
+	err := r.db.Table("stock_thresholds").
+		Select("stock_thresholds.product_id, products.name, stock_thresholds.threshold, stock_thresholds.alerted, " +
+			"SUM(stock_levels.on_hand - stock_levels.reserved) AS available").
+		Joins("JOIN products ON products.id = stock_thresholds.product_id AND products.deleted_at IS NULL").
+		Joins("JOIN stock_levels ON stock_levels.product_id = stock_thresholds.product_id").
+		Group("stock_thresholds.product_id, products.name, stock_thresholds.threshold, stock_thresholds.alerted").
+		Order("stock_thresholds.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, row := range rows {
+		low := row.Available < row.Threshold
+		if low == row.Alerted {
+			continue
+		}
+
+		if low {
+			err := channel.Send(notification.Message{
+				Kind:      notification.KindLowStock,
+				Recipient: notification.OpsRecipient,
+				Subject:   fmt.Sprintf("Low stock: %s", row.Name),
+				Body: fmt.Sprintf("%s (product %d) has %d available, below its reorder threshold of %d.",
+					row.Name, row.ProductID, row.Available, row.Threshold),
+			})
+			if err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		err := r.db.Model(&domain.StockThreshold{}).
+			Where("product_id = ? AND threshold = ?", row.ProductID, row.Threshold).
+			Update("alerted", low).Error
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+func (r *Repository) notifyBackInStock(channel notification.Channel) (int, error) {
+	var rows []struct {
+		ID       uint
+		Name     string
+		UserName string
+	}
+	err := r.db.Table("stock_subscriptions").
+		Select("stock_subscriptions.id, products.name, users.user_name").
+		Joins("JOIN products ON products.id = stock_subscriptions.product_id AND products.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = stock_subscriptions.user_id AND users.deleted_at IS NULL").
+		Where("stock_subscriptions.notified_at IS NULL").
+		Where("COALESCE((SELECT SUM(stock_levels.on_hand - stock_levels.reserved) FROM stock_levels " +
+			"WHERE stock_levels.product_id = stock_subscriptions.product_id), 1) > 0").
+		Order("stock_subscriptions.id").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, row := range rows {
+		err := channel.Send(notification.Message{
+			Kind:      notification.KindBackInStock,
+			Recipient: row.UserName,
+			Subject:   fmt.Sprintf("%s is back in stock", row.Name),
+			Body:      fmt.Sprintf("%s is available again.", row.Name),
+		})
+		if err != nil {
+			return sent, err
+		}
+		sent++
+
+		err = r.db.Model(&domain.StockSubscription{}).
+			Where("id = ?", row.ID).
+			Update("notified_at", time.Now()).Error
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
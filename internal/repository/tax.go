@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/promotion"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+	"gorm.io/gorm"
+)
+
+func (r *Repository) GetTaxRates() ([]domain.TaxRate, error) {
+	var result []domain.TaxRate
+
+	if err := r.db.Order("country, region, category").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) CreateTaxRate(data domain.TaxRate) (*domain.TaxRate, error) {
+	rate := domain.TaxRate{
+		Country:  data.Country,
+		Region:   data.Region,
+		Category: data.Category,
+		Name:     data.Name,
+		Rate:     data.Rate,
+	}
+
+	if err := r.db.Create(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrTaxRateAlreadyExists
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *Repository) UpdateTaxRate(id uint, data domain.TaxRate) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rate, id).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&rate).Select("Country", "Region", "Category", "Name", "Rate").Updates(domain.TaxRate{
+			Country:  data.Country,
+			Region:   data.Region,
+			Category: data.Category,
+			Name:     data.Name,
+			Rate:     data.Rate,
+		}).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrTaxRateAlreadyExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *Repository) DeleteTaxRate(id uint) error {
+	result := r.db.Delete(&domain.TaxRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// calculator returns the calculator set with SetTaxCalculator, or else a
+// table of the tax rates in the database.
+func (r *Repository) calculator(tx *gorm.DB) (tax.Calculator, error) {
+	if r.taxCalculator != nil {
+		return r.taxCalculator, nil
+	}
+
+	var rates []domain.TaxRate
+	if err := tx.Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	table := tax.Table{Rates: make([]tax.Rate, len(rates))}
+	for i, rate := range rates {
+		table.Rates[i] = tax.Rate{
+			Country:  rate.Country,
+			Region:   rate.Region,
+			Category: rate.Category,
+			Name:     rate.Name,
+			Rate:     rate.Rate,
+		}
+	}
+	return table, nil
+}
+
+// applyTax works out the tax on the order's lines, after its discounts,
+// for the address, and records a tax line for each rate on each line.
+// Without an address, the order can't be taxed, which is
+// ErrTaxAddressRequired unless there are no tax rates to charge.
+func (r *Repository) applyTax(tx *gorm.DB, order *domain.Order, products map[uint]domain.Product, address *tax.Address) error {
+	calculator, err := r.calculator(tx)
+	if err != nil {
+		return err
+	}
+	if address == nil {
+		if table, ok := calculator.(tax.Table); ok && len(table.Rates) == 0 {
+			return nil
+		}
+		return ErrTaxAddressRequired
+	}
+
+	req := tax.Request{
+		Address:          *address,
+		Lines:            make([]tax.Line, len(order.Items)),
+		PricesIncludeTax: order.PricesIncludeTax,
+		Rounding:         r.taxRounding,
+	}
+	for i, amount := range discountedLineAmounts(*order) {
+		item := order.Items[i]
+		req.Lines[i] = tax.Line{
+			ProductID:   item.ProductID,
+			Category:    products[item.ProductID].TaxCategory,
+			AmountCents: amount,
+		}
+	}
+
+	result, err := calculator.Calculate(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTaxUnavailable, err)
+	}
+
+	order.TaxCountry = address.Country
+	order.TaxRegion = address.Region
+	order.TaxCents = result.TotalCents()
+	for _, line := range result.Lines {
+		order.Taxes = append(order.Taxes, domain.OrderTax{
+			ProductID:    line.ProductID,
+			Category:     line.Category,
+			Name:         line.Name,
+			Country:      line.Country,
+			Region:       line.Region,
+			Rate:         line.Rate,
+			TaxableCents: line.TaxableCents,
+			TaxCents:     line.TaxCents,
+		})
+	}
+	return nil
+}
+
+// discountedLineAmounts returns what each of the order's lines sells for
+// once its discounts are taken off. Discounts on a product come off its
+// line; discounts on the whole order are shared between the lines in
+// proportion to their amounts, with the odd cents going to the first
+// lines. Free shipping doesn't discount the lines.
+func discountedLineAmounts(order domain.Order) []int64 {
+	amounts := make([]int64, len(order.Items))
+	for i, item := range order.Items {
+		amounts[i] = item.LineTotalCents
+	}
+
+	var orderDiscount int64
+	for _, discount := range order.Discounts {
+		switch {
+		case discount.Kind == string(promotion.KindFreeShipping):
+		case discount.ProductID != nil:
+			for i, item := range order.Items {
+				if item.ProductID == *discount.ProductID {
+					amounts[i] -= min(discount.AmountCents, amounts[i])
+				}
+			}
+		default:
+			orderDiscount += discount.AmountCents
+		}
+	}
+
+	var total int64
+	for _, amount := range amounts {
+		total += amount
+	}
+	orderDiscount = min(orderDiscount, total)
+	if orderDiscount == 0 {
+		return amounts
+	}
+
+	shares := make([]int64, len(amounts))
+	left := orderDiscount
+	for i, amount := range amounts {
+		shares[i] = orderDiscount * amount / total
+		left -= shares[i]
+	}
+	for i := 0; left > 0; i++ {
+		if shares[i] < amounts[i] {
+			shares[i]++
+			left--
+		}
+	}
+	for i := range amounts {
+		amounts[i] -= shares[i]
+	}
+	return amounts
+}
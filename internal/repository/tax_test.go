@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"github.com/Hiroki111/go-backend-example/internal/money"
+	"github.com/Hiroki111/go-backend-example/internal/repository"
+	"github.com/Hiroki111/go-backend-example/internal/tax"
+)
+
+func TestCreateOrder_Tax(t *testing.T) {
+	tests := []struct {
+		name             string
+		pricesIncludeTax bool
+		rounding         string
+		taxCents         int64
+		totalCents       int64
+	}{
+		{name: "exclusive, half up", rounding: "half_up", taxCents: 139, totalCents: 1500},
+		{name: "exclusive, half even", rounding: "half_even", taxCents: 138, totalCents: 1499},
+		{name: "exclusive, down", rounding: "down", taxCents: 137, totalCents: 1498},
+		{name: "exclusive, up", rounding: "up", taxCents: 140, totalCents: 1501},
+		{name: "inclusive, half up", pricesIncludeTax: true, rounding: "half_up", taxCents: 123, totalCents: 1361},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, _ := setupRepository(t)
+			repo.SetPricesIncludeTax(test.pricesIncludeTax)
+			if err := repo.SetTaxRounding(test.rounding); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, rate := range []domain.TaxRate{
+				{Country: "DE", Name: "VAT", Rate: "0.19"},
+				{Country: "DE", Category: "reduced", Name: "VAT (reduced)", Rate: "0.07"},
+			} {
+				if _, err := repo.CreateTaxRate(rate); err != nil {
+					t.Fatalf("failed to create tax rate: %v", err)
+				}
+			}
+
+			// 0.19 of 150 is 28.5, of 110 is 20.9 and of 101 is 19.19.
+			var items []repository.OrderItemInput
+			for _, product := range []domain.Product{
+				{Name: "book", PriceCents: 1000, TaxCategory: "reduced"},
+				{Name: "pen", PriceCents: 150},
+				{Name: "clip", PriceCents: 110},
+				{Name: "pin", PriceCents: 101},
+			} {
+				created, err := repo.CreateProduct(product, 0)
+				if err != nil {
+					t.Fatalf("failed to create product: %v", err)
+				}
+				items = append(items, repository.OrderItemInput{ProductID: created.ID, Quantity: 1})
+			}
+
+			order, err := repo.CreateOrder(1, items, money.Identity(repo.BaseCurrency()), repository.OrderOptions{
+				TaxAddress: &tax.Address{Country: "DE"},
+			})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+			if order.TaxCents != test.taxCents || order.TotalCents != test.totalCents || len(order.Taxes) != 4 {
+				t.Fatalf("expected %d tax and a total of %d, got %+v", test.taxCents, test.totalCents, order)
+			}
+			if book := order.Taxes[0]; book.Name != "VAT (reduced)" || book.Rate != "0.07" {
+				t.Fatalf("expected the reduced rate on the book, got %+v", book)
+			}
+			if book := order.Taxes[0]; test.pricesIncludeTax && book.TaxableCents+book.TaxCents != 1000 {
+				t.Fatalf("expected the tax to be part of the book's price, got %+v", book)
+			}
+		})
+	}
+}
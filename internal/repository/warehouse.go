@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Hiroki111/go-backend-example/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repository) GetWarehouses() ([]domain.Warehouse, error) {
+	var result []domain.Warehouse
+
+	if err := r.db.Order("priority, id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) CreateWarehouse(data domain.Warehouse) (*domain.Warehouse, error) {
+	warehouse := domain.Warehouse{
+		Name:       data.Name,
+		Street:     data.Street,
+		City:       data.City,
+		PostalCode: data.PostalCode,
+		Country:    data.Country,
+		Latitude:   data.Latitude,
+		Longitude:  data.Longitude,
+		Priority:   data.Priority,
+	}
+
+	if err := r.db.Create(&warehouse).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrWarehouseAlreadyExists
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *Repository) UpdateWarehouse(id uint, data domain.Warehouse) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&warehouse, id).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&warehouse).Select(
+			"Name", "Street", "City", "PostalCode", "Country", "Latitude", "Longitude", "Priority",
+		).Updates(domain.Warehouse{
+			Name:       data.Name,
+			Street:     data.Street,
+			City:       data.City,
+			PostalCode: data.PostalCode,
+			Country:    data.Country,
+			Latitude:   data.Latitude,
+			Longitude:  data.Longitude,
+			Priority:   data.Priority,
+		}).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrWarehouseAlreadyExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
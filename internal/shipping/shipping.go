@@ -0,0 +1,167 @@
+// Package shipping works out which shipping methods can take a parcel to
+// an address, and what they cost.
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Basis is what a method's rate table is looked up by.
+type Basis string
+
+const (
+	BasisWeight Basis = "weight"
+	BasisPrice  Basis = "price"
+)
+
+// ErrUnavailable is wrapped with an explanation when a method can't ship
+// a parcel to an address.
+var ErrUnavailable = errors.New("shipping method unavailable")
+
+func (b Basis) IsValid() bool {
+	return b == BasisWeight || b == BasisPrice
+}
+
+// Rate is a row of a rate table. It covers parcels up to UpTo grams or
+// cents, inclusive, or any parcel when UpTo is nil.
+type Rate struct {
+	UpTo      *int64
+	CostCents int64
+}
+
+// Method is a way of shipping to a zone, priced by its rate table. Costs
+// are in the base currency.
+type Method struct {
+	ID    uint
+	Name  string
+	Basis Basis
+	Rates []Rate
+}
+
+// Location is a country, or a region within it when Region is set.
+type Location struct {
+	Country string
+	Region  string
+}
+
+// Zone is the set of locations its methods ship to.
+type Zone struct {
+	ID        uint
+	Name      string
+	Locations []Location
+	Methods   []Method
+}
+
+// Parcel is what is being shipped: its weight and the price of its
+// contents in the base currency.
+type Parcel struct {
+	WeightGrams   int64
+	SubtotalCents int64
+}
+
+// Quote is what a method costs for a parcel.
+type Quote struct {
+	Method    Method
+	CostCents int64
+}
+
+// ValidateRates checks that a rate table covers parcels in increasing
+// order, with at most the last row having no limit.
+func ValidateRates(rates []Rate) error {
+	if len(rates) == 0 {
+		return errors.New("at least one rate required")
+	}
+	var previous int64 = -1
+	for i, rate := range rates {
+		if rate.CostCents < 0 {
+			return errors.New("cost_cents must not be negative")
+		}
+		if rate.UpTo == nil {
+			if i != len(rates)-1 {
+				return errors.New("only the last rate can have no limit")
+			}
+			continue
+		}
+		if *rate.UpTo <= previous {
+			return errors.New("rate limits must increase")
+		}
+		previous = *rate.UpTo
+	}
+	return nil
+}
+
+// Cost returns what the method charges for the parcel, or false when no
+// row of its rate table covers it.
+func (m Method) Cost(parcel Parcel) (int64, bool) {
+	value := parcel.WeightGrams
+	if m.Basis == BasisPrice {
+		value = parcel.SubtotalCents
+	}
+	for _, rate := range m.Rates {
+		if rate.UpTo == nil || value <= *rate.UpTo {
+			return rate.CostCents, true
+		}
+	}
+	return 0, false
+}
+
+// Match returns the zone that covers the address most closely: one that
+// lists its region wins over one that lists the whole country, and
+// otherwise the first zone wins. It returns false when no zone covers it.
+func Match(zones []Zone, country, region string) (Zone, bool) {
+	best, bestScore := Zone{}, 0
+	for _, zone := range zones {
+		for _, location := range zone.Locations {
+			if !strings.EqualFold(location.Country, country) {
+				continue
+			}
+			score := 1
+			if location.Region != "" {
+				if !strings.EqualFold(location.Region, region) {
+					continue
+				}
+				score = 2
+			}
+			if score > bestScore {
+				best, bestScore = zone, score
+			}
+		}
+	}
+	return best, bestScore > 0
+}
+
+// Quotes lists the methods of the zone covering the address that can
+// take the parcel, in the zone's order.
+func Quotes(zones []Zone, country, region string, parcel Parcel) []Quote {
+	zone, ok := Match(zones, country, region)
+	if !ok {
+		return nil
+	}
+	var quotes []Quote
+	for _, method := range zone.Methods {
+		if cost, ok := method.Cost(parcel); ok {
+			quotes = append(quotes, Quote{Method: method, CostCents: cost})
+		}
+	}
+	return quotes
+}
+
+// Find returns the quote for the method with the given ID, or an error
+// wrapping ErrUnavailable when it can't take the parcel to the address.
+func Find(zones []Zone, country, region string, parcel Parcel, methodID uint) (Quote, error) {
+	for _, quote := range Quotes(zones, country, region, parcel) {
+		if quote.Method.ID == methodID {
+			return quote, nil
+		}
+	}
+	return Quote{}, fmt.Errorf("%w: method %d doesn't ship this order to %s", ErrUnavailable, methodID, location(country, region))
+}
+
+func location(country, region string) string {
+	if region == "" {
+		return country
+	}
+	return region + ", " + country
+}
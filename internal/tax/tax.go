@@ -0,0 +1,228 @@
+// Package tax works out the tax on an order's lines.
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Address is the jurisdiction an order is taxed in: a two-letter country
+// code and, optionally, a region within it such as a state or province.
+type Address struct {
+	Country string
+	Region  string
+}
+
+// Rounding is how the tax on each line is rounded to a whole minor unit.
+type Rounding string
+
+const (
+	// RoundHalfUp rounds to the nearest unit, with halves away from zero.
+	RoundHalfUp Rounding = "half_up"
+	// RoundHalfEven rounds to the nearest unit, with halves to the even
+	// one.
+	RoundHalfEven Rounding = "half_even"
+	RoundDown     Rounding = "down"
+	RoundUp       Rounding = "up"
+)
+
+// ParseRounding returns the rounding with the given name.
+func ParseRounding(name string) (Rounding, error) {
+	switch rounding := Rounding(name); rounding {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return rounding, nil
+	}
+	return "", fmt.Errorf("unknown tax rounding %q", name)
+}
+
+// Line is an order line to tax. AmountCents is what the line sells for,
+// after discounts. Category is the product's tax category; empty is the
+// standard category.
+type Line struct {
+	ProductID   uint
+	Category    string
+	AmountCents int64
+}
+
+// Request asks for the tax on lines sold to an address. When
+// PricesIncludeTax is set the line amounts already include the tax, which
+// is worked out of them; otherwise it is added on top.
+type Request struct {
+	Address          Address
+	Lines            []Line
+	PricesIncludeTax bool
+	Rounding         Rounding
+}
+
+// LineTax is the tax one rate puts on one line. Line is the line's index
+// in the request. Rate is a decimal fraction, such as "0.0825".
+type LineTax struct {
+	Line         int
+	ProductID    uint
+	Category     string
+	Name         string
+	Country      string
+	Region       string
+	Rate         string
+	TaxableCents int64
+	TaxCents     int64
+}
+
+type Result struct {
+	Lines []LineTax
+}
+
+func (r Result) TotalCents() int64 {
+	var total int64
+	for _, line := range r.Lines {
+		total += line.TaxCents
+	}
+	return total
+}
+
+// Calculator works out the tax for a request. Table is the built-in one;
+// an external tax service can be plugged in behind the same interface.
+type Calculator interface {
+	Calculate(req Request) (Result, error)
+}
+
+// ParseRate parses a tax rate written as a decimal fraction between 0 and
+// 1, such as "0.0825" for 8.25%.
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, errors.New("rate must be a decimal between 0 and 1")
+	}
+	return rate, nil
+}
+
+// Rate is a tax rate for a jurisdiction: a whole country when Region is
+// empty, or a region within it. A rate with a Category only applies to
+// that tax category, and takes the place of the jurisdiction's rate
+// without one.
+type Rate struct {
+	Country  string
+	Region   string
+	Category string
+	Name     string
+	Rate     string
+}
+
+// Table taxes lines with a list of rates. A line is taxed by its country
+// and by its region, when they have rates, and the two add up, the way
+// state taxes come on top of federal ones. A category with no rate of its
+// own in a jurisdiction gets the jurisdiction's standard rate.
+type Table struct {
+	Rates []Rate
+}
+
+func (t Table) Calculate(req Request) (Result, error) {
+	var result Result
+	for i, line := range req.Lines {
+		rates := t.ratesFor(req.Address, line.Category)
+
+		total := new(big.Rat)
+		parsed := make([]*big.Rat, len(rates))
+		for j, rate := range rates {
+			value, err := ParseRate(rate.Rate)
+			if err != nil {
+				return Result{}, fmt.Errorf("tax rate %s: %w", rate.Name, err)
+			}
+			parsed[j] = value
+			total.Add(total, value)
+		}
+
+		// With tax included, the amount is the net amount times 1 + total,
+		// and the net amount is what is left once the tax is taken out.
+		base := new(big.Rat).SetInt64(line.AmountCents)
+		if req.PricesIncludeTax {
+			base.Quo(base, new(big.Rat).Add(big.NewRat(1, 1), total))
+		}
+		taxes := make([]LineTax, len(rates))
+		taxable := line.AmountCents
+		for j, rate := range rates {
+			taxes[j] = LineTax{
+				Line:      i,
+				ProductID: line.ProductID,
+				Category:  line.Category,
+				Name:      rate.Name,
+				Country:   rate.Country,
+				Region:    rate.Region,
+				Rate:      rate.Rate,
+				TaxCents:  round(new(big.Rat).Mul(base, parsed[j]), req.Rounding),
+			}
+			if req.PricesIncludeTax {
+				taxable -= taxes[j].TaxCents
+			}
+		}
+		for j := range taxes {
+			taxes[j].TaxableCents = taxable
+		}
+		result.Lines = append(result.Lines, taxes...)
+	}
+	return result, nil
+}
+
+// ratesFor returns the country's rate and then the region's, for the
+// category.
+func (t Table) ratesFor(address Address, category string) []Rate {
+	var rates []Rate
+	if rate, ok := t.lookup(address.Country, "", category); ok {
+		rates = append(rates, rate)
+	}
+	if address.Region != "" {
+		if rate, ok := t.lookup(address.Country, address.Region, category); ok {
+			rates = append(rates, rate)
+		}
+	}
+	return rates
+}
+
+func (t Table) lookup(country, region, category string) (Rate, bool) {
+	var standard *Rate
+	for i, rate := range t.Rates {
+		if !strings.EqualFold(rate.Country, country) || !strings.EqualFold(rate.Region, region) {
+			continue
+		}
+		if rate.Category == category {
+			return rate, true
+		}
+		if rate.Category == "" {
+			standard = &t.Rates[i]
+		}
+	}
+	if standard == nil {
+		return Rate{}, false
+	}
+	return *standard, true
+}
+
+func round(value *big.Rat, rounding Rounding) int64 {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if remainder.Sign() != 0 {
+		twice := new(big.Int).Mul(remainder, big.NewInt(2))
+		var up bool
+		switch rounding {
+		case RoundDown:
+		case RoundUp:
+			up = true
+		case RoundHalfEven:
+			cmp := twice.Cmp(den)
+			up = cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1)
+		default:
+			up = twice.Cmp(den) >= 0
+		}
+		if up {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}